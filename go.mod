@@ -7,10 +7,10 @@ require (
 	github.com/google/go-cmp v0.5.8
 	github.com/google/uuid v1.6.0
 	github.com/json-iterator/go v1.1.12
-	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.14.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.8.0
+	go.etcd.io/bbolt v1.3.6
 )
 
 require (
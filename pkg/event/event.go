@@ -69,6 +69,10 @@ type Event struct {
 	// DataSchema - A link to the schema that the `Data` attribute adheres to.
 	// +optional
 	DataSchema *types.URI `json:"dataSchema,omitempty"`
+	// Extensions - CloudEvents extension attributes keyed by their lower-case name, the values
+	// have the CloudEvents types set by SetExtension.
+	// +optional
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
 	// +required
 	Data *Data `json:"data" `
 }
@@ -23,37 +23,96 @@ import (
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
 )
 
-// NewCloudEvent create new cloud event from cloud native events and pubsub
-func (e *Event) NewCloudEvent(ps *pubsub.PubSub) (*cloudevent.Event, error) {
-	ce := cloudevent.NewEvent(cloudevent.VersionV03)
-	ce.SetTime(e.GetTime())
-	ce.SetType(e.Type)
-	ce.SetDataContentType(cloudevent.ApplicationJSON)
-	ce.SetSubject(e.Source)   // subject is set to source of the event object
-	ce.SetSource(ps.Resource) // bus address
-	ce.SetSpecVersion(cloudevent.VersionV03)
-	ce.SetID(uuid.New().String())
-	if err := ce.SetData(cloudevent.ApplicationJSON, e.GetData()); err != nil {
-		return nil, err
+// CloudEventOption configures the conversion of an Event to a CloudEvent
+type CloudEventOption func(*cloudEventOptions)
+
+type cloudEventOptions struct {
+	busAddress         string
+	defaultContentType string
+}
+
+// WithBusAddress sets the CloudEvent source to the bus address the event is published on and
+// moves the event source to the CloudEvent subject
+func WithBusAddress(address string) CloudEventOption {
+	return func(o *cloudEventOptions) {
+		o.busAddress = address
 	}
-	return &ce, nil
 }
 
-// NewCloudEvent create new cloud event from cloud native events and pubsub
-func (e *Event) NewCloudEventV2() (*cloudevent.Event, error) {
+// WithDefaultDataContentType sets the CloudEvent data content type of events without one
+func WithDefaultDataContentType(contentType string) CloudEventOption {
+	return func(o *cloudEventOptions) {
+		o.defaultContentType = contentType
+	}
+}
+
+// ToCloudEvent converts the event to a CloudEvents 1.0 event. The conversion is lossless,
+// GetCloudNativeEvents restores the id, type, source, time, data content type, data schema,
+// extension attributes and data of the event. The event source is the CloudEvent source unless
+// WithBusAddress is given. An event without id is given a new one.
+func (e *Event) ToCloudEvent(opts ...CloudEventOption) (*cloudevent.Event, error) {
+	o := cloudEventOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	ce := cloudevent.NewEvent(cloudevent.VersionV1)
-	ce.SetTime(e.GetTime())
+	id := e.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+	ce.SetID(id)
 	ce.SetType(e.Type)
-	ce.SetSource(e.Source)
-	ce.SetSpecVersion(cloudevent.VersionV1)
-	ce.SetID(uuid.New().String())
-	if err := ce.SetData("", e.GetData()); err != nil {
+	if o.busAddress != "" {
+		ce.SetSource(o.busAddress)
+		ce.SetSubject(e.Source)
+	} else {
+		ce.SetSource(e.Source)
+	}
+	if e.Time != nil {
+		ce.SetTime(e.Time.Time)
+	}
+	if e.DataSchema != nil {
+		ce.SetDataSchema(e.DataSchema.String())
+	}
+	for name, value := range e.Extensions {
+		if err := ce.Context.SetExtension(name, value); err != nil {
+			return nil, fmt.Errorf("invalid extension %s: %w", name, err)
+		}
+	}
+	if e.DataContentType != nil {
+		ce.SetDataContentType(*e.DataContentType)
+	} else if o.defaultContentType != "" {
+		ce.SetDataContentType(o.defaultContentType)
+	}
+	if e.Data != nil {
+		// the data is JSON whatever the content type, SetData would pick a codec from it
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		ce.DataEncoded = data
+	}
+	if err := ce.Validate(); err != nil {
 		return nil, err
 	}
 	return &ce, nil
 }
 
-// GetCloudNativeEvents  get event data from cloud events object if its valid else return error
+// NewCloudEvent create new cloud event from cloud native events and pubsub, the CloudEvent source is
+// the pubsub resource and the subject is the event source. The data content type defaults to
+// application/json.
+func (e *Event) NewCloudEvent(ps *pubsub.PubSub) (*cloudevent.Event, error) {
+	return e.ToCloudEvent(WithBusAddress(ps.Resource), WithDefaultDataContentType(ApplicationJSON))
+}
+
+// NewCloudEventV2 create new cloud event from cloud native events, the CloudEvent source is the
+// event source
+func (e *Event) NewCloudEventV2() (*cloudevent.Event, error) {
+	return e.ToCloudEvent()
+}
+
+// GetCloudNativeEvents  get event data from cloud events object if its valid else return error.
+// The subject of the cloud event, when set, is the event source. It reverses ToCloudEvent.
 func (e *Event) GetCloudNativeEvents(ce *cloudevent.Event) (err error) {
 	if ce.Data() == nil {
 		return fmt.Errorf("event data is empty")
@@ -62,7 +121,19 @@ func (e *Event) GetCloudNativeEvents(ce *cloudevent.Event) (err error) {
 	if err = json.Unmarshal(ce.Data(), &data); err != nil {
 		return
 	}
-	e.SetDataContentType(ApplicationJSON)
+	e.DataSchema = nil
+	if schema := ce.DataSchema(); schema != "" {
+		if err = e.SetDataSchema(schema); err != nil {
+			return
+		}
+	}
+	e.Extensions = nil
+	for name, value := range ce.Extensions() {
+		if err = e.SetExtension(name, value); err != nil {
+			return
+		}
+	}
+	e.SetDataContentType(ce.DataContentType())
 	e.SetTime(ce.Time())
 	e.SetType(ce.Type())
 	if ce.Subject() != "" {
@@ -72,5 +143,5 @@ func (e *Event) GetCloudNativeEvents(ce *cloudevent.Event) (err error) {
 	}
 	e.SetData(data)
 	e.SetID(ce.ID())
-	return
+	return e.validateDataSchema()
 }
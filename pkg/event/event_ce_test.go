@@ -16,11 +16,15 @@ package event_test
 
 import (
 	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing/quick"
 
 	ce "github.com/cloudevents/sdk-go/v2"
 	"github.com/google/uuid"
 	cneevent "github.com/redhat-cne/sdk-go/pkg/event"
 	"github.com/redhat-cne/sdk-go/pkg/event/ptp"
+	"github.com/redhat-cne/sdk-go/pkg/event/schema"
 	cnepubsub "github.com/redhat-cne/sdk-go/pkg/pubsub"
 	"github.com/redhat-cne/sdk-go/pkg/types"
 	cneeventv1 "github.com/redhat-cne/sdk-go/v1/event"
@@ -84,7 +88,7 @@ func TestEvent_NewCloudEvent(t *testing.T) {
 			cnePubsub: &pubsub,
 			want: func() *ce.Event {
 				e := ce.NewEvent()
-				e.SetSpecVersion(ce.VersionV03)
+				e.SetSpecVersion(ce.VersionV1)
 				e.SetType(_type)
 				_ = e.SetData(ce.ApplicationJSON, data)
 				e.SetTime(now.Time)
@@ -101,7 +105,7 @@ func TestEvent_NewCloudEvent(t *testing.T) {
 			event := tc.cneEvent
 			cEvent, err := event.NewCloudEvent(tc.cnePubsub)
 			assert.Nil(t, err)
-			tc.want.SetID(cEvent.ID())
+			assert.Equal(t, tc.want.ID(), cEvent.ID())
 			gotBytes, err := json.Marshal(cEvent)
 			log.Printf("cloud events %s\n", string(gotBytes))
 			if tc.wantErr != nil {
@@ -181,3 +185,179 @@ func assertCNEJsonEquals(t *testing.T, want *cneevent.Event, got []byte) {
 
 	require.Equal(t, wantToCompare, gotToCompare)
 }
+
+// genEvent generates events that use every attribute kept by the CloudEvents conversion
+type genEvent struct {
+	event cneevent.Event
+}
+
+func genName(r *rand.Rand, min, max int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, min+r.Intn(max-min+1))
+	for i := range b {
+		b[i] = letters[r.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+func genPath(r *rand.Rand) string {
+	path := ""
+	for i := 0; i <= r.Intn(4); i++ {
+		path += "/" + genName(r, 1, 8)
+	}
+	return path
+}
+
+// Generate implements quick.Generator
+func (genEvent) Generate(r *rand.Rand, _ int) reflect.Value {
+	e := cneeventv1.CloudNativeEvent()
+	e.SetID(uuid.New().String())
+	e.SetType("event.sync." + genName(r, 1, 20))
+	e.SetSource(genPath(r))
+	switch r.Intn(3) {
+	case 1:
+		e.SetDataContentType(cneevent.ApplicationJSON)
+	case 2:
+		e.SetDataContentType(cneevent.TextJSON)
+	}
+	if r.Intn(4) > 0 {
+		e.SetTime(time.Unix(r.Int63n(1<<33), r.Int63n(int64(time.Second))).UTC())
+	}
+	if r.Intn(2) == 0 {
+		_ = e.SetDataSchema("https://" + genName(r, 1, 10) + ".example.com" + genPath(r))
+	}
+	for i := 0; i < r.Intn(4); i++ {
+		name := "x" + genName(r, 0, 10)
+		switch r.Intn(3) {
+		case 0:
+			_ = e.SetExtension(name, genName(r, 0, 20))
+		case 1:
+			_ = e.SetExtension(name, r.Int31())
+		default:
+			_ = e.SetExtension(name, r.Intn(2) == 0)
+		}
+	}
+	d := cneevent.Data{}
+	_ = d.SetVersion("v" + genName(r, 1, 3))
+	for i := 0; i < r.Intn(4); i++ {
+		v := cneevent.DataValue{Resource: genPath(r), DataType: cneevent.NOTIFICATION, ValueType: cneevent.ENUMERATION,
+			Value: genName(r, 1, 12)}
+		if r.Intn(2) == 0 {
+			v.DataType, v.ValueType, v.Value = cneevent.METRIC, cneevent.DECIMAL, r.NormFloat64()*1e3
+		}
+		d.AppendValues(v)
+	}
+	e.SetData(d)
+	return reflect.ValueOf(genEvent{event: e})
+}
+
+func TestEvent_CloudEventRoundTrip(t *testing.T) {
+	for name, opts := range map[string][]cneevent.CloudEventOption{
+		"source":      nil,
+		"bus address": {cneevent.WithBusAddress("/cluster/node/ptp")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			roundTrip := func(g genEvent) bool {
+				ce, err := g.event.ToCloudEvent(opts...)
+				if err != nil {
+					t.Logf("conversion of %s failed: %v", g.event.JSONString(), err)
+					return false
+				}
+				var got cneevent.Event
+				if err = got.GetCloudNativeEvents(ce); err != nil {
+					t.Logf("conversion of %s failed: %v", ce, err)
+					return false
+				}
+				return assert.Equal(t, g.event, got)
+			}
+			assert.Nil(t, quick.Check(roundTrip, &quick.Config{MaxCount: 500}))
+		})
+	}
+}
+
+func TestEvent_RoundTripWithDataSchemas(t *testing.T) {
+	schema.NewRegistry("").Install()
+	t.Cleanup(func() { cneevent.SetDataSchemas(nil) })
+	roundTrip := func(g genEvent) bool {
+		ce, err := g.event.ToCloudEvent()
+		if err != nil {
+			t.Logf("conversion of %s failed: %v", g.event.JSONString(), err)
+			return false
+		}
+		var got cneevent.Event
+		if err = got.GetCloudNativeEvents(ce); err != nil {
+			t.Logf("conversion of %s failed: %v", ce, err)
+			return false
+		}
+		if !assert.Equal(t, g.event, got) {
+			return false
+		}
+		if ct := g.event.DataContentType; ct != nil && *ct == cneevent.TextJSON {
+			// not written by WriteJSON
+			return true
+		}
+		b, err := json.Marshal(g.event)
+		if err != nil {
+			t.Logf("encoding of %s failed: %v", g.event.JSONString(), err)
+			return false
+		}
+		var decoded cneevent.Event
+		if err = json.Unmarshal(b, &decoded); err != nil {
+			t.Logf("decoding of %s failed: %v", b, err)
+			return false
+		}
+		return assert.Equal(t, g.event.DataSchema, decoded.DataSchema)
+	}
+	assert.Nil(t, quick.Check(roundTrip, &quick.Config{MaxCount: 500}))
+}
+
+func TestEvent_ToCloudEvent(t *testing.T) {
+	setup()
+	e := cneeventv1.CloudNativeEvent()
+	e.SetType(_type)
+	e.SetSource(_source)
+	e.SetData(data)
+	assert.Nil(t, e.SetExtension("Cluster", "east"))
+	assert.Nil(t, e.SetExtension("sequence", int64(7)))
+	assert.Equal(t, map[string]interface{}{"cluster": "east", "sequence": int32(7)}, e.GetExtensions())
+	assert.Error(t, e.SetExtension("subject", "x"))
+	assert.Error(t, e.SetExtension("not-valid", "x"))
+	assert.Nil(t, e.SetExtension("sequence", nil))
+
+	c, err := e.ToCloudEvent()
+	assert.Nil(t, err)
+	assert.Equal(t, ce.VersionV1, c.SpecVersion())
+	// an event without id is given one
+	assert.NotEmpty(t, c.ID())
+	assert.Equal(t, _source, c.Source())
+	assert.Equal(t, "", c.Subject())
+	assert.Equal(t, "", c.DataContentType())
+	assert.Equal(t, map[string]interface{}{"cluster": "east"}, c.Extensions())
+	c, err = e.NewCloudEvent(&pubsub)
+	assert.Nil(t, err)
+	assert.Equal(t, pubsub.GetResource(), c.Source())
+	assert.Equal(t, _source, c.Subject())
+	assert.Equal(t, cneevent.ApplicationJSON, c.DataContentType())
+	e.SetDataContentType(cneevent.TextJSON)
+	c, err = e.NewCloudEvent(&pubsub)
+	assert.Nil(t, err)
+	assert.Equal(t, cneevent.TextJSON, c.DataContentType())
+	e.DataContentType = nil
+
+	// extensions set without SetExtension are still checked
+	e.Extensions["not-valid"] = "x"
+	_, err = e.ToCloudEvent()
+	assert.Error(t, err)
+	delete(e.Extensions, "not-valid")
+
+	// a cloud event must have a type and a source
+	_, err = (&cneevent.Event{Data: &data}).ToCloudEvent()
+	assert.Error(t, err)
+
+	// the extensions are kept by the event json
+	b, err := json.Marshal(e)
+	assert.Nil(t, err)
+	var decoded cneevent.Event
+	assert.Nil(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, e.GetExtensions(), decoded.GetExtensions())
+}
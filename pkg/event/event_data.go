@@ -43,6 +43,18 @@ const (
 	DECIMAL ValueType = "decimal64.3"
 	// REDFISH_EVENT ...
 	REDFISH_EVENT ValueType = "redfish-event" //nolint:all
+	// INTEGER a signed 64-bit integer encoded as a JSON number, decoded as int64
+	INTEGER ValueType = "integer"
+	// BOOLEAN a JSON boolean, decoded as bool
+	BOOLEAN ValueType = "boolean"
+	// STRING a JSON string, decoded as string
+	STRING ValueType = "string"
+	// TIMESTAMP an RFC 3339 time encoded as a JSON string, decoded as time.Time
+	TIMESTAMP ValueType = "timestamp"
+	// DURATION a duration such as "1m30s" encoded as a JSON string, decoded as time.Duration
+	DURATION ValueType = "duration"
+	// OBJECT a nested JSON object, decoded as map[string]interface{}
+	OBJECT ValueType = "object"
 )
 
 // Data
@@ -91,13 +103,15 @@ type Data struct {
 //
 // ```
 type DataValue struct {
-	// The resource address specifies the Event Producer with a hierarchical path. Currently hierarchical paths with wild cards are not supported.
+	// The resource address specifies the Event Producer with a hierarchical path. Subscriptions may use the
+	// wildcard segments `*` (exactly one segment) and `**` (any number of segments), e.g. /cluster/node/*/sync/** .
 	// example: /east-edge-10/Node3/sync/sync-status/sync-state
 	Resource string `json:"ResourceAddress" example:"/east-edge-10/Node3/sync/sync-status/sync-state"`
 	// Type of value object. ( notification | metric)
 	// example: notification
 	DataType DataType `json:"data_type" example:"notification"`
-	// The type format of the value property.
+	// The type format of the value property, one of the built-in value types or a type added with
+	// RegisterValueType.
 	// example: enumeration
 	ValueType ValueType `json:"value_type" example:"enumeration"`
 	// value in value_type format.
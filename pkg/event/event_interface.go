@@ -32,6 +32,8 @@ type Reader interface {
 	GetDataContentType() string
 	// GetData returns event.GetData()
 	GetData() *Data
+	// GetExtensions returns event.GetExtensions()
+	GetExtensions() map[string]interface{}
 	// Clone clones the event .
 	Clone() Event
 	// String returns a pretty-printed representation of the EventContext.
@@ -52,6 +54,8 @@ type Writer interface {
 	SetDataSchema(string) error
 	// SetDataContentType performs event.SetDataContentType.
 	SetDataContentType(string)
+	// SetExtension performs event.SetExtension.
+	SetExtension(string, interface{}) error
 	// SetData
 	SetData(Data)
 }
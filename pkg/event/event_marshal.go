@@ -20,50 +20,57 @@ import (
 	"io"
 
 	jsoniter "github.com/json-iterator/go"
-	"github.com/redhat-cne/sdk-go/pkg/event/redfish"
 )
 
 // WriteJSON writes the in event in the provided writer.
-// Note: this function assumes the input event is valid.
+// Note: this function assumes the input event is valid, unless SetValidateOnMarshal is enabled.
 func WriteJSON(in *Event, writer io.Writer) error {
+	if validateMarshal.Load() {
+		if err := in.Validate(); err != nil {
+			return err
+		}
+	}
 	stream := jsoniter.ConfigFastest.BorrowStream(writer)
 	defer jsoniter.ConfigFastest.ReturnStream(stream)
 	stream.WriteObjectStart()
 
-	if in.DataContentType != nil {
-		switch in.GetDataContentType() {
-		case ApplicationJSON:
-			stream.WriteObjectField("id")
-			stream.WriteString(in.ID)
-			stream.WriteMore()
+	// the data is JSON when no content type is set
+	if in.DataContentType != nil && in.GetDataContentType() != ApplicationJSON {
+		return fmt.Errorf("unsupported event content type %s", in.GetDataContentType())
+	}
+	stream.WriteObjectField("id")
+	stream.WriteString(in.ID)
+	stream.WriteMore()
 
-			stream.WriteObjectField("type")
-			stream.WriteString(in.GetType())
-			stream.WriteMore()
+	stream.WriteObjectField("type")
+	stream.WriteString(in.GetType())
+	stream.WriteMore()
 
-			stream.WriteObjectField("source")
-			stream.WriteString(in.GetSource())
+	stream.WriteObjectField("source")
+	stream.WriteString(in.GetSource())
 
-			if in.GetDataContentType() != "" {
-				stream.WriteMore()
-				stream.WriteObjectField("dataContentType")
-				stream.WriteString(in.GetDataContentType())
-			}
+	if in.DataContentType != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("dataContentType")
+		stream.WriteString(in.GetDataContentType())
+	}
 
-			if in.Time != nil {
-				stream.WriteMore()
-				stream.WriteObjectField("time")
-				stream.WriteString(in.Time.String())
-			}
+	if in.Time != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("time")
+		stream.WriteString(in.Time.String())
+	}
 
-			if in.GetDataSchema() != "" {
-				stream.WriteMore()
-				stream.WriteObjectField("dataSchema")
-				stream.WriteString(in.GetDataSchema())
-			}
-		default:
-			return fmt.Errorf("missing event content type")
-		}
+	if in.DataSchema != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("dataSchema")
+		stream.WriteString(in.GetDataSchema())
+	}
+
+	if len(in.Extensions) > 0 {
+		stream.WriteMore()
+		stream.WriteObjectField("extensions")
+		stream.WriteVal(in.Extensions)
 	}
 
 	// Let's do a check on the error
@@ -133,25 +140,11 @@ func writeJSONData(in *Data, writer io.Writer, stream *jsoniter.Stream) error {
 			stream.WriteString(string(v.ValueType))
 			stream.WriteMore()
 			stream.WriteObjectField("value")
-			switch v.ValueType {
-			case ENUMERATION:
-				// if type is a string
-				stream.WriteString(fmt.Sprintf("%v", v.Value))
-
-			case DECIMAL:
-				stream.WriteString(fmt.Sprintf("%v", v.Value))
-
-			case REDFISH_EVENT:
-				redfishEvent, ok := (v.Value).(redfish.Event)
-				if ok {
-					if err := redfish.WriteJSONEvent(&redfishEvent, writer, stream); err != nil {
-						return fmt.Errorf("error writing data: %w", err)
-					}
-				}
-			default:
-				// if type is other than above
-				return fmt.Errorf("error while writing the value attributes: unknown type")
+			b, err := EncodeValue(&v)
+			if err != nil {
+				return fmt.Errorf("error while writing the value attributes: %w", err)
 			}
+			stream.WriteRaw(string(b))
 			stream.WriteObjectEnd()
 		}
 		stream.WriteArrayEnd()
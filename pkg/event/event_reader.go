@@ -57,3 +57,8 @@ func (e *Event) GetDataContentType() string {
 func (e *Event) GetData() *Data {
 	return e.Data
 }
+
+// GetExtensions implements Reader.GetExtensions
+func (e *Event) GetExtensions() map[string]interface{} {
+	return e.Extensions
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"sync/atomic"
+)
+
+// DataSchemas provides the schemas of the data of events, see package schema
+type DataSchemas interface {
+	// Validate checks the data of e against the schema referenced by e.DataSchema, schemas it
+	// does not know are not checked
+	Validate(e *Event) error
+}
+
+type dataSchemasHolder struct {
+	DataSchemas
+}
+
+var dataSchemas atomic.Pointer[dataSchemasHolder]
+
+// SetDataSchemas installs the schemas used to validate the events read with a data schema. The
+// events written are left as they are, their data schema is set by the sender. A nil s removes them.
+func SetDataSchemas(s DataSchemas) {
+	if s == nil {
+		dataSchemas.Store(nil)
+		return
+	}
+	dataSchemas.Store(&dataSchemasHolder{s})
+}
+
+// validateDataSchema checks a received event against its data schema
+func (e *Event) validateDataSchema() error {
+	s := dataSchemas.Load()
+	if s == nil || e.DataSchema == nil {
+		return nil
+	}
+	return s.Validate(e)
+}
@@ -1,15 +1,12 @@
 package event
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"strconv"
 	"sync"
 
 	jsoniter "github.com/json-iterator/go"
 
-	"github.com/redhat-cne/sdk-go/pkg/event/redfish"
 	"github.com/redhat-cne/sdk-go/pkg/types"
 )
 
@@ -85,12 +82,15 @@ func readDataJSONFromIterator(out *Data, iterator *jsoniter.Iterator) error {
 func readJSONFromIterator(out *Event, iterator *jsoniter.Iterator) error {
 	var (
 		// Universally parseable fields.
-		id     string
-		typ    string
-		source string
-		time   *types.Timestamp
-		data   *Data
-		err    error
+		id          string
+		typ         string
+		source      string
+		contentType *string
+		dataSchema  string
+		extensions  map[string]interface{}
+		time        *types.Timestamp
+		data        *Data
+		err         error
 
 		// These fields require knowledge about the specversion to be parsed.
 		//schemaurl jsoniter.Any
@@ -110,6 +110,13 @@ func readJSONFromIterator(out *Event, iterator *jsoniter.Iterator) error {
 			typ = iterator.ReadString()
 		case "source":
 			source = iterator.ReadString()
+		case "dataContentType":
+			ct := iterator.ReadString()
+			contentType = &ct
+		case "dataSchema":
+			dataSchema = iterator.ReadString()
+		case "extensions":
+			iterator.ReadVal(&extensions)
 		case "time":
 			time = readTimestamp(iterator)
 		case "data":
@@ -134,10 +141,23 @@ func readJSONFromIterator(out *Event, iterator *jsoniter.Iterator) error {
 	out.ID = id
 	out.Type = typ
 	out.Source = source
+	out.DataContentType = contentType
+	out.DataSchema = nil
+	if dataSchema != "" {
+		if err = out.SetDataSchema(dataSchema); err != nil {
+			return err
+		}
+	}
+	out.Extensions = nil
+	for name, value := range extensions {
+		if err = out.SetExtension(name, value); err != nil {
+			return err
+		}
+	}
 	if data != nil {
 		out.SetData(*data)
 	}
-	return nil
+	return out.validateDataSchema()
 }
 
 func readTimestamp(iter *jsoniter.Iterator) *types.Timestamp {
@@ -152,7 +172,7 @@ func readDataValue(iter *jsoniter.Iterator) ([]DataValue, error) {
 	var values []DataValue
 	var err error
 	for iter.ReadArray() {
-		var cacheValue interface{}
+		var rawValue []byte
 		dv := DataValue{}
 		for dvField := iter.ReadObject(); dvField != ""; dvField = iter.ReadObject() {
 			switch dvField {
@@ -163,30 +183,25 @@ func readDataValue(iter *jsoniter.Iterator) ([]DataValue, error) {
 			case "value_type":
 				dv.ValueType = ValueType(iter.ReadString())
 			case "value":
-				cacheValue = iter.Read()
+				// value_type may come after the value, keep the raw value until then
+				rawValue = append([]byte(nil), iter.SkipAndReturnBytes()...)
 			default:
 				iter.Skip()
 			}
 		}
 
-		if dv.ValueType == DECIMAL {
-			dv.Value, err = strconv.ParseFloat(cacheValue.(string), 64)
-		} else if dv.ValueType == ENUMERATION {
-			dv.Value = cacheValue.(string)
-		} else if dv.ValueType == REDFISH_EVENT {
-			jsonRedfishEvent, err2 := json.Marshal(cacheValue)
-			if err2 != nil {
-				return values, err2
-			}
-
-			e := redfish.Event{}
-			if err = json.Unmarshal(jsonRedfishEvent, &e); err != nil {
-				return values, err
-			}
-			dv.Value = e
-		} else {
+		if iter.Error != nil {
+			return values, iter.Error
+		}
+		if _, ok := LookupValueType(dv.ValueType); !ok {
 			return values, fmt.Errorf("value type %v is not supported", dv.ValueType)
 		}
+		if rawValue == nil {
+			return values, fmt.Errorf("value of type %v is not set", dv.ValueType)
+		}
+		if err = DecodeValue(&dv, rawValue); err != nil {
+			return values, err
+		}
 		values = append(values, dv)
 	}
 
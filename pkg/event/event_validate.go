@@ -0,0 +1,238 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/redhat-cne/sdk-go/pkg/resource"
+)
+
+// ErrInvalidEvent is matched by errors.Is for every *ValidationError
+var ErrInvalidEvent = errors.New("invalid event")
+
+// ValidationError describes one invalid field of an event
+type ValidationError struct {
+	// Field is the JSON path of the field, such as data.values[0].value_type
+	Field   string
+	Message string
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Is reports ErrInvalidEvent
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidEvent
+}
+
+// ValidationErrors returns the *ValidationError joined in an error returned by Validate
+func ValidationErrors(err error) []*ValidationError {
+	var result []*ValidationError
+	var joined interface{ Unwrap() []error }
+	var ve *ValidationError
+	switch {
+	case errors.As(err, &joined):
+		for _, e := range joined.Unwrap() {
+			result = append(result, ValidationErrors(e)...)
+		}
+	case errors.As(err, &ve):
+		result = append(result, ve)
+	}
+	return result
+}
+
+// value types of each data type, value types added with RegisterValueType may be used with any data type
+var dataValueTypes = map[DataType]map[ValueType]bool{
+	NOTIFICATION: {ENUMERATION: true, STRING: true, BOOLEAN: true, TIMESTAMP: true, OBJECT: true, REDFISH_EVENT: true},
+	METRIC:       {DECIMAL: true, INTEGER: true, DURATION: true, BOOLEAN: true, TIMESTAMP: true, OBJECT: true},
+}
+
+// DataVersionPattern matches the versions of event data, such as v1 or 1.0
+const DataVersionPattern = `^v?[0-9]+(\.[0-9]+)*$`
+
+var (
+	dataVersion     = regexp.MustCompile(DataVersionPattern)
+	validateMarshal atomic.Bool
+)
+
+// checkedValueType reports whether the data type of a value of valueType is checked
+func checkedValueType(valueType ValueType) bool {
+	for _, valueTypes := range dataValueTypes {
+		if valueTypes[valueType] {
+			return true
+		}
+	}
+	return false
+}
+
+// SetValidateOnMarshal makes WriteJSON, and so json.Marshal, validate events before writing them
+func SetValidateOnMarshal(enabled bool) {
+	validateMarshal.Store(enabled)
+}
+
+// ValidateOption configures Validate
+type ValidateOption func(*validateConfig)
+
+type validateConfig struct {
+	seenID func(id string) bool
+}
+
+// WithSeenID reports an error when seen returns true for the event ID, see RecentIDs
+func WithSeenID(seen func(id string) bool) ValidateOption {
+	return func(c *validateConfig) {
+		c.seenID = seen
+	}
+}
+
+// RecentIDs remembers the last IDs it has seen, to catch producers reusing event IDs
+type RecentIDs struct {
+	mu   sync.Mutex
+	ids  map[string]struct{}
+	ring []string
+	next int
+}
+
+// NewRecentIDs returns a RecentIDs remembering the last size IDs
+func NewRecentIDs(size int) *RecentIDs {
+	if size < 1 {
+		size = 1
+	}
+	return &RecentIDs{ids: make(map[string]struct{}, size), ring: make([]string, size)}
+}
+
+// Seen reports whether id is one of the recent IDs and remembers it
+func (r *RecentIDs) Seen(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.ids[id]; ok {
+		return true
+	}
+	delete(r.ids, r.ring[r.next])
+	r.ring[r.next] = id
+	r.next = (r.next + 1) % len(r.ring)
+	r.ids[id] = struct{}{}
+	return false
+}
+
+// Validate checks the event and returns the errors.Join of a *ValidationError for every problem found
+func (e *Event) Validate(opts ...ValidateOption) error {
+	var c validateConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	var errs []error
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case e.ID == "":
+		fail("id", "is required")
+	case strings.TrimSpace(e.ID) != e.ID || strings.IndexFunc(e.ID, unicode.IsControl) >= 0:
+		fail("id", "%q has surrounding blanks or control characters", e.ID)
+	case c.seenID != nil && c.seenID(e.ID):
+		fail("id", "%s was already used, IDs must be unique within the producer", e.ID)
+	}
+	if e.Type == "" {
+		fail("type", "is required")
+	}
+	if e.Source == "" {
+		fail("source", "is required")
+	} else if _, err := url.Parse(e.Source); err != nil {
+		fail("source", "is not a URI reference: %v", err)
+	}
+	if e.DataContentType != nil && *e.DataContentType != ApplicationJSON {
+		fail("dataContentType", "unsupported content type %s", *e.DataContentType)
+	}
+	switch {
+	case e.Time == nil:
+		fail("time", "is required")
+	case e.Time.IsZero():
+		fail("time", "is the zero time")
+	case e.Time.Year() < 0 || e.Time.Year() > 9999:
+		fail("time", "year %d cannot be written as RFC 3339", e.Time.Year())
+	}
+	if e.Data == nil {
+		fail("data", "is required")
+	} else {
+		errs = append(errs, e.Data.validate()...)
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Data) validate() []error {
+	var errs []error
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if d.Version == "" {
+		fail("data.version", "is required")
+	} else if !dataVersion.MatchString(d.Version) {
+		fail("data.version", "%q is not a version such as v1 or 1.0", d.Version)
+	}
+	if len(d.Values) == 0 {
+		fail("data.values", "at least one value is required")
+	}
+	for i := range d.Values {
+		v := &d.Values[i]
+		field := fmt.Sprintf("data.values[%d]", i)
+		if err := validateResource(v.Resource); err != nil {
+			fail(field+".ResourceAddress", "%v", err)
+		}
+		valueTypes, ok := dataValueTypes[v.DataType]
+		if !ok {
+			fail(field+".data_type", "unknown data type %q", v.DataType)
+		}
+		if _, ok = LookupValueType(v.ValueType); !ok {
+			fail(field+".value_type", "unknown value type %q", v.ValueType)
+			continue
+		}
+		if valueTypes != nil && checkedValueType(v.ValueType) && !valueTypes[v.ValueType] {
+			fail(field+".value_type", "%s values cannot be of type %s", v.DataType, v.ValueType)
+		}
+		if _, err := EncodeValue(v); err != nil {
+			fail(field+".value", "%v", err)
+		}
+	}
+	return errs
+}
+
+// validateResource checks a resource address is an absolute path without empty segments, wildcards
+// or blanks, such as /east-edge-10/Node3/sync/sync-status/sync-state
+func validateResource(address string) error {
+	switch {
+	case address == "":
+		return fmt.Errorf("is required")
+	case !strings.HasPrefix(address, resource.Separator):
+		return fmt.Errorf("%q must start with /", address)
+	case strings.Contains(address, "//") || strings.HasSuffix(address, resource.Separator):
+		return fmt.Errorf("%q has an empty segment", address)
+	case resource.HasWildcard(address):
+		return fmt.Errorf("%q is a pattern, not an address", address)
+	case strings.IndexFunc(address, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0:
+		return fmt.Errorf("%q has blanks or control characters", address)
+	}
+	return nil
+}
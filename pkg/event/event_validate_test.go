@@ -0,0 +1,147 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-cne/sdk-go/pkg/event"
+	"github.com/redhat-cne/sdk-go/pkg/event/ptp"
+	"github.com/redhat-cne/sdk-go/pkg/types"
+)
+
+func validEvent() event.Event {
+	e := event.Event{ID: "ABC-1234", Type: string(ptp.PtpStateChange), Source: "/cluster/node/ptp"}
+	e.SetTime(time.Now())
+	e.SetData(event.Data{Version: "v1", Values: []event.DataValue{
+		{Resource: "/cluster/node/ptp", DataType: event.NOTIFICATION, ValueType: event.ENUMERATION, Value: ptp.FREERUN},
+		{Resource: "/cluster/node/ptp", DataType: event.METRIC, ValueType: event.DECIMAL, Value: 10.63},
+	}})
+	return e
+}
+
+func validationFields(err error) []string {
+	var fields []string
+	for _, e := range event.ValidationErrors(err) {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestEvent_Validate(t *testing.T) {
+	e := validEvent()
+	assert.NoError(t, e.Validate())
+
+	testCases := map[string]struct {
+		change func(e *event.Event)
+		want   []string
+	}{
+		"missing attributes": {
+			change: func(e *event.Event) { e.ID, e.Type, e.Source, e.Time, e.Data = "", "", "", nil, nil },
+			want:   []string{"id", "type", "source", "time", "data"},
+		},
+		"blank id": {
+			change: func(e *event.Event) { e.ID = " ABC-1234" },
+			want:   []string{"id"},
+		},
+		"content type": {
+			change: func(e *event.Event) { e.SetDataContentType(event.TextPlain) },
+			want:   []string{"dataContentType"},
+		},
+		"zero time": {
+			change: func(e *event.Event) { e.Time = &types.Timestamp{} },
+			want:   []string{"time"},
+		},
+		"time out of RFC 3339 range": {
+			change: func(e *event.Event) { e.SetTime(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)) },
+			want:   []string{"time"},
+		},
+		"data": {
+			change: func(e *event.Event) {
+				e.Data.Version = "latest"
+				e.Data.Values[0].Resource = "cluster//ptp"
+				e.Data.Values[0].DataType = event.METRIC
+				e.Data.Values[1].ValueType = event.DURATION
+			},
+			want: []string{"data.version", "data.values[0].ResourceAddress", "data.values[0].value_type",
+				"data.values[1].value"},
+		},
+		"resource addresses": {
+			change: func(e *event.Event) {
+				e.Data.Values[0].Resource = "/cluster/node/"
+				e.Data.Values[1].Resource = "/cluster/*/ptp"
+				e.Data.Values = append(e.Data.Values, event.DataValue{Resource: "/sync/**",
+					DataType: event.METRIC, ValueType: event.DECIMAL, Value: 1.0})
+			},
+			want: []string{"data.values[0].ResourceAddress", "data.values[1].ResourceAddress",
+				"data.values[2].ResourceAddress"},
+		},
+		"unknown types": {
+			change: func(e *event.Event) {
+				e.Data.Values[0].DataType = "alarm"
+				e.Data.Values[1].ValueType = "float"
+			},
+			want: []string{"data.values[0].data_type", "data.values[1].value_type"},
+		},
+		"no values": {
+			change: func(e *event.Event) { e.Data.Values = nil },
+			want:   []string{"data.values"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			e := validEvent()
+			tc.change(&e)
+			err := e.Validate()
+			assert.ErrorIs(t, err, event.ErrInvalidEvent)
+			assert.Equal(t, tc.want, validationFields(err))
+		})
+	}
+}
+
+func TestEvent_ValidateSeenID(t *testing.T) {
+	ids := event.NewRecentIDs(2)
+	for _, id := range []string{"a", "b", "c"} {
+		e := validEvent()
+		e.ID = id
+		assert.NoError(t, e.Validate(event.WithSeenID(ids.Seen)))
+	}
+	e := validEvent()
+	e.ID = "c"
+	assert.Equal(t, []string{"id"}, validationFields(e.Validate(event.WithSeenID(ids.Seen))))
+	// a was forgotten
+	e.ID = "a"
+	assert.NoError(t, e.Validate(event.WithSeenID(ids.Seen)))
+}
+
+func TestEvent_ValidateOnMarshal(t *testing.T) {
+	e := validEvent()
+	e.Data.Values[0].Resource = "ptp"
+	_, err := json.Marshal(e)
+	require.NoError(t, err)
+
+	event.SetValidateOnMarshal(true)
+	t.Cleanup(func() { event.SetValidateOnMarshal(false) })
+	_, err = json.Marshal(e)
+	assert.True(t, errors.Is(err, event.ErrInvalidEvent))
+	_, err = json.Marshal(validEvent())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,336 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/redhat-cne/sdk-go/pkg/event/redfish"
+	"github.com/redhat-cne/sdk-go/pkg/types"
+)
+
+// ErrUnknownValueType is returned when a value has a value type without codec
+var ErrUnknownValueType = errors.New("unknown value type")
+
+// ValueCodec encodes and decodes the values of a value type
+type ValueCodec interface {
+	// Encode returns the JSON encoding of v, it fails if v is not a value of the type
+	Encode(v interface{}) ([]byte, error)
+	// Decode returns the value encoded in data, it fails if data is not an encoding of the type
+	Decode(data []byte) (interface{}, error)
+}
+
+// ValueCodecFuncs is a ValueCodec made of two functions
+type ValueCodecFuncs struct {
+	EncodeFunc func(v interface{}) ([]byte, error)
+	DecodeFunc func(data []byte) (interface{}, error)
+}
+
+// Encode implements ValueCodec
+func (c ValueCodecFuncs) Encode(v interface{}) ([]byte, error) {
+	return c.EncodeFunc(v)
+}
+
+// Decode implements ValueCodec
+func (c ValueCodecFuncs) Decode(data []byte) (interface{}, error) {
+	return c.DecodeFunc(data)
+}
+
+var valueCodecs = struct {
+	sync.RWMutex
+	codecs map[ValueType]ValueCodec
+}{codecs: map[ValueType]ValueCodec{
+	ENUMERATION:   ValueCodecFuncs{encodeEnumeration, decodeString},
+	DECIMAL:       ValueCodecFuncs{encodeDecimal, decodeDecimal},
+	REDFISH_EVENT: ValueCodecFuncs{encodeRedfishEvent, decodeRedfishEvent},
+	INTEGER:       ValueCodecFuncs{encodeInteger, decodeInteger},
+	BOOLEAN:       ValueCodecFuncs{encodeBoolean, decodeBoolean},
+	STRING:        ValueCodecFuncs{encodeString, decodeString},
+	TIMESTAMP:     ValueCodecFuncs{encodeTimestamp, decodeTimestamp},
+	DURATION:      ValueCodecFuncs{encodeDuration, decodeDuration},
+	OBJECT:        ValueCodecFuncs{encodeObject, decodeObject},
+}}
+
+// RegisterValueType adds a value type with its codec, it fails if the value type is already registered
+func RegisterValueType(valueType ValueType, codec ValueCodec) error {
+	if valueType == "" || codec == nil {
+		return fmt.Errorf("value type and codec must be set")
+	}
+	valueCodecs.Lock()
+	defer valueCodecs.Unlock()
+	if _, ok := valueCodecs.codecs[valueType]; ok {
+		return fmt.Errorf("value type %s is already registered", valueType)
+	}
+	valueCodecs.codecs[valueType] = codec
+	return nil
+}
+
+// LookupValueType returns the codec of a value type
+func LookupValueType(valueType ValueType) (ValueCodec, bool) {
+	valueCodecs.RLock()
+	defer valueCodecs.RUnlock()
+	codec, ok := valueCodecs.codecs[valueType]
+	return codec, ok
+}
+
+// EncodeValue returns the JSON encoding of the value of v according to its value type
+func EncodeValue(v *DataValue) ([]byte, error) {
+	codec, ok := LookupValueType(v.ValueType)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownValueType, v.ValueType)
+	}
+	b, err := codec.Encode(v.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %v: %w", v.ValueType, v.Value, err)
+	}
+	return b, nil
+}
+
+// DecodeValue sets the value of v from its JSON encoding according to its value type
+func DecodeValue(v *DataValue, data []byte) error {
+	codec, ok := LookupValueType(v.ValueType)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownValueType, v.ValueType)
+	}
+	value, err := codec.Decode(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s value %s: %w", v.ValueType, data, err)
+	}
+	v.Value = value
+	return nil
+}
+
+func typeError(v interface{}) error {
+	return fmt.Errorf("unexpected type %T", v)
+}
+
+// encodeEnumeration accepts strings, named string types such as ptp.SyncState, fmt.Stringer
+// and scalars which are formatted with %v as before
+func encodeEnumeration(v interface{}) ([]byte, error) {
+	if s, ok := v.(fmt.Stringer); ok {
+		return json.Marshal(s.String())
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String:
+		return encodeString(v)
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return json.Marshal(fmt.Sprintf("%v", v))
+	}
+	return nil, typeError(v)
+}
+
+func encodeString(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return nil, typeError(v)
+	}
+	return json.Marshal(rv.String())
+}
+
+func decodeString(data []byte) (interface{}, error) {
+	var s string
+	if err := strictUnmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// encodeDecimal accepts numbers and numeric strings, decimals are encoded as strings
+func encodeDecimal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("%v is not a finite number", f)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.String:
+		if _, err := strconv.ParseFloat(rv.String(), 64); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, typeError(v)
+	}
+	return json.Marshal(fmt.Sprintf("%v", v))
+}
+
+// decodeDecimal accepts both a JSON number and a numeric JSON string
+func decodeDecimal(data []byte) (interface{}, error) {
+	var f float64
+	if err := strictUnmarshal(data, &f); err == nil {
+		return f, nil
+	}
+	var s string
+	if err := strictUnmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("not a number or a numeric string")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func encodeInteger(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []byte(strconv.FormatInt(rv.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%v overflows int64", v)
+		}
+		return []byte(strconv.FormatUint(rv.Uint(), 10)), nil
+	}
+	return nil, typeError(v)
+}
+
+func decodeInteger(data []byte) (interface{}, error) {
+	var i int64
+	if err := strictUnmarshal(data, &i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func encodeBoolean(v interface{}) ([]byte, error) {
+	if b, ok := v.(bool); ok {
+		return json.Marshal(b)
+	}
+	return nil, typeError(v)
+}
+
+func decodeBoolean(data []byte) (interface{}, error) {
+	var b bool
+	if err := strictUnmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func encodeTimestamp(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return json.Marshal(t.Format(time.RFC3339Nano))
+	case *time.Time:
+		if t != nil {
+			return encodeTimestamp(*t)
+		}
+	case types.Timestamp:
+		return encodeTimestamp(t.Time)
+	case *types.Timestamp:
+		if t != nil {
+			return encodeTimestamp(t.Time)
+		}
+	}
+	return nil, typeError(v)
+}
+
+func decodeTimestamp(data []byte) (interface{}, error) {
+	var s string
+	if err := strictUnmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func encodeDuration(v interface{}) ([]byte, error) {
+	if d, ok := v.(time.Duration); ok {
+		return json.Marshal(d.String())
+	}
+	return nil, typeError(v)
+}
+
+func decodeDuration(data []byte) (interface{}, error) {
+	var s string
+	if err := strictUnmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return time.ParseDuration(s)
+}
+
+// encodeObject accepts any value encoded as a JSON object, such as a map or a struct
+func encodeObject(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(b, []byte("{")) {
+		return nil, typeError(v)
+	}
+	return b, nil
+}
+
+func decodeObject(data []byte) (interface{}, error) {
+	var m map[string]interface{}
+	if err := strictUnmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("not an object")
+	}
+	return m, nil
+}
+
+func encodeRedfishEvent(v interface{}) ([]byte, error) {
+	var e redfish.Event
+	switch t := v.(type) {
+	case redfish.Event:
+		e = t
+	case *redfish.Event:
+		if t == nil {
+			return nil, typeError(v)
+		}
+		e = *t
+	default:
+		return nil, typeError(v)
+	}
+	var buf bytes.Buffer
+	stream := jsoniter.ConfigFastest.BorrowStream(&buf)
+	defer jsoniter.ConfigFastest.ReturnStream(stream)
+	if err := redfish.WriteJSONEvent(&e, &buf, stream); err != nil {
+		return nil, err
+	}
+	if err := stream.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeRedfishEvent(data []byte) (interface{}, error) {
+	e := redfish.Event{}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// strictUnmarshal decodes data into v without the conversions encoding/json skips silently,
+// null is rejected
+func strictUnmarshal(data []byte, v interface{}) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return fmt.Errorf("value is null")
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-cne/sdk-go/pkg/event"
+	"github.com/redhat-cne/sdk-go/pkg/event/ptp"
+)
+
+func valueEvent(values ...event.DataValue) event.Event {
+	e := event.Event{ID: "ABC-1234", Type: string(ptp.PtpStateChange), Source: "/cluster/node/ptp"}
+	e.SetData(event.Data{Version: "v1", Values: values})
+	return e
+}
+
+func readValue(t *testing.T, valueType event.ValueType, raw string) (interface{}, error) {
+	body := fmt.Sprintf(`{"id":"ABC-1234","type":"x","source":"/x","data":{"version":"v1","values":[`+
+		`{"value":%s,"ResourceAddress":"/x","data_type":"metric","value_type":%q}]}}`, raw, valueType)
+	var e event.Event
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		return nil, err
+	}
+	require.Len(t, e.Data.Values, 1)
+	return e.Data.Values[0].Value, nil
+}
+
+func TestValueType_RoundTrip(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	testCases := []struct {
+		valueType event.ValueType
+		value     interface{}
+		raw       string
+		want      interface{}
+	}{
+		{event.ENUMERATION, ptp.FREERUN, `"FREERUN"`, "FREERUN"},
+		{event.DECIMAL, 10.63, `"10.63"`, 10.63},
+		{event.INTEGER, -42, `-42`, int64(-42)},
+		{event.INTEGER, uint8(7), `7`, int64(7)},
+		{event.BOOLEAN, true, `true`, true},
+		{event.STRING, "locked", `"locked"`, "locked"},
+		{event.TIMESTAMP, now, `"2024-05-06T07:08:09.123456789Z"`, now},
+		{event.DURATION, 90 * time.Second, `"1m30s"`, 90 * time.Second},
+		{event.OBJECT, map[string]interface{}{"offset": 1.5, "port": map[string]interface{}{"name": "ens1f0"}},
+			`{"offset":1.5,"port":{"name":"ens1f0"}}`,
+			map[string]interface{}{"offset": 1.5, "port": map[string]interface{}{"name": "ens1f0"}}},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.valueType), func(t *testing.T) {
+			e := valueEvent(event.DataValue{Resource: "/x", DataType: event.METRIC, ValueType: tc.valueType, Value: tc.value})
+			b, err := json.Marshal(e)
+			require.NoError(t, err)
+			assert.Contains(t, string(b), `"value":`+tc.raw)
+
+			var got event.Event
+			require.NoError(t, json.Unmarshal(b, &got))
+			assert.Equal(t, tc.want, got.Data.Values[0].Value)
+
+			// the value may come before its value type
+			v, err := readValue(t, tc.valueType, tc.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, v)
+		})
+	}
+}
+
+func TestValueType_Decimal(t *testing.T) {
+	for _, raw := range []string{`10.5`, `"10.5"`} {
+		v, err := readValue(t, event.DECIMAL, raw)
+		require.NoError(t, err)
+		assert.Equal(t, 10.5, v)
+	}
+	_, err := readValue(t, event.DECIMAL, `"ten"`)
+	assert.Error(t, err)
+}
+
+func TestValueType_Strict(t *testing.T) {
+	encodeErrors := []event.DataValue{
+		{ValueType: event.INTEGER, Value: 1.5},
+		{ValueType: event.INTEGER, Value: uint64(1 << 63)},
+		{ValueType: event.BOOLEAN, Value: "true"},
+		{ValueType: event.STRING, Value: 1},
+		{ValueType: event.TIMESTAMP, Value: "2024-05-06T07:08:09Z"},
+		{ValueType: event.DURATION, Value: 90},
+		{ValueType: event.OBJECT, Value: []int{1}},
+		{ValueType: event.DECIMAL, Value: "ten"},
+		{ValueType: event.REDFISH_EVENT, Value: "event"},
+		{ValueType: "unknown", Value: 1},
+	}
+	for _, v := range encodeErrors {
+		_, err := json.Marshal(valueEvent(v))
+		assert.Error(t, err, "%s %v", v.ValueType, v.Value)
+	}
+
+	decodeErrors := map[event.ValueType][]string{
+		event.INTEGER:   {`1.5`, `"1"`, `null`},
+		event.BOOLEAN:   {`1`, `"true"`},
+		event.STRING:    {`1`, `{}`},
+		event.TIMESTAMP: {`"yesterday"`, `0`},
+		event.DURATION:  {`"90"`, `90`},
+		event.OBJECT:    {`[]`, `"{}"`, `null`},
+		"unknown":       {`1`},
+	}
+	for valueType, raws := range decodeErrors {
+		for _, raw := range raws {
+			_, err := readValue(t, valueType, raw)
+			assert.Error(t, err, "%s %s", valueType, raw)
+		}
+	}
+}
+
+type semver struct{ major, minor int }
+
+func TestRegisterValueType(t *testing.T) {
+	const versionType event.ValueType = "test-version"
+	codec := event.ValueCodecFuncs{
+		EncodeFunc: func(v interface{}) ([]byte, error) {
+			ver, ok := v.(semver)
+			if !ok {
+				return nil, errors.New("not a version")
+			}
+			return json.Marshal(fmt.Sprintf("%d.%d", ver.major, ver.minor))
+		},
+		DecodeFunc: func(data []byte) (interface{}, error) {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil {
+				return nil, err
+			}
+			var ver semver
+			_, err := fmt.Sscanf(s, "%d.%d", &ver.major, &ver.minor)
+			return ver, err
+		},
+	}
+	require.NoError(t, event.RegisterValueType(versionType, codec))
+	assert.Error(t, event.RegisterValueType(versionType, codec))
+	assert.Error(t, event.RegisterValueType(event.INTEGER, codec))
+	_, ok := event.LookupValueType(versionType)
+	assert.True(t, ok)
+
+	b, err := json.Marshal(valueEvent(event.DataValue{ValueType: versionType, Value: semver{1, 2}}))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"value":"1.2"`)
+	var got event.Event
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, semver{1, 2}, got.Data.Values[0].Value)
+
+	_, err = json.Marshal(valueEvent(event.DataValue{ValueType: versionType, Value: "1.2"}))
+	assert.Error(t, err)
+}
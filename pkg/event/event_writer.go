@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	cloudevent "github.com/cloudevents/sdk-go/v2"
 	"github.com/redhat-cne/sdk-go/pkg/types"
 )
 
@@ -62,6 +63,29 @@ func (e *Event) SetDataSchema(s string) error {
 	return nil
 }
 
+// SetExtension implements Writer.SetExtension. The name is lower-cased and the value converted
+// to its CloudEvents type, a nil value removes the extension.
+func (e *Event) SetExtension(name string, value interface{}) error {
+	// the CloudEvents context validates the name and the value
+	ec := cloudevent.EventContextV1{}
+	if err := ec.SetExtension(name, value); err != nil {
+		return err
+	}
+	name = strings.ToLower(name)
+	if value == nil {
+		delete(e.Extensions, name)
+		if len(e.Extensions) == 0 {
+			e.Extensions = nil
+		}
+		return nil
+	}
+	if e.Extensions == nil {
+		e.Extensions = map[string]interface{}{}
+	}
+	e.Extensions[name] = ec.Extensions[name]
+	return nil
+}
+
 // SetDataContentType implements Writer.SetDataContentType
 func (e *Event) SetDataContentType(ct string) {
 	ct = strings.TrimSpace(ct)
@@ -0,0 +1,246 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/event"
+)
+
+// metric is the kind of metric carried by the events of a type
+type metric string
+
+const (
+	offsetMetric       metric = "offset"
+	clockClassMetric   metric = "clock class"
+	clockQualityMetric metric = "clock quality"
+)
+
+// eventSpec describes the events of a type
+type eventSpec struct {
+	resource EventResource
+	metrics  []metric
+}
+
+var eventSpecs = map[EventType]eventSpec{
+	GnssStateChange:          {resource: GnssSyncStatus, metrics: []metric{offsetMetric}},
+	OsClockSyncStateChange:   {resource: OsClockSyncState, metrics: []metric{offsetMetric}},
+	PtpClockClassChange:      {resource: PtpClockClass, metrics: []metric{clockClassMetric}},
+	PtpStateChange:           {resource: PtpLockState, metrics: []metric{offsetMetric}},
+	SynceClockQualityChange:  {resource: SynceClockQuality, metrics: []metric{clockQualityMetric}},
+	SynceStateChange:         {resource: SynceLockState},
+	SynceStateChangeExtended: {resource: SynceLockStateExtended},
+	SyncStateChange:          {resource: SyncStatusState},
+}
+
+// Resource returns the resource of the events of type t
+func (t EventType) Resource() EventResource {
+	return eventSpecs[t].resource
+}
+
+// Builder builds the event of a PTP event type, it only accepts the state and the metrics of the
+// type. The first error is returned by Build.
+type Builder struct {
+	eventType EventType
+	address   string
+	id        string
+	time      time.Time
+	state     *SyncState
+	values    []event.DataValue
+	// metrics are the metrics of values, in the same order
+	metrics []metric
+	err     error
+}
+
+// NewBuilder returns a builder of the events of eventType about the node, or about the interface
+// iface of the node when it is not empty
+func NewBuilder(eventType EventType, node, iface string) *Builder {
+	b := &Builder{eventType: eventType}
+	if _, ok := eventSpecs[eventType]; !ok {
+		b.err = fmt.Errorf("unknown PTP event type %s", eventType)
+	}
+	if node == "" {
+		b.fail(fmt.Errorf("node is required"))
+	}
+	b.address = "/cluster/node/" + strings.Trim(node, "/")
+	if iface = strings.Trim(iface, "/"); iface != "" {
+		b.address += "/" + iface
+	}
+	return b
+}
+
+// NewLockStateChange returns a builder of PtpStateChange events about the interface of the node
+func NewLockStateChange(node, iface string) *Builder {
+	return NewBuilder(PtpStateChange, node, iface)
+}
+
+// NewClockClassChange returns a builder of PtpClockClassChange events about the interface of the node
+func NewClockClassChange(node, iface string) *Builder {
+	return NewBuilder(PtpClockClassChange, node, iface)
+}
+
+// NewOsClockSyncStateChange returns a builder of OsClockSyncStateChange events about the node
+func NewOsClockSyncStateChange(node string) *Builder {
+	return NewBuilder(OsClockSyncStateChange, node, "")
+}
+
+// NewSyncStateChange returns a builder of SyncStateChange events about the node
+func NewSyncStateChange(node string) *Builder {
+	return NewBuilder(SyncStateChange, node, "")
+}
+
+// NewGnssStateChange returns a builder of GnssStateChange events about the interface of the node
+func NewGnssStateChange(node, iface string) *Builder {
+	return NewBuilder(GnssStateChange, node, iface)
+}
+
+// NewSynceStateChange returns a builder of SynceStateChange events about the interface of the node
+func NewSynceStateChange(node, iface string) *Builder {
+	return NewBuilder(SynceStateChange, node, iface)
+}
+
+// NewSynceStateChangeExtended returns a builder of SynceStateChangeExtended events about the
+// interface of the node
+func NewSynceStateChangeExtended(node, iface string) *Builder {
+	return NewBuilder(SynceStateChangeExtended, node, iface)
+}
+
+// NewSynceClockQualityChange returns a builder of SynceClockQualityChange events about the interface
+// of the node
+func NewSynceClockQualityChange(node, iface string) *Builder {
+	return NewBuilder(SynceClockQualityChange, node, iface)
+}
+
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// ID sets the event ID, a new UUID by default
+func (b *Builder) ID(id string) *Builder {
+	b.id = id
+	return b
+}
+
+// Time sets the event time, the time of Build by default
+func (b *Builder) Time(t time.Time) *Builder {
+	b.time = t
+	return b
+}
+
+// State sets the state notified by the event, it must be one of the SyncStates of the event type
+func (b *Builder) State(state SyncState) *Builder {
+	allowed := false
+	for _, s := range b.eventType.SyncStates() {
+		allowed = allowed || s == state
+	}
+	switch {
+	case !allowed:
+		b.fail(fmt.Errorf("state %s is not allowed in %s events", state, b.eventType))
+	case b.state != nil:
+		b.fail(fmt.Errorf("state of %s event is already set", b.eventType))
+	default:
+		b.state = &state
+	}
+	return b
+}
+
+// Offset adds the offset in nanoseconds, of a lock state, an OS clock or a GNSS state change
+func (b *Builder) Offset(offset float64) *Builder {
+	return b.metric(offsetMetric, offset)
+}
+
+// ClockClass adds the clock class of a clock class change
+func (b *Builder) ClockClass(class uint8) *Builder {
+	return b.metric(clockClassMetric, float64(class))
+}
+
+// ClockQuality adds the quality level of a SyncE clock quality change
+func (b *Builder) ClockQuality(quality float64) *Builder {
+	return b.metric(clockQualityMetric, quality)
+}
+
+func (b *Builder) metric(m metric, value float64) *Builder {
+	allowed := false
+	for _, spec := range eventSpecs[b.eventType].metrics {
+		allowed = allowed || spec == m
+	}
+	if !allowed {
+		b.fail(fmt.Errorf("%s events have no %s", b.eventType, m))
+		return b
+	}
+	for _, set := range b.metrics {
+		if set == m {
+			b.fail(fmt.Errorf("%s of %s event is already set", m, b.eventType))
+			return b
+		}
+	}
+	b.metrics = append(b.metrics, m)
+	b.values = append(b.values, event.DataValue{
+		Resource:  b.address,
+		DataType:  event.METRIC,
+		ValueType: event.DECIMAL,
+		Value:     value,
+	})
+	return b
+}
+
+// Build returns the event, its source is the resource of the event type and its values are about
+// the node or the interface. The event is checked with event.Validate.
+func (b *Builder) Build() (event.Event, error) {
+	if b.err != nil {
+		return event.Event{}, b.err
+	}
+	var values []event.DataValue
+	if len(b.eventType.SyncStates()) > 0 {
+		if b.state == nil {
+			return event.Event{}, fmt.Errorf("state of %s event is required", b.eventType)
+		}
+		values = append(values, event.DataValue{
+			Resource:  b.address,
+			DataType:  event.NOTIFICATION,
+			ValueType: event.ENUMERATION,
+			Value:     *b.state,
+		})
+	} else if len(b.values) == 0 {
+		return event.Event{}, fmt.Errorf("%s event has no %s", b.eventType, eventSpecs[b.eventType].metrics[0])
+	}
+	values = append(values, b.values...)
+
+	e := event.Event{
+		ID:     b.id,
+		Type:   string(b.eventType),
+		Source: string(b.eventType.Resource()),
+	}
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if b.time.IsZero() {
+		e.SetTime(time.Now().UTC())
+	} else {
+		e.SetTime(b.time)
+	}
+	e.SetDataContentType(event.ApplicationJSON)
+	e.SetData(event.Data{Version: event.APISchemaVersion, Values: values})
+	if err := e.Validate(); err != nil {
+		return event.Event{}, err
+	}
+	return e, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-cne/sdk-go/pkg/event"
+	"github.com/redhat-cne/sdk-go/pkg/event/ptp"
+	"github.com/redhat-cne/sdk-go/pkg/event/schema"
+)
+
+func TestBuilder(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	e, err := ptp.NewLockStateChange("node1", "ens1f0").State(ptp.LOCKED).Offset(-12.3).Time(now).Build()
+	require.NoError(t, err)
+	assert.NotEmpty(t, e.ID)
+	assert.Equal(t, string(ptp.PtpStateChange), e.Type)
+	assert.Equal(t, string(ptp.PtpLockState), e.Source)
+	assert.Equal(t, now, e.Time.Time)
+	assert.Equal(t, event.ApplicationJSON, e.GetDataContentType())
+	assert.Equal(t, &event.Data{Version: event.APISchemaVersion, Values: []event.DataValue{
+		{Resource: "/cluster/node/node1/ens1f0", DataType: event.NOTIFICATION, ValueType: event.ENUMERATION, Value: ptp.LOCKED},
+		{Resource: "/cluster/node/node1/ens1f0", DataType: event.METRIC, ValueType: event.DECIMAL, Value: -12.3},
+	}}, e.Data)
+
+	// the events match their schema
+	r := schema.NewRegistry("")
+	for _, b := range []*ptp.Builder{
+		ptp.NewSyncStateChange("node1").State(ptp.HOLDOVER).ID("1"),
+		ptp.NewClockClassChange("node1", "ens1f0").ClockClass(6),
+		ptp.NewGnssStateChange("node1", "ens2f0").State(ptp.ANTENNA_DISCONNECTED).Offset(5),
+		ptp.NewSynceClockQualityChange("node1", "ens1f0").ClockQuality(2),
+	} {
+		e, err = b.Build()
+		require.NoError(t, err)
+		require.NoError(t, e.SetDataSchema(r.URI(e.Type)))
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		var got event.Event
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.NoError(t, r.Validate(&got))
+	}
+}
+
+func TestBuilder_Errors(t *testing.T) {
+	for name, b := range map[string]*ptp.Builder{
+		"state not allowed":   ptp.NewLockStateChange("node1", "ens1f0").State(ptp.BOOTING),
+		"state set twice":     ptp.NewLockStateChange("node1", "ens1f0").State(ptp.LOCKED).State(ptp.FREERUN),
+		"state missing":       ptp.NewLockStateChange("node1", "ens1f0").Offset(1),
+		"no state":            ptp.NewClockClassChange("node1", "ens1f0").State(ptp.LOCKED),
+		"metric missing":      ptp.NewClockClassChange("node1", "ens1f0"),
+		"metric not allowed":  ptp.NewSyncStateChange("node1").State(ptp.LOCKED).Offset(1),
+		"unknown event type":  ptp.NewBuilder("event.sync.unknown", "node1", ""),
+		"node missing":        ptp.NewSyncStateChange("").State(ptp.LOCKED),
+		"invalid node":        ptp.NewSyncStateChange("node 1").State(ptp.LOCKED),
+		"clock quality synce": ptp.NewSynceStateChange("node1", "ens1f0").State(ptp.LOCKED).ClockQuality(1),
+		"offset set twice":    ptp.NewLockStateChange("node1", "ens1f0").State(ptp.LOCKED).Offset(1).Offset(2),
+		"clock class twice":   ptp.NewClockClassChange("node1", "ens1f0").ClockClass(6).ClockClass(7),
+	} {
+		_, err := b.Build()
+		assert.Error(t, err, name)
+	}
+}
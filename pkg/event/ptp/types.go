@@ -49,3 +49,26 @@ const (
 	// SyncStateChange is Notification used to inform about the overall synchronization state change
 	SyncStateChange EventType = "event.sync.sync-status.synchronization-state-change"
 )
+
+// syncStates are the states of the notifications of each event type
+var syncStates = map[EventType][]SyncState{
+	GnssStateChange: {SYNCHRONIZED, ACQUIRING_SYNC, ANTENNA_DISCONNECTED, BOOTING, ANTENNA_SHORT_CIRCUIT,
+		FAILURE_MULTIPATH, FAILURE_NOFIX, FAILURE_LOW_SNR, FAILURE_PLL},
+	OsClockSyncStateChange:   {LOCKED, HOLDOVER, FREERUN},
+	PtpStateChange:           {LOCKED, HOLDOVER, FREERUN},
+	SynceStateChange:         {LOCKED, HOLDOVER, FREERUN},
+	SynceStateChangeExtended: {LOCKED, HOLDOVER, FREERUN},
+	SyncStateChange:          {LOCKED, HOLDOVER, FREERUN},
+}
+
+// EventTypes returns all the PTP event types
+func EventTypes() []EventType {
+	return []EventType{GnssStateChange, OsClockSyncStateChange, PtpClockClassChange, PtpStateChange,
+		SynceClockQualityChange, SynceStateChange, SynceStateChangeExtended, SyncStateChange}
+}
+
+// SyncStates returns the states allowed in the notifications of events of type t, events such as
+// PtpClockClassChange only carry metrics and have none
+func (t EventType) SyncStates() []SyncState {
+	return append([]SyncState(nil), syncStates[t]...)
+}
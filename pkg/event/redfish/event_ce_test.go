@@ -107,7 +107,7 @@ func TestEvent_NewCloudEvent(t *testing.T) {
 			cnePubsub: &pubsub,
 			want: func() *ce.Event {
 				e := ce.NewEvent()
-				e.SetSpecVersion(ce.VersionV03)
+				e.SetSpecVersion(ce.VersionV1)
 				e.SetType(_type)
 				_ = e.SetData(ce.ApplicationJSON, data)
 				e.SetTime(now.Time)
@@ -124,7 +124,7 @@ func TestEvent_NewCloudEvent(t *testing.T) {
 			event := tc.cneEvent
 			cEvent, err := event.NewCloudEvent(tc.cnePubsub)
 			assert.Nil(t, err)
-			tc.want.SetID(cEvent.ID())
+			assert.Equal(t, tc.want.ID(), cEvent.ID())
 			gotBytes, err := json.Marshal(cEvent)
 			log.Printf("cloud events %s\n", string(gotBytes))
 			if tc.wantErr != nil {
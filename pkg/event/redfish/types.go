@@ -33,3 +33,8 @@ const (
 	// StatusChange ...
 	StatusChange EventType = "event.redfish.status-change"
 )
+
+// EventTypes returns all the Redfish event types
+func EventTypes() []EventType {
+	return []EventType{Alert, ResourceAdded, ResourceUpdated, ResourceRemoved, StatusChange}
+}
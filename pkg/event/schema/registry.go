@@ -0,0 +1,263 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/redhat-cne/sdk-go/pkg/event"
+	"github.com/redhat-cne/sdk-go/pkg/event/ptp"
+	"github.com/redhat-cne/sdk-go/pkg/event/redfish"
+)
+
+// DefaultBaseURI is the base of the URIs of the schemas of a registry created without one
+const DefaultBaseURI = "https://github.com/redhat-cne/sdk-go/schemas/"
+
+// DataDocument is the name of the schema of the data of events of any type
+const DataDocument = "data.json"
+
+// durationPattern matches the durations accepted by time.ParseDuration
+const durationPattern = `^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// decimalPattern matches the decimals encoded as strings
+const decimalPattern = `^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][-+]?[0-9]+)?$`
+
+// Registry generates the schemas of the data of events and validates events against them. Every
+// event type has its own schema, based on the data schema with the constraints of the type.
+type Registry struct {
+	mu         sync.RWMutex
+	baseURI    string
+	values     map[event.ValueType]*Schema
+	eventTypes map[string]*Schema
+	documents  map[string]*Schema
+}
+
+// NewRegistry returns a registry of the schemas of the built-in value types and of the PTP and
+// Redfish event types, their URIs start with baseURI or DefaultBaseURI if it is empty
+func NewRegistry(baseURI string) *Registry {
+	if baseURI == "" {
+		baseURI = DefaultBaseURI
+	}
+	if !strings.HasSuffix(baseURI, "/") {
+		baseURI += "/"
+	}
+	r := &Registry{
+		baseURI: baseURI,
+		values: map[event.ValueType]*Schema{
+			event.ENUMERATION: {Type: "string"},
+			event.DECIMAL: {AnyOf: []*Schema{
+				{Type: "number"},
+				{Type: "string", Pattern: decimalPattern},
+			}},
+			event.INTEGER:       {Type: "integer"},
+			event.BOOLEAN:       {Type: "boolean"},
+			event.STRING:        {Type: "string"},
+			event.TIMESTAMP:     {Type: "string", Format: "date-time"},
+			event.DURATION:      {Type: "string", Pattern: durationPattern},
+			event.OBJECT:        {Type: "object"},
+			event.REDFISH_EVENT: redfishEventSchema(),
+		},
+		eventTypes: map[string]*Schema{},
+	}
+	for _, t := range ptp.EventTypes() {
+		var constraint *Schema
+		if states := t.SyncStates(); len(states) > 0 {
+			enum := make([]interface{}, len(states))
+			for i, state := range states {
+				enum[i] = string(state)
+			}
+			constraint = &Schema{
+				If: &Schema{
+					Properties: map[string]*Schema{
+						"data_type":  {Const: string(event.NOTIFICATION)},
+						"value_type": {Const: string(event.ENUMERATION)},
+					},
+				},
+				Then: &Schema{Properties: map[string]*Schema{"value": {Enum: enum}}},
+			}
+		} else {
+			// clock class and clock quality changes carry metrics only
+			constraint = &Schema{Properties: map[string]*Schema{"data_type": {Const: string(event.METRIC)}}}
+		}
+		r.eventTypes[string(t)] = constraint
+	}
+	for _, t := range redfish.EventTypes() {
+		r.eventTypes[string(t)] = &Schema{Properties: map[string]*Schema{
+			"value_type": {Const: string(event.REDFISH_EVENT)},
+		}}
+	}
+	return r
+}
+
+// redfishEventSchema returns the schema of redfish.Event, Resolution is left out of the records of
+// older Redfish services
+func redfishEventSchema() *Schema {
+	s := Reflect(redfish.Event{})
+	record := s.Properties["Events"].Items
+	required := record.Required[:0]
+	for _, name := range record.Required {
+		if name != "Resolution" {
+			required = append(required, name)
+		}
+	}
+	record.Required = required
+	return s
+}
+
+// SetValueSchema sets the schema of the values of valueType, such as a type added with
+// event.RegisterValueType
+func (r *Registry) SetValueSchema(valueType event.ValueType, s *Schema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[valueType] = s.Clone()
+	r.documents = nil
+}
+
+// SetEventType sets the constraint on every value of the events of eventType
+func (r *Registry) SetEventType(eventType string, value *Schema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.eventTypes[eventType] = value.Clone()
+	r.documents = nil
+}
+
+// URI returns the URI of the schema of the data of events of eventType. Event types without
+// their own schema use the data schema.
+func (r *Registry) URI(eventType string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.eventTypes[eventType]; ok {
+		return r.baseURI + eventType + ".json"
+	}
+	return r.baseURI + DataDocument
+}
+
+// Document returns the schema identified by uri
+func (r *Registry) Document(uri string) (*Schema, bool) {
+	s, ok := r.load()[uri]
+	return s.Clone(), ok
+}
+
+// Documents returns the JSON of every schema keyed by its URI, to publish them
+func (r *Registry) Documents() (map[string][]byte, error) {
+	result := map[string][]byte{}
+	for uri, s := range r.load() {
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		result[uri] = b
+	}
+	return result, nil
+}
+
+// Validate checks the data of e against the schema referenced by e.DataSchema, it implements
+// event.DataSchemas. Events referencing a schema out of the registry are not checked. The error
+// joins an *event.ValidationError for every mismatch.
+func (r *Registry) Validate(e *event.Event) error {
+	if e.DataSchema == nil {
+		return nil
+	}
+	s, ok := r.load()[e.GetDataSchema()]
+	if !ok {
+		return nil
+	}
+	if e.Data == nil {
+		return &event.ValidationError{Field: "data", Message: "is required by " + s.ID}
+	}
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return s.Validate("data", data)
+}
+
+// SetDataSchema sets the data schema of e to the schema of its type, unless e already has one.
+// Senders call it before writing e, the schema is part of the event and survives its conversions.
+func (r *Registry) SetDataSchema(e *event.Event) error {
+	if e.DataSchema != nil {
+		return nil
+	}
+	return e.SetDataSchema(r.URI(e.Type))
+}
+
+// Install makes the registry validate the events read, see event.SetDataSchemas. The events
+// written are not changed, see SetDataSchema.
+func (r *Registry) Install() {
+	event.SetDataSchemas(r)
+}
+
+// load returns the documents, generating them after a change
+func (r *Registry) load() map[string]*Schema {
+	r.mu.RLock()
+	documents := r.documents
+	r.mu.RUnlock()
+	if documents != nil {
+		return documents
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.documents == nil {
+		r.documents = r.generate()
+	}
+	return r.documents
+}
+
+func (r *Registry) generate() map[string]*Schema {
+	documents := map[string]*Schema{}
+	data := r.dataSchema(nil)
+	data.ID = r.baseURI + DataDocument
+	data.Title = "Cloud native event data"
+	documents[data.ID] = data
+	for eventType, constraint := range r.eventTypes {
+		s := r.dataSchema(constraint)
+		s.ID = r.baseURI + eventType + ".json"
+		s.Title = "Data of " + eventType + " events"
+		documents[s.ID] = s
+	}
+	return documents
+}
+
+// dataSchema returns the schema of event.Data, the values must match constraint if it is set
+func (r *Registry) dataSchema(constraint *Schema) *Schema {
+	data := Reflect(event.Data{})
+	data.Schema = Draft
+	data.Properties["version"].Pattern = event.DataVersionPattern
+	values := data.Properties["values"]
+	values.MinItems = 1
+
+	value := Reflect(event.DataValue{})
+	value.Properties["ResourceAddress"].Pattern = `^(/[^/\s]+)+$`
+	value.Properties["data_type"].Enum = []interface{}{string(event.NOTIFICATION), string(event.METRIC)}
+	valueTypes := make([]string, 0, len(r.values))
+	for valueType := range r.values {
+		valueTypes = append(valueTypes, string(valueType))
+	}
+	sort.Strings(valueTypes)
+	for _, valueType := range valueTypes {
+		value.AllOf = append(value.AllOf, &Schema{
+			If:   &Schema{Properties: map[string]*Schema{"value_type": {Const: valueType}}},
+			Then: &Schema{Properties: map[string]*Schema{"value": r.values[event.ValueType(valueType)].Clone()}},
+		})
+	}
+	if constraint != nil {
+		value.AllOf = append(value.AllOf, constraint.Clone())
+	}
+	values.Items = value
+	return data
+}
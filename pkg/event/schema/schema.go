@@ -0,0 +1,159 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/types"
+)
+
+// Draft is the JSON Schema dialect of the generated documents
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used by the generated documents
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Const       interface{}        `json:"const,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinItems    int                `json:"minItems,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	If          *Schema            `json:"if,omitempty"`
+	Then        *Schema            `json:"then,omitempty"`
+}
+
+// Clone returns a deep copy of s
+func (s *Schema) Clone() *Schema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Enum = append([]interface{}(nil), s.Enum...)
+	c.Required = append([]string(nil), s.Required...)
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, p := range s.Properties {
+			c.Properties[name] = p.Clone()
+		}
+	}
+	c.Items = s.Items.Clone()
+	c.AllOf = cloneAll(s.AllOf)
+	c.AnyOf = cloneAll(s.AnyOf)
+	c.If = s.If.Clone()
+	c.Then = s.Then.Clone()
+	return &c
+}
+
+func cloneAll(schemas []*Schema) []*Schema {
+	if schemas == nil {
+		return nil
+	}
+	result := make([]*Schema, len(schemas))
+	for i, s := range schemas {
+		result[i] = s.Clone()
+	}
+	return result
+}
+
+var (
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	bytesType     = reflect.TypeOf([]byte{})
+	timeType      = reflect.TypeOf(time.Time{})
+	timestampType = reflect.TypeOf(types.Timestamp{})
+	uriType       = reflect.TypeOf(types.URI{})
+)
+
+// Reflect returns the schema of the JSON encoding of the Go value v, struct fields are named and
+// required according to their json tags. Byte slices hold raw JSON and accept any value.
+func Reflect(v interface{}) *Schema {
+	return reflectType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func reflectType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case rawJSONType, bytesType:
+		return &Schema{}
+	case timeType, timestampType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uriType:
+		return &Schema{Type: "string", Format: "uri-reference"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := float64(0)
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: reflectType(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		if visiting[t] {
+			// recursive types are not described any further
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = reflectType(f.Type, visiting)
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	// interfaces and values of other kinds accept any value
+	return &Schema{}
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-cne/sdk-go/pkg/event"
+	"github.com/redhat-cne/sdk-go/pkg/event/ptp"
+	"github.com/redhat-cne/sdk-go/pkg/event/redfish"
+	"github.com/redhat-cne/sdk-go/pkg/event/schema"
+)
+
+func newEvent(eventType string, values ...event.DataValue) event.Event {
+	e := event.Event{ID: "ABC-1234", Type: eventType, Source: "/cluster/node/ptp"}
+	e.SetTime(time.Now())
+	e.SetData(event.Data{Version: "v1", Values: values})
+	return e
+}
+
+func notification(value interface{}) event.DataValue {
+	return event.DataValue{Resource: string(ptp.PtpLockState), DataType: event.NOTIFICATION,
+		ValueType: event.ENUMERATION, Value: value}
+}
+
+func metric(valueType event.ValueType, value interface{}) event.DataValue {
+	return event.DataValue{Resource: string(ptp.PtpClockClass), DataType: event.METRIC,
+		ValueType: valueType, Value: value}
+}
+
+func fields(err error) []string {
+	var result []string
+	for _, e := range event.ValidationErrors(err) {
+		result = append(result, e.Field)
+	}
+	return result
+}
+
+func TestReflect(t *testing.T) {
+	s := schema.Reflect(redfish.Event{})
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, []string{"@odata.type", "Events", "Id", "Name"}, s.Required)
+	assert.Equal(t, "array", s.Properties["Events"].Type)
+	assert.Equal(t, "string", s.Properties["Events"].Items.Properties["MessageId"].Type)
+	assert.Equal(t, "integer", s.Properties["Events"].Items.Properties["EventGroupId"].Type)
+	// raw JSON
+	assert.Equal(t, &schema.Schema{}, s.Properties["Oem"])
+
+	s = schema.Reflect(event.Event{})
+	assert.Equal(t, "date-time", s.Properties["time"].Format)
+	assert.Equal(t, "uri-reference", s.Properties["dataSchema"].Format)
+	assert.NotContains(t, s.Required, "dataSchema")
+}
+
+func TestRegistry_Documents(t *testing.T) {
+	r := schema.NewRegistry("https://example.com/schemas")
+	assert.Equal(t, "https://example.com/schemas/data.json", r.URI("unknown"))
+	uri := r.URI(string(ptp.PtpStateChange))
+	assert.Equal(t, "https://example.com/schemas/"+string(ptp.PtpStateChange)+".json", uri)
+
+	documents, err := r.Documents()
+	require.NoError(t, err)
+	assert.Len(t, documents, 1+len(ptp.EventTypes())+len(redfish.EventTypes()))
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(documents[uri], &doc))
+	assert.Equal(t, schema.Draft, doc["$schema"])
+	assert.Equal(t, uri, doc["$id"])
+	assert.Contains(t, string(documents[uri]), `"HOLDOVER"`)
+	assert.NotContains(t, string(documents[uri]), `"BOOTING"`)
+
+	s, ok := r.Document(uri)
+	require.True(t, ok)
+	assert.Equal(t, []string{"version", "values"}, s.Required)
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	r := schema.NewRegistry("")
+	testCases := map[string]struct {
+		event event.Event
+		want  []string
+	}{
+		"ptp state": {
+			event: newEvent(string(ptp.PtpStateChange), notification(ptp.LOCKED), metric(event.DECIMAL, "-12.5")),
+		},
+		"ptp state not allowed": {
+			event: newEvent(string(ptp.PtpStateChange), notification(ptp.BOOTING)),
+			want:  []string{"data.values[0].value"},
+		},
+		"gnss state": {
+			event: newEvent(string(ptp.GnssStateChange), notification(ptp.ANTENNA_DISCONNECTED)),
+		},
+		"clock class is a metric": {
+			event: newEvent(string(ptp.PtpClockClassChange), metric(event.DECIMAL, 6), notification(ptp.LOCKED)),
+			want:  []string{"data.values[1].data_type"},
+		},
+		"value types": {
+			event: newEvent("custom", metric(event.INTEGER, 3), metric(event.DURATION, time.Minute),
+				metric(event.TIMESTAMP, time.Now()), metric(event.OBJECT, map[string]int{"a": 1})),
+		},
+		"data": {
+			event: func() event.Event {
+				e := newEvent("custom", metric(event.DECIMAL, 1))
+				e.Data.Version = "latest"
+				e.Data.Values[0].Resource = "clock-class"
+				e.Data.Values[0].DataType = "alarm"
+				return e
+			}(),
+			want: []string{"data.values[0].ResourceAddress", "data.values[0].data_type", "data.version"},
+		},
+		"no values": {
+			event: newEvent(string(ptp.SyncStateChange)),
+			want:  []string{"data.values"},
+		},
+		"redfish": {
+			event: newEvent(string(redfish.Alert), event.DataValue{Resource: string(redfish.Systems),
+				DataType: event.NOTIFICATION, ValueType: event.REDFISH_EVENT, Value: redfish.Event{
+					OdataType: "#Event.v1_3_0.Event", ID: "1", Name: "Event Array",
+					Events: []redfish.EventRecord{{EventType: "Alert", MemberID: "0", MessageID: "TMP0100"}},
+				}}),
+		},
+		"redfish value type": {
+			event: newEvent(string(redfish.StatusChange), notification("OK")),
+			want:  []string{"data.values[0].value_type"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			e := tc.event
+			require.NoError(t, e.SetDataSchema(r.URI(e.Type)))
+			err := r.Validate(&e)
+			if tc.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, event.ErrInvalidEvent)
+			assert.Equal(t, tc.want, fields(err))
+		})
+	}
+
+	// schemas out of the registry are not checked
+	e := newEvent(string(ptp.PtpStateChange), notification(ptp.BOOTING))
+	assert.NoError(t, r.Validate(&e))
+	require.NoError(t, e.SetDataSchema("https://example.com/schema.json"))
+	assert.NoError(t, r.Validate(&e))
+}
+
+func TestRegistry_SetValueSchema(t *testing.T) {
+	require.NoError(t, event.RegisterValueType("percent", event.ValueCodecFuncs{
+		EncodeFunc: func(v interface{}) ([]byte, error) { return json.Marshal(v) },
+		DecodeFunc: func(data []byte) (v interface{}, err error) {
+			err = json.Unmarshal(data, &v)
+			return
+		},
+	}))
+	r := schema.NewRegistry("")
+	r.SetValueSchema("percent", &schema.Schema{Type: "number", Minimum: new(float64)})
+	e := newEvent("custom", metric("percent", 12))
+	require.NoError(t, e.SetDataSchema(r.URI(e.Type)))
+	data, err := json.Marshal(e.Data)
+	require.NoError(t, err)
+	s, _ := r.Document(r.URI(e.Type))
+	assert.NoError(t, s.Validate("data", data))
+	assert.Equal(t, []string{"data.values[0].value"},
+		fields(s.Validate("data", []byte(`{"version":"v1","values":[{"ResourceAddress":"/a","data_type":"metric","value_type":"percent","value":-1}]}`))))
+
+	r.SetEventType("custom", &schema.Schema{Properties: map[string]*schema.Schema{"ResourceAddress": {Const: "/a"}}})
+	assert.NoError(t, r.Validate(&e))
+	require.NoError(t, e.SetDataSchema(r.URI(e.Type)))
+	assert.Equal(t, []string{"data.values[0].ResourceAddress"}, fields(r.Validate(&e)))
+}
+
+func TestRegistry_Install(t *testing.T) {
+	r := schema.NewRegistry("")
+	r.Install()
+	t.Cleanup(func() { event.SetDataSchemas(nil) })
+
+	// events are written as they are
+	e := newEvent(string(ptp.PtpStateChange), notification(ptp.LOCKED))
+	b, err := json.Marshal(e)
+	require.NoError(t, err)
+	var got event.Event
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Nil(t, got.DataSchema)
+	ce, err := e.ToCloudEvent()
+	require.NoError(t, err)
+	assert.Equal(t, "", ce.DataSchema())
+
+	require.NoError(t, r.SetDataSchema(&e))
+	assert.Equal(t, r.URI(string(ptp.PtpStateChange)), e.GetDataSchema())
+	b, err = json.Marshal(e)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, r.URI(string(ptp.PtpStateChange)), got.GetDataSchema())
+	ce, err = e.ToCloudEvent()
+	require.NoError(t, err)
+	assert.Equal(t, r.URI(string(ptp.PtpStateChange)), ce.DataSchema())
+
+	// a data schema set by the sender is kept
+	other := newEvent(string(ptp.PtpStateChange), notification(ptp.LOCKED))
+	require.NoError(t, other.SetDataSchema("https://example.com/schema.json"))
+	require.NoError(t, r.SetDataSchema(&other))
+	assert.Equal(t, "https://example.com/schema.json", other.GetDataSchema())
+
+	// received events are checked against their schema
+	e.Data.Values[0].Value = ptp.BOOTING
+	b, err = json.Marshal(e)
+	require.NoError(t, err)
+	err = json.Unmarshal(b, &got)
+	assert.True(t, errors.Is(err, event.ErrInvalidEvent))
+	ce, err = e.ToCloudEvent()
+	require.NoError(t, err)
+	assert.ErrorIs(t, got.GetCloudNativeEvents(ce), event.ErrInvalidEvent)
+}
@@ -0,0 +1,238 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/event"
+)
+
+// Validate checks the JSON document data against s and returns the errors.Join of an
+// *event.ValidationError for every mismatch, their fields start with path
+func (s *Schema) Validate(path string, data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return &event.ValidationError{Field: path, Message: fmt.Sprintf("is not JSON: %v", err)}
+	}
+	var errs []error
+	s.validate(path, v, &errs)
+	return errors.Join(errs...)
+}
+
+func (s *Schema) validate(path string, v interface{}, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &event.ValidationError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.Type != "" && !hasType(v, s.Type) {
+		fail("%s is not of type %s", describe(v), s.Type)
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		fail("%s is not one of %v", describe(v), s.Enum)
+	}
+	if s.Const != nil && !equal(v, s.Const) {
+		fail("%s is not %v", describe(v), s.Const)
+	}
+	switch t := v.(type) {
+	case string:
+		if s.Pattern != "" {
+			if re, err := compile(s.Pattern); err != nil {
+				fail("invalid pattern %s: %v", s.Pattern, err)
+			} else if !re.MatchString(t) {
+				fail("%q does not match %s", t, s.Pattern)
+			}
+		}
+		if err := checkFormat(s.Format, t); err != nil {
+			fail("%q is not a %s: %v", t, s.Format, err)
+		}
+	case json.Number:
+		f, _ := t.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("%s is less than %v", t, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("%s is greater than %v", t, *s.Maximum)
+		}
+	case []interface{}:
+		if len(t) < s.MinItems {
+			fail("has %d items, at least %d are required", len(t), s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range t {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := t[name]; !ok {
+				*errs = append(*errs, &event.ValidationError{Field: path + "." + name, Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if pv, ok := t[name]; ok {
+				s.Properties[name].validate(path+"."+name, pv, errs)
+			}
+		}
+	}
+	for _, sub := range s.AllOf {
+		sub.validate(path, v, errs)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			var subErrs []error
+			if sub.validate(path, v, &subErrs); len(subErrs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("%s does not match any of the allowed schemas", describe(v))
+		}
+	}
+	if s.If != nil {
+		var ifErrs []error
+		if s.If.validate(path, v, &ifErrs); len(ifErrs) == 0 && s.Then != nil {
+			s.Then.validate(path, v, errs)
+		}
+	}
+}
+
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+func checkFormat(format, s string) error {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, s)
+	case "uri-reference":
+		_, err = url.Parse(s)
+	case "uri":
+		var u *url.URL
+		if u, err = url.Parse(s); err == nil && !u.IsAbs() {
+			err = fmt.Errorf("not absolute")
+		}
+	}
+	return err
+}
+
+// normalize converts numbers to float64 so that the values decoded from a document compare
+// with the values of a schema
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		f, _ := t.Float64()
+		return f
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		f, _ := strconv.ParseFloat(fmt.Sprint(t), 64)
+		return f
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String && rv.Type() != reflect.TypeOf("") {
+		// named string types such as ptp.SyncState
+		return rv.String()
+	}
+	return v
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if equal(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(t)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprint(v)
+}
+
+var patterns sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localmetrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StoreMetrics holds the metrics of a pub/sub or subscriber store instance.
+// A nil *StoreMetrics is valid and records nothing.
+type StoreMetrics struct {
+	//objectCount ... number of objects held by the store
+	objectCount *prometheus.GaugeVec
+	//writeCount ... number of writes to the store backend
+	writeCount *prometheus.CounterVec
+}
+
+// NewStoreMetrics creates store metrics and registers them with reg.
+// Collectors already registered with reg are reused.
+func NewStoreMetrics(reg prometheus.Registerer) *StoreMetrics {
+	m := &StoreMetrics{
+		objectCount: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cne_store_objects",
+				Help: "Metric to get number of publishers, subscriptions and clients held by the store",
+			}, []string{"kind"}),
+		writeCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cne_store_writes_total",
+				Help: "Metric to get number of writes to the store backend",
+			}, []string{"kind", "status"}),
+	}
+	m.objectCount = register(reg, m.objectCount).(*prometheus.GaugeVec)
+	m.writeCount = register(reg, m.writeCount).(*prometheus.CounterVec)
+	return m
+}
+
+func register(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+// SetObjectCount ...
+func (m *StoreMetrics) SetObjectCount(kind string, val int) {
+	if m == nil {
+		return
+	}
+	m.objectCount.With(prometheus.Labels{"kind": kind}).Set(float64(val))
+}
+
+// IncWriteCount ...
+func (m *StoreMetrics) IncWriteCount(kind string, status MetricStatus) {
+	if m == nil {
+		return
+	}
+	m.writeCount.With(prometheus.Labels{"kind": kind, "status": string(status)}).Inc()
+}
@@ -16,6 +16,7 @@ package pubsub
 
 import (
 	"strings"
+	"time"
 
 	"github.com/redhat-cne/sdk-go/pkg/types"
 )
@@ -43,6 +44,31 @@ type PubSub struct {
 	// Resource - The type of the Resource.
 	// +required
 	Resource string `json:"ResourceAddress" example:"/east-edge-10/vdu3/o-ran-sync/sync-group/sync-status/sync-state"`
+	// CreatedAt - The time the pub/sub was created in the store, omitted if unknown.
+	CreatedAt time.Time `json:"CreatedAt,omitempty"`
+	// Revision - Incremented on every change of the pub/sub, used for conditional updates.
+	Revision uint64 `json:"Revision,omitempty"`
+	// Namespace - The tenant owning the pub/sub, empty for the DefaultNamespace.
+	Namespace string `json:"Namespace,omitempty"`
+}
+
+// DefaultNamespace is the namespace of pub/subs and subscribers created without one
+const DefaultNamespace = "default"
+
+// Namespace returns namespace, or DefaultNamespace if it is empty
+func Namespace(namespace string) string {
+	if namespace = strings.TrimSpace(namespace); namespace == "" {
+		return DefaultNamespace
+	}
+	return namespace
+}
+
+// Copy returns a copy of ps that does not share its URIs
+func (ps *PubSub) Copy() PubSub {
+	c := *ps
+	c.EndPointURI = ps.EndPointURI.Copy()
+	c.URILocation = ps.URILocation.Copy()
+	return c
 }
 
 // String returns a pretty-printed representation of the Event.
@@ -14,6 +14,8 @@
 
 package pubsub
 
+import "time"
+
 // Reader is the interface for reading through an event from attributes.
 type Reader interface {
 	// GetResource returns event.GetResource()
@@ -23,6 +25,12 @@ type Reader interface {
 	// GetURILocation returns event.GetUriLocation()
 	GetURILocation() string
 	GetID() string
+	// GetCreatedAt returns the creation time
+	GetCreatedAt() time.Time
+	// GetRevision returns the revision
+	GetRevision() uint64
+	// GetNamespace returns the namespace
+	GetNamespace() string
 	// String returns a pretty-printed representation of the PubSub.
 	String() string
 }
@@ -39,4 +47,10 @@ type Writer interface {
 	SetURILocation(string) error
 	// SetID performs event.SetID.
 	SetID(string)
+	// SetCreatedAt sets the creation time
+	SetCreatedAt(time.Time)
+	// SetRevision sets the revision
+	SetRevision(uint64)
+	// SetNamespace sets the namespace
+	SetNamespace(string)
 }
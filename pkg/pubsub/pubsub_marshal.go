@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 )
@@ -48,6 +49,24 @@ func WriteJSON(in *PubSub, writer io.Writer) error {
 		stream.WriteString(in.GetURILocation())
 	}
 
+	if !in.GetCreatedAt().IsZero() {
+		stream.WriteMore()
+		stream.WriteObjectField("CreatedAt")
+		stream.WriteString(in.GetCreatedAt().Format(time.RFC3339Nano))
+	}
+
+	if in.GetRevision() != 0 {
+		stream.WriteMore()
+		stream.WriteObjectField("Revision")
+		stream.WriteUint64(in.GetRevision())
+	}
+
+	if in.GetNamespace() != DefaultNamespace {
+		stream.WriteMore()
+		stream.WriteObjectField("Namespace")
+		stream.WriteString(in.GetNamespace())
+	}
+
 	// Let's do a check on the error
 	if stream.Error != nil {
 		return fmt.Errorf("error while writing the event attributes: %w", stream.Error)
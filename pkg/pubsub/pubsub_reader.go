@@ -14,6 +14,8 @@
 
 package pubsub
 
+import "time"
+
 var _ Reader = (*PubSub)(nil)
 
 // GetResource implements EventReader.Resource
@@ -31,6 +33,21 @@ func (ps *PubSub) GetEndpointURI() string {
 	return ps.EndPointURI.String()
 }
 
+// GetCreatedAt returns the creation time, zero if unknown
+func (ps *PubSub) GetCreatedAt() time.Time {
+	return ps.CreatedAt
+}
+
+// GetRevision returns the revision, zero if the pub/sub was never stored
+func (ps *PubSub) GetRevision() uint64 {
+	return ps.Revision
+}
+
+// GetNamespace returns the namespace, DefaultNamespace if none was set
+func (ps *PubSub) GetNamespace() string {
+	return Namespace(ps.Namespace)
+}
+
 // GetURILocation returns uri location
 func (ps *PubSub) GetURILocation() string {
 	return ps.URILocation.String()
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 )
@@ -54,6 +55,10 @@ func readJSONFromIterator(out *PubSub, iterator *jsoniter.Iterator) error {
 		endpointUri string //nolint:revive
 		uriLocation string
 		resource    string
+		createdAt   time.Time
+		revision    uint64
+		namespace   string
+		err         error
 	)
 
 	for key := iterator.ReadObject(); key != ""; key = iterator.ReadObject() {
@@ -72,6 +77,14 @@ func readJSONFromIterator(out *PubSub, iterator *jsoniter.Iterator) error {
 			uriLocation = iterator.ReadString()
 		case "ResourceAddress":
 			resource = iterator.ReadString()
+		case "CreatedAt":
+			if createdAt, err = time.Parse(time.RFC3339Nano, iterator.ReadString()); err != nil {
+				return fmt.Errorf("invalid CreatedAt: %w", err)
+			}
+		case "Revision":
+			revision = iterator.ReadUint64()
+		case "Namespace":
+			namespace = iterator.ReadString()
 		default:
 			iterator.Skip()
 		}
@@ -92,6 +105,11 @@ func readJSONFromIterator(out *PubSub, iterator *jsoniter.Iterator) error {
 	out.SetEndpointURI(endpointUri) //nolint:errcheck
 	out.SetURILocation(uriLocation) //nolint:errcheck
 	out.SetResource(resource)       //nolint:errcheck
+	if !createdAt.IsZero() {
+		out.SetCreatedAt(createdAt)
+	}
+	out.SetRevision(revision)
+	out.SetNamespace(namespace)
 
 	return nil
 }
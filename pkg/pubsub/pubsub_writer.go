@@ -19,6 +19,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/redhat-cne/sdk-go/pkg/types"
 )
@@ -41,6 +42,24 @@ func (ps *PubSub) SetID(id string) {
 	ps.ID = id
 }
 
+// SetCreatedAt sets the creation time in UTC
+func (ps *PubSub) SetCreatedAt(t time.Time) {
+	ps.CreatedAt = t.UTC()
+}
+
+// SetRevision sets the revision
+func (ps *PubSub) SetRevision(revision uint64) {
+	ps.Revision = revision
+}
+
+// SetNamespace sets the namespace
+func (ps *PubSub) SetNamespace(namespace string) {
+	if namespace = Namespace(namespace); namespace == DefaultNamespace {
+		namespace = ""
+	}
+	ps.Namespace = namespace
+}
+
 // SetEndpointURI ...
 func (ps *PubSub) SetEndpointURI(s string) error {
 	s = strings.TrimSpace(s)
@@ -0,0 +1,134 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Separator between the segments of a resource address
+	Separator = "/"
+	// AnySegment matches exactly one non-empty segment, e.g. /cluster/node/*/sync
+	AnySegment = "*"
+	// AnyPath matches zero or more segments, e.g. /sync/ptp-status/**
+	AnyPath = "**"
+)
+
+// Matcher is a compiled resource address pattern
+type Matcher struct {
+	pattern  string
+	segments []string
+	wildcard bool
+}
+
+// Compile parses a resource address pattern. Wildcards must span a whole segment,
+// a segment such as "ptp-*" is rejected.
+func Compile(pattern string) (*Matcher, error) {
+	m := &Matcher{pattern: pattern, segments: strings.Split(pattern, Separator)}
+	for _, s := range m.segments {
+		switch {
+		case s == AnySegment, s == AnyPath:
+			m.wildcard = true
+		case strings.Contains(s, AnySegment):
+			return nil, fmt.Errorf("invalid resource pattern %q: wildcard %q must be a whole segment", pattern, s)
+		}
+	}
+	return m, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is invalid
+func MustCompile(pattern string) *Matcher {
+	m, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// String returns the pattern the matcher was compiled from
+func (m *Matcher) String() string {
+	return m.pattern
+}
+
+// HasWildcard returns true if the pattern contains a wildcard segment
+func (m *Matcher) HasWildcard() bool {
+	return m.wildcard
+}
+
+// Match reports whether address matches the pattern
+func (m *Matcher) Match(address string) bool {
+	if !m.wildcard {
+		return m.pattern == address
+	}
+	return matchSegments(m.segments, strings.Split(address, Separator))
+}
+
+func matchSegments(pattern, address []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case AnyPath:
+			// collapse consecutive ** and try every possible tail of the address
+			for len(pattern) > 0 && pattern[0] == AnyPath {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(address); i++ {
+				if matchSegments(pattern, address[i:]) {
+					return true
+				}
+			}
+			return false
+		case AnySegment:
+			if len(address) == 0 || address[0] == "" {
+				return false
+			}
+		default:
+			if len(address) == 0 || address[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, address = pattern[1:], address[1:]
+	}
+	return len(address) == 0
+}
+
+// HasWildcard returns true if pattern contains a wildcard segment
+func HasWildcard(pattern string) bool {
+	for _, s := range strings.Split(pattern, Separator) {
+		if s == AnySegment || s == AnyPath {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether address matches pattern. Invalid patterns only match themselves.
+func Match(pattern, address string) bool {
+	if pattern == address {
+		return true
+	}
+	if !HasWildcard(pattern) {
+		return false
+	}
+	m, err := Compile(pattern)
+	if err != nil {
+		return false
+	}
+	return m.Match(address)
+}
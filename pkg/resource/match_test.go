@@ -0,0 +1,65 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		address string
+		match   bool
+	}{
+		{"/cluster/node/ptp", "/cluster/node/ptp", true},
+		{"/cluster/node/ptp", "/cluster/node/ptp2", false},
+		{"/cluster/node/*/sync/sync-status/sync-state", "/cluster/node/worker-1/sync/sync-status/sync-state", true},
+		{"/cluster/node/*/sync/sync-status/sync-state", "/cluster/node/sync/sync-status/sync-state", false},
+		{"/cluster/node/*/sync/sync-status/sync-state", "/cluster/node/a/b/sync/sync-status/sync-state", false},
+		{"/cluster/node/*", "/cluster/node/", false},
+		{"/sync/ptp-status/**", "/sync/ptp-status/lock-state", true},
+		{"/sync/ptp-status/**", "/sync/ptp-status/a/b/c", true},
+		{"/sync/ptp-status/**", "/sync/ptp-status", true},
+		{"/sync/ptp-status/**", "/sync/gnss-status/gnss-sync-status", false},
+		{"/cluster/**/sync-state", "/cluster/node/worker-1/sync/sync-status/sync-state", true},
+		{"/cluster/**/sync-state", "/cluster/sync-state", true},
+		{"/cluster/**/sync-state", "/cluster/node/lock-state", false},
+		{"/**/**/lock-state", "/cluster/node/lock-state", true},
+		{"**", "/any/address", true},
+		{"/*/*", "/a/b", true},
+		{"/*/*", "/a/b/c", false},
+	}
+	for _, tc := range tests {
+		m, err := resource.Compile(tc.pattern)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.match, m.Match(tc.address), "%s ~ %s", tc.pattern, tc.address)
+		assert.Equal(t, tc.match, resource.Match(tc.pattern, tc.address), "%s ~ %s", tc.pattern, tc.address)
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, pattern := range []string{"/sync/ptp-*", "/sync/***", "/a/*b/c"} {
+		_, err := resource.Compile(pattern)
+		assert.NotNil(t, err, pattern)
+		assert.False(t, resource.Match(pattern, "/sync/ptp-status"))
+		assert.True(t, resource.Match(pattern, pattern))
+	}
+	assert.False(t, resource.HasWildcard("/cluster/node/ptp"))
+	assert.True(t, resource.HasWildcard("/cluster/*/ptp"))
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+// Mode selects how an archive is combined with the content of a store
+type Mode int
+
+const (
+	// Merge adds the records missing from the store, records that differ from the stored
+	// ones are reported as conflicts and left unchanged
+	Merge Mode = iota
+	// Replace discards the content of every section present in the archive
+	Replace
+)
+
+// String represent of Mode enum
+func (m Mode) String() string {
+	switch m {
+	case Merge:
+		return "merge"
+	case Replace:
+		return "replace"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Section names used in conflicts
+const (
+	SectionPublishers    = "publishers"
+	SectionSubscriptions = "subscriptions"
+	SectionClients       = "clients"
+)
+
+// Archive is the pub/sub state of a node. A nil section was not exported and is left
+// unchanged on import, an empty section is exported as an empty list.
+type Archive struct {
+	CreatedAt     time.Time               `json:"createdAt"`
+	Publishers    []pubsub.PubSub         `json:"publishers"`
+	Subscriptions []pubsub.PubSub         `json:"subscriptions"`
+	Clients       []subscriber.Subscriber `json:"clients"`
+}
+
+// Conflict is an archive record that was not imported
+type Conflict struct {
+	Section string `json:"section"`
+	ID      string `json:"id"`
+	Reason  string `json:"reason"`
+}
+
+// String returns the conflict as a single line
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s: %s", c.Section, c.ID, c.Reason)
+}
+
+// Report summarizes an import, clients count as a single record
+type Report struct {
+	Added     int        `json:"added"`
+	Updated   int        `json:"updated"`
+	Unchanged int        `json:"unchanged"`
+	Removed   int        `json:"removed"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// Merge adds the counts and conflicts of other to r
+func (r *Report) Merge(other Report) {
+	r.Added += other.Added
+	r.Updated += other.Updated
+	r.Unchanged += other.Unchanged
+	r.Removed += other.Removed
+	r.Conflicts = append(r.Conflicts, other.Conflicts...)
+}
+
+// Conflict records a record that was not imported
+func (r *Report) Conflict(section, id, format string, args ...interface{}) {
+	r.Conflicts = append(r.Conflicts, Conflict{Section: section, ID: id, Reason: fmt.Sprintf(format, args...)})
+}
+
+// String returns a summary of the report
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "added %d, updated %d, unchanged %d, removed %d, %d conflicts",
+		r.Added, r.Updated, r.Unchanged, r.Removed, len(r.Conflicts))
+	for _, c := range r.Conflicts {
+		b.WriteString("\n  ")
+		b.WriteString(c.String())
+	}
+	return b.String()
+}
+
+// Part is implemented by the stores owning sections of an archive
+type Part interface {
+	// ExportTo sets the sections owned by the store
+	ExportTo(a *Archive) error
+	// ImportFrom applies the sections owned by the store that are present in a, opts set the
+	// actor recorded for the changes
+	ImportFrom(a *Archive, mode Mode, opts ...store.CallOption) (Report, error)
+}
+
+// Encode writes a in the current schema version
+func Encode(w io.Writer, a *Archive) error {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	b, err := schema.Wrap(schema.KindArchive, payload)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Decode reads an archive written by Encode
+func Decode(r io.Reader) (*Archive, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("archive is empty")
+	}
+	payload, _, err := schema.Unwrap(schema.KindArchive, data)
+	if err != nil {
+		return nil, err
+	}
+	var a Archive
+	if err = json.Unmarshal(payload, &a); err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	return &a, nil
+}
+
+// Export writes the sections of every part to w as a single archive
+func Export(w io.Writer, parts ...Part) error {
+	a := &Archive{CreatedAt: time.Now().UTC()}
+	for _, p := range parts {
+		if err := p.ExportTo(a); err != nil {
+			return err
+		}
+	}
+	return Encode(w, a)
+}
+
+// Import reads an archive from r and applies it to every part. Parts are applied in order,
+// an error stops the import and the report covers the parts applied so far.
+func Import(r io.Reader, mode Mode, parts ...Part) (Report, error) {
+	var report Report
+	if err := CheckMode(mode); err != nil {
+		return report, err
+	}
+	a, err := Decode(r)
+	if err != nil {
+		return report, err
+	}
+	for _, p := range parts {
+		partReport, err := p.ImportFrom(a, mode)
+		report.Merge(partReport)
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// CheckMode returns an error for an unknown mode
+func CheckMode(mode Mode) error {
+	if mode != Merge && mode != Replace {
+		return fmt.Errorf("unknown import mode %s", mode)
+	}
+	return nil
+}
+
+// SameRecord reports whether two publishers or subscriptions differ only by their store
+// metadata, i.e. creation time and revision
+func SameRecord(a, b pubsub.PubSub) bool {
+	return a.GetID() == b.GetID() && a.GetResource() == b.GetResource() && a.GetNamespace() == b.GetNamespace() &&
+		a.GetEndpointURI() == b.GetEndpointURI() && a.GetURILocation() == b.GetURILocation()
+}
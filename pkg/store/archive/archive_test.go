@@ -0,0 +1,98 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive_test
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+	"github.com/redhat-cne/sdk-go/pkg/types"
+	pubsubapi "github.com/redhat-cne/sdk-go/v1/pubsub"
+	subscriberapi "github.com/redhat-cne/sdk-go/v1/subscriber"
+	"github.com/stretchr/testify/assert"
+)
+
+var endpoint = &types.URI{URL: url.URL{Scheme: "http", Host: "localhost:8080", Path: "/event"}}
+
+func TestEncodeDecode(t *testing.T) {
+	a := &archive.Archive{Publishers: []pubsub.PubSub{{ID: "1", EndPointURI: endpoint, Resource: "/a"}}}
+	var b bytes.Buffer
+	assert.Nil(t, archive.Encode(&b, a))
+	assert.Contains(t, b.String(), `"kind": "archive"`)
+
+	decoded, err := archive.Decode(&b)
+	assert.Nil(t, err)
+	assert.Len(t, decoded.Publishers, 1)
+	assert.True(t, archive.SameRecord(a.Publishers[0], decoded.Publishers[0]))
+	// sections that were not exported stay nil
+	assert.Nil(t, decoded.Subscriptions)
+	assert.Nil(t, decoded.Clients)
+
+	_, err = archive.Decode(strings.NewReader(""))
+	assert.NotNil(t, err)
+	wrongKind, _ := schema.Wrap(schema.KindSubscriber, []byte("{}"))
+	_, err = archive.Decode(bytes.NewReader(wrongKind))
+	assert.NotNil(t, err)
+}
+
+func TestImportMode(t *testing.T) {
+	_, err := archive.Import(strings.NewReader("{}"), archive.Mode(5))
+	assert.NotNil(t, err)
+	assert.Equal(t, "replace", archive.Replace.String())
+}
+
+func TestExportImport(t *testing.T) {
+	pubs := pubsubapi.NewAPI()
+	subs := subscriberapi.NewAPI()
+	_, err := pubs.CreatePublisher(pubsubapi.NewPubSub(endpoint, "/pub"))
+	assert.Nil(t, err)
+	_, err = pubs.CreateSubscription(pubsubapi.NewPubSub(endpoint, "/sub"))
+	assert.Nil(t, err)
+	clientID := uuid.New()
+	client := subscriberapi.NewSubscriber(clientID)
+	client.SubStore.Store = map[string]*pubsub.PubSub{"1": {ID: "1", EndPointURI: endpoint, Resource: "/sub"}}
+	_ = client.SetEndPointURI(endpoint.String())
+	_, err = subs.CreateSubscription(clientID, client)
+	assert.Nil(t, err)
+
+	var b bytes.Buffer
+	assert.Nil(t, archive.Export(&b, pubs, subs))
+	data := b.Bytes()
+
+	otherPubs := pubsubapi.NewAPI()
+	otherSubs := subscriberapi.NewAPI()
+	report, err := archive.Import(bytes.NewReader(data), archive.Merge, otherPubs, otherSubs)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, report.Added)
+	assert.Empty(t, report.Conflicts)
+	assert.Len(t, otherPubs.GetPublishers(), 1)
+	assert.Len(t, otherPubs.GetSubscriptions(), 1)
+	assert.Len(t, otherSubs.GetSubscriptionsFromClientID(clientID), 1)
+	c, err := otherSubs.GetSubscriptionClient(clientID)
+	assert.Nil(t, err)
+	assert.Equal(t, subscriber.Active, c.GetStatus())
+
+	// importing the same archive again changes nothing
+	report, err = archive.Import(bytes.NewReader(data), archive.Merge, otherPubs, otherSubs)
+	assert.Nil(t, err)
+	assert.Equal(t, archive.Report{Unchanged: 3}, report)
+}
@@ -0,0 +1,315 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
+	log "github.com/sirupsen/logrus"
+)
+
+// Action is the kind of change recorded
+type Action string
+
+const (
+	// ActionCreate a record was created
+	ActionCreate Action = "create"
+	// ActionUpdate a record was changed
+	ActionUpdate Action = "update"
+	// ActionDelete a record was deleted on request
+	ActionDelete Action = "delete"
+	// ActionFail a client reached the failed delivery threshold and is marked for deletion
+	ActionFail Action = "fail"
+	// ActionQuarantine a client reached the failed delivery threshold and is quarantined
+	ActionQuarantine Action = "quarantine"
+	// ActionAutoDelete a record was deleted by the store itself, e.g. after failed deliveries or an expired lease
+	ActionAutoDelete Action = "auto-delete"
+	// ActionReload the store was reloaded from its backend
+	ActionReload Action = "reload"
+)
+
+// Kind is the type of the changed record
+type Kind string
+
+const (
+	// KindPublisher a publisher
+	KindPublisher Kind = "publisher"
+	// KindSubscription a subscription
+	KindSubscription Kind = "subscription"
+	// KindClient a subscriber client with all its subscriptions
+	KindClient Kind = "client"
+	// KindStore the whole store
+	KindStore Kind = "store"
+)
+
+const (
+	// ActorAPI is the default actor of the changes requested through the v1 APIs
+	ActorAPI = "api"
+	// ActorSystem is the actor of the changes made by the store itself
+	ActorSystem = "system"
+)
+
+const (
+	// DefaultKey is the backend key of the current audit log
+	DefaultKey = "audit.log"
+	// DefaultMaxSize is the size in bytes above which the log is rotated
+	DefaultMaxSize = 1 << 20
+	// DefaultMaxFiles is the number of rotated logs kept
+	DefaultMaxFiles = 5
+)
+
+// Record is a single change
+type Record struct {
+	Time      time.Time `json:"time"`
+	Action    Action    `json:"action"`
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id,omitempty"`
+	ClientID  string    `json:"clientID,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Resource  string    `json:"resource,omitempty"`
+	// Actor is who made the change
+	Actor string `json:"actor"`
+	// Reason is why the change was made
+	Reason string `json:"reason,omitempty"`
+}
+
+// Log appends records to a backend key and rotates it once it grows past its maximum size.
+// The rotated logs are kept as <key>.1 (newest) to <key>.<maxFiles> (oldest).
+// A nil *Log discards every record.
+type Log struct {
+	mu       sync.Mutex
+	backend  backend.Backend
+	key      string
+	maxSize  int
+	maxFiles int
+	size     int
+	clock    clock.Clock
+	logger   log.FieldLogger
+}
+
+// Option configures a Log created by New
+type Option func(*Log)
+
+// WithKey sets the backend key of the current log, defaults to DefaultKey
+func WithKey(key string) Option {
+	return func(l *Log) {
+		l.key = key
+	}
+}
+
+// WithMaxSize sets the size in bytes above which the log is rotated, defaults to DefaultMaxSize
+func WithMaxSize(size int) Option {
+	return func(l *Log) {
+		l.maxSize = size
+	}
+}
+
+// WithMaxFiles sets the number of rotated logs kept, defaults to DefaultMaxFiles
+func WithMaxFiles(n int) Option {
+	return func(l *Log) {
+		l.maxFiles = n
+	}
+}
+
+// WithClock sets the clock used to time records, defaults to the real clock
+func WithClock(c clock.Clock) Option {
+	return func(l *Log) {
+		l.clock = c
+	}
+}
+
+// WithLogger sets the logger used to report write failures, defaults to the logrus standard logger
+func WithLogger(logger log.FieldLogger) Option {
+	return func(l *Log) {
+		l.logger = logger
+	}
+}
+
+// New returns a log appending to b
+func New(b backend.Backend, opts ...Option) *Log {
+	l := &Log{
+		backend:  b,
+		key:      DefaultKey,
+		maxSize:  DefaultMaxSize,
+		maxFiles: DefaultMaxFiles,
+		clock:    clock.RealClock{},
+		logger:   log.StandardLogger(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if data, err := b.Get(l.key); err == nil {
+		l.size = len(data)
+	}
+	return l
+}
+
+// Record appends r to the log, the time is set if missing. Failures are logged, an audit
+// write never fails the change being recorded.
+func (l *Log) Record(r Record) {
+	if l == nil {
+		return
+	}
+	if err := l.record(r); err != nil {
+		l.logger.Errorf("failed to write audit record %s %s %s: %v", r.Action, r.Kind, r.ID, err)
+	}
+}
+
+func (l *Log) record(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r.Time.IsZero() {
+		r.Time = l.clock.Now().UTC()
+	}
+	b, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if l.size > 0 && l.size+len(b) > l.maxSize {
+		if err = l.rotate(); err != nil {
+			return err
+		}
+	}
+	if err = backend.Append(l.backend, l.key, b); err != nil {
+		return err
+	}
+	l.size += len(b)
+	return nil
+}
+
+// rotate shifts the rotated logs by one, dropping the oldest, and moves the current log to
+// <key>.1. Callers must hold l.mu.
+func (l *Log) rotate() error {
+	err := backend.Batch(l.backend, func(tx backend.Tx) error {
+		if l.maxFiles <= 0 {
+			return tx.Delete(l.key)
+		}
+		if err := tx.Delete(l.rotated(l.maxFiles)); err != nil {
+			return err
+		}
+		for i := l.maxFiles - 1; i >= 0; i-- {
+			from := l.rotated(i)
+			data, err := tx.Get(from)
+			if errors.Is(err, backend.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err = tx.Put(l.rotated(i+1), data); err != nil {
+				return err
+			}
+			if err = tx.Delete(from); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", l.key, err)
+	}
+	l.size = 0
+	return nil
+}
+
+// rotated returns the key of the i-th rotated log, 0 is the current log
+func (l *Log) rotated(i int) string {
+	if i == 0 {
+		return l.key
+	}
+	return fmt.Sprintf("%s.%d", l.key, i)
+}
+
+// Query selects records, unset fields match everything
+type Query struct {
+	// Since matches records made at or after the time
+	Since time.Time
+	// Until matches records made before the time
+	Until time.Time
+	// Actions matches records with one of the actions
+	Actions []Action
+	// Kind matches records of the kind
+	Kind Kind
+	// ID matches records of a publisher or subscription
+	ID string
+	// ClientID matches records of a subscriber client and its subscriptions
+	ClientID string
+	// Namespace matches records of the namespace
+	Namespace string
+	// Limit returns only the newest records, all records if not set
+	Limit int
+}
+
+// Match reports whether r is selected by the query
+func (q *Query) Match(r *Record) bool {
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Time.Before(q.Until) {
+		return false
+	}
+	if len(q.Actions) > 0 {
+		found := false
+		for _, a := range q.Actions {
+			found = found || a == r.Action
+		}
+		if !found {
+			return false
+		}
+	}
+	return (q.Kind == "" || q.Kind == r.Kind) &&
+		(q.ID == "" || q.ID == r.ID) &&
+		(q.ClientID == "" || q.ClientID == r.ClientID) &&
+		(q.Namespace == "" || q.Namespace == r.Namespace)
+}
+
+// Query returns the records selected by q, oldest first, including those of the rotated logs
+func (l *Log) Query(q Query) ([]Record, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var records []Record
+	for i := l.maxFiles; i >= 0; i-- {
+		data, err := l.backend.Get(l.rotated(i))
+		if errors.Is(err, backend.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			var r Record
+			// empty lines and a torn last line are skipped
+			if len(line) == 0 || json.Unmarshal(line, &r) != nil {
+				continue
+			}
+			if q.Match(&r) {
+				records = append(records, r)
+			}
+		}
+	}
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[len(records)-q.Limit:]
+	}
+	return records, nil
+}
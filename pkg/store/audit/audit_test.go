@@ -0,0 +1,89 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Query(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(start)
+	l := audit.New(backend.NewMemoryBackend(), audit.WithClock(fakeClock))
+	l.Record(audit.Record{Action: audit.ActionCreate, Kind: audit.KindClient, ID: "c1", ClientID: "c1", Actor: audit.ActorAPI})
+	fakeClock.Step(time.Minute)
+	l.Record(audit.Record{Action: audit.ActionCreate, Kind: audit.KindSubscription, ID: "s1", ClientID: "c1", Namespace: "tenant", Actor: audit.ActorAPI})
+	fakeClock.Step(time.Minute)
+	l.Record(audit.Record{Action: audit.ActionAutoDelete, Kind: audit.KindClient, ID: "c1", ClientID: "c1", Actor: audit.ActorSystem,
+		Reason: "delivery failed 5 times"})
+
+	records, err := l.Query(audit.Query{})
+	assert.Nil(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, start, records[0].Time)
+
+	records, _ = l.Query(audit.Query{ClientID: "c1", Kind: audit.KindClient})
+	assert.Len(t, records, 2)
+	records, _ = l.Query(audit.Query{Actions: []audit.Action{audit.ActionAutoDelete}})
+	assert.Len(t, records, 1)
+	assert.Equal(t, "delivery failed 5 times", records[0].Reason)
+	records, _ = l.Query(audit.Query{Namespace: "tenant"})
+	assert.Len(t, records, 1)
+	records, _ = l.Query(audit.Query{Since: start.Add(time.Minute), Until: start.Add(2 * time.Minute)})
+	assert.Len(t, records, 1)
+	assert.Equal(t, "s1", records[0].ID)
+	records, _ = l.Query(audit.Query{Limit: 2})
+	assert.Len(t, records, 2)
+	assert.Equal(t, audit.ActionAutoDelete, records[1].Action)
+}
+
+func TestLog_Rotate(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	l := audit.New(b, audit.WithMaxSize(200), audit.WithMaxFiles(2))
+	for i := 0; i < 20; i++ {
+		l.Record(audit.Record{Action: audit.ActionUpdate, Kind: audit.KindPublisher, ID: "p1", Actor: audit.ActorAPI})
+	}
+	keys, err := b.List()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{audit.DefaultKey, audit.DefaultKey + ".1", audit.DefaultKey + ".2"}, keys)
+	data, _ := b.Get(audit.DefaultKey)
+	assert.LessOrEqual(t, len(data), 200)
+
+	// the oldest records are dropped
+	records, err := l.Query(audit.Query{})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, records)
+	assert.Less(t, len(records), 20)
+
+	// the size of the current log survives a restart
+	reopened := audit.New(b, audit.WithMaxSize(200), audit.WithMaxFiles(2))
+	reopened.Record(audit.Record{Action: audit.ActionDelete, Kind: audit.KindPublisher, ID: "p1", Actor: audit.ActorAPI})
+	records, _ = reopened.Query(audit.Query{Limit: 1})
+	assert.Equal(t, audit.ActionDelete, records[0].Action)
+}
+
+func TestLog_Nil(t *testing.T) {
+	var l *audit.Log
+	l.Record(audit.Record{Action: audit.ActionCreate})
+	records, err := l.Query(audit.Query{})
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+}
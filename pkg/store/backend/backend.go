@@ -0,0 +1,183 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by Get when the key does not exist in the backend.
+var ErrNotFound = errors.New("key not found")
+
+// Backend is a key/value persistence layer used by the v1 pubsub and subscriber APIs.
+// Keys are flat names such as "sub.json", "pub.json" or "<clientID>.json", values are
+// the serialized content stored under that name.
+type Backend interface {
+	// Get returns the value stored for key or ErrNotFound.
+	Get(key string) ([]byte, error)
+	// Put stores value under key, replacing any previous value.
+	Put(key string, value []byte) error
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(key string) error
+	// List returns all keys currently stored in the backend.
+	List() ([]string, error)
+	// Watch streams changes made to the backend until ctx is cancelled.
+	Watch(ctx context.Context) (<-chan WatchEvent, error)
+}
+
+// Op is the kind of change reported by Watch.
+type Op int
+
+const (
+	// OpPut a key was created or updated
+	OpPut Op = iota
+	// OpDelete a key was removed
+	OpDelete
+)
+
+// String represent of Op enum
+func (o Op) String() string {
+	return [...]string{"PUT", "DELETE"}[o]
+}
+
+// WatchEvent describes a single change to a key in the backend.
+type WatchEvent struct {
+	Op  Op
+	Key string
+}
+
+// Appender is implemented by backends that can append to a key without rewriting it.
+type Appender interface {
+	// Append adds record at the end of the value stored for key, creating it if needed.
+	Append(key string, record []byte) error
+}
+
+// Append adds record to key using Appender when b supports it, otherwise by rewriting the value.
+func Append(b Backend, key string, record []byte) error {
+	if a, ok := b.(Appender); ok {
+		return a.Append(key, record)
+	}
+	current, err := b.Get(key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return b.Put(key, append(current, record...))
+}
+
+// Tx is the set of changes applied by a batch
+type Tx interface {
+	// Get returns the value stored for key, including changes made earlier in the batch, or ErrNotFound.
+	Get(key string) ([]byte, error)
+	// Put stores value under key, replacing any previous value.
+	Put(key string, value []byte) error
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// Batcher is implemented by backends that can apply several changes in a single transaction.
+type Batcher interface {
+	// Batch runs fn in a read-write transaction, the changes are discarded if fn returns an error.
+	Batch(fn func(tx Tx) error) error
+}
+
+// Batch runs fn in a single transaction when b implements Batcher, otherwise the changes are
+// applied to b one by one and the ones made before an error are kept.
+func Batch(b Backend, fn func(tx Tx) error) error {
+	if bt, ok := b.(Batcher); ok {
+		return bt.Batch(fn)
+	}
+	return fn(b)
+}
+
+// BackupReader is implemented by backends that keep the previous copy of a key
+// around so that a corrupted value can be recovered.
+type BackupReader interface {
+	// GetBackup returns the last good value stored for key or ErrNotFound.
+	GetBackup(key string) ([]byte, error)
+}
+
+// BackupRestorer is implemented by backends that can put the backup copy of a key back
+// in place without replacing the backup, so a second good copy survives the recovery.
+type BackupRestorer interface {
+	// RestoreBackup replaces the value of key with its backup copy, the backup is kept.
+	RestoreBackup(key string) error
+}
+
+// BackupWriter is implemented by backends that keep a backup copy of a key and can replace it,
+// for example to re-encrypt it.
+type BackupWriter interface {
+	// PutBackup replaces the backup copy of key with value.
+	PutBackup(key string, value []byte) error
+}
+
+// CorruptionError reports a key whose content could not be decoded.
+// Recovered is set when the last good copy was loaded instead.
+type CorruptionError struct {
+	Key       string
+	Recovered bool
+	Err       error
+}
+
+// Error corrupted key error string
+func (c *CorruptionError) Error() string {
+	if c.Recovered {
+		return fmt.Sprintf("%s is corrupted, recovered from backup: %v", c.Key, c.Err)
+	}
+	return fmt.Sprintf("%s is corrupted and could not be recovered: %v", c.Key, c.Err)
+}
+
+// Unwrap returns the decoding error
+func (c *CorruptionError) Unwrap() error {
+	return c.Err
+}
+
+// LoadWithFallback reads key and passes its content to decode. Missing or empty keys are
+// not decoded. If decode fails and the backend implements BackupReader, the backup copy is
+// decoded instead and written back under key, with BackupRestorer when the backend implements
+// it; a *CorruptionError is returned in both cases.
+func LoadWithFallback(b Backend, key string, decode func([]byte) error) error {
+	data, err := b.Get(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	decodeErr := decode(data)
+	if decodeErr == nil {
+		return nil
+	}
+	br, ok := b.(BackupReader)
+	if !ok {
+		return &CorruptionError{Key: key, Err: decodeErr}
+	}
+	backup, err := br.GetBackup(key)
+	if err != nil || len(backup) == 0 || decode(backup) != nil {
+		return &CorruptionError{Key: key, Err: decodeErr}
+	}
+	if restorer, ok := b.(BackupRestorer); ok {
+		err = restorer.RestoreBackup(key)
+	} else {
+		err = b.Put(key, backup)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore %s from backup: %w", key, err)
+	}
+	return &CorruptionError{Key: key, Recovered: true, Err: decodeErr}
+}
@@ -0,0 +1,282 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+func backends(t *testing.T) map[string]backend.Backend {
+	return map[string]backend.Backend{
+		"file":   backend.NewFileBackend(t.TempDir()),
+		"memory": backend.NewMemoryBackend(),
+		"bolt":   newBoltBackend(t, filepath.Join(t.TempDir(), "store.db")),
+	}
+}
+
+func newBoltBackend(t *testing.T, path string) *backend.BoltBackend {
+	b, err := backend.NewBoltBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func TestBackend_PutGetDelete(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := b.Get("sub.json")
+			assert.ErrorIs(t, err, backend.ErrNotFound)
+
+			assert.Nil(t, b.Put("sub.json", []byte("[]")))
+			assert.Nil(t, b.Put("pub.json", []byte("[1]")))
+			v, err := b.Get("sub.json")
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("[]"), v)
+
+			keys, err := b.List()
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, []string{"sub.json", "pub.json"}, keys)
+
+			assert.Nil(t, b.Delete("sub.json"))
+			assert.Nil(t, b.Delete("sub.json"))
+			_, err = b.Get("sub.json")
+			assert.ErrorIs(t, err, backend.ErrNotFound)
+		})
+	}
+}
+
+func TestBackend_Watch(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			ch, err := b.Watch(ctx)
+			assert.Nil(t, err)
+
+			assert.Nil(t, b.Put("pub.json", []byte("[]")))
+			assert.Nil(t, b.Delete("pub.json"))
+			for _, want := range []backend.WatchEvent{{Op: backend.OpPut, Key: "pub.json"}, {Op: backend.OpDelete, Key: "pub.json"}} {
+				select {
+				case got := <-ch:
+					assert.Equal(t, want, got)
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for %v", want)
+				}
+			}
+			cancel()
+			for range ch { //nolint:revive
+			}
+		})
+	}
+}
+
+func TestFileBackend_PutKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	b := backend.NewFileBackend(dir)
+	assert.Nil(t, b.Put("sub.json", []byte("first")))
+	_, err := b.GetBackup("sub.json")
+	assert.ErrorIs(t, err, backend.ErrNotFound)
+
+	assert.Nil(t, b.Put("sub.json", []byte("second")))
+	v, err := b.GetBackup("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("first"), v)
+
+	keys, err := b.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sub.json"}, keys)
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	assert.Nil(t, b.Delete("sub.json"))
+	_, err = b.GetBackup("sub.json")
+	assert.ErrorIs(t, err, backend.ErrNotFound)
+}
+
+func TestFileBackend_BackupCheck(t *testing.T) {
+	dir := t.TempDir()
+	b := backend.NewFileBackend(dir, backend.WithBackupCheck(backend.ValidJSON))
+	assert.Nil(t, b.Put("sub.json", []byte(`["first"]`)))
+	assert.Nil(t, b.Put("sub.json", []byte(`["second"]`)))
+
+	// a corrupted file does not replace the last good copy
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "sub.json"), []byte(`["sec`), backend.DefaultFileMode))
+	assert.Nil(t, b.Put("sub.json", []byte(`["third"]`)))
+	v, err := b.GetBackup("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`["first"]`), v)
+	assert.Nil(t, b.Put("sub.json", []byte(`["fourth"]`)))
+	v, err = b.GetBackup("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`["third"]`), v)
+
+	// keys other than .json are not checked
+	assert.Nil(t, b.Put("journal.log", []byte("one")))
+	assert.Nil(t, b.Put("journal.log", []byte("two")))
+	v, err = b.GetBackup("journal.log")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("one"), v)
+}
+
+func TestFileBackend_FileMode(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "sub.json")
+	assert.Nil(t, os.WriteFile(legacy, []byte("{}"), 0644))
+	assert.Nil(t, os.Chmod(legacy, 0644))
+	b := backend.NewFileBackend(dir)
+	info, err := os.Stat(legacy)
+	assert.Nil(t, err)
+	assert.Equal(t, backend.DefaultFileMode, info.Mode().Perm())
+
+	assert.Nil(t, b.Append("journal.log", []byte("{}\n")))
+	info, err = os.Stat(filepath.Join(dir, "journal.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, backend.DefaultFileMode, info.Mode().Perm())
+
+	b = backend.NewFileBackend(dir, backend.WithFileMode(0640))
+	assert.Nil(t, b.Put("pub.json", []byte("{}")))
+	info, err = os.Stat(filepath.Join(dir, "pub.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestBoltBackend_Batch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	b := newBoltBackend(t, path)
+	clientOne := "123e4567-e89b-12d3-a456-426614174000.json"
+	clientTwo := "123e4567-e89b-12d3-a456-426614174001.json"
+	assert.Nil(t, b.Put(clientOne, []byte("1")))
+	assert.Nil(t, b.Put(clientTwo, []byte("2")))
+	assert.Nil(t, backend.Append(b, "journal.log", []byte("a\n")))
+	assert.Nil(t, backend.Append(b, "journal.log", []byte("b\n")))
+
+	// a failed batch leaves the database unchanged
+	err := backend.Batch(b, func(tx backend.Tx) error {
+		assert.Nil(t, tx.Delete(clientOne))
+		_, e := tx.Get(clientOne)
+		assert.ErrorIs(t, e, backend.ErrNotFound)
+		return errors.New("abort")
+	})
+	assert.NotNil(t, err)
+	v, err := b.Get(clientOne)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), v)
+
+	assert.Nil(t, backend.Batch(b, func(tx backend.Tx) error {
+		if e := tx.Delete(clientOne); e != nil {
+			return e
+		}
+		return tx.Delete(clientTwo)
+	}))
+	assert.Nil(t, b.Close())
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	reopened := newBoltBackend(t, path)
+	keys, err := reopened.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"journal.log"}, keys)
+	v, err = reopened.Get("journal.log")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("a\nb\n"), v)
+}
+
+func TestLoadWithFallback(t *testing.T) {
+	dir := t.TempDir()
+	b := backend.NewFileBackend(dir)
+	decode := func(out *[]string) func([]byte) error {
+		return func(data []byte) error {
+			return json.Unmarshal(data, out)
+		}
+	}
+
+	var got []string
+	assert.Nil(t, backend.LoadWithFallback(b, "sub.json", decode(&got)))
+	assert.Nil(t, got)
+
+	assert.Nil(t, b.Put("sub.json", []byte(`["a"]`)))
+	assert.Nil(t, b.Put("sub.json", []byte(`["a","b"]`)))
+	assert.Nil(t, backend.LoadWithFallback(b, "sub.json", decode(&got)))
+	assert.Equal(t, []string{"a", "b"}, got)
+
+	// simulate a torn write of the main file
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "sub.json"), []byte(`["a","`), 0600))
+	got = nil
+	err := backend.LoadWithFallback(b, "sub.json", decode(&got))
+	var corrupted *backend.CorruptionError
+	assert.True(t, errors.As(err, &corrupted))
+	assert.True(t, corrupted.Recovered)
+	assert.Equal(t, []string{"a"}, got)
+	restored, err := b.Get("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`["a"]`), restored)
+	// the backup is still the good copy
+	backup, err := b.GetBackup("sub.json")
+	assert.Nil(t, err)
+	got = nil
+	assert.Nil(t, decode(&got)(backup))
+	assert.Equal(t, []string{"a"}, got)
+
+	// no usable backup
+	m := backend.NewMemoryBackend()
+	assert.Nil(t, m.Put("sub.json", []byte("{")))
+	err = backend.LoadWithFallback(m, "sub.json", decode(&got))
+	assert.True(t, errors.As(err, &corrupted))
+	assert.False(t, corrupted.Recovered)
+}
+
+func TestFileBackend_WatchExternal(t *testing.T) {
+	dir := t.TempDir()
+	b := backend.NewFileBackend(dir, backend.WithPollInterval(10*time.Millisecond))
+	assert.Nil(t, b.Put("sub.json", []byte("[]")))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := backend.WatchExternal(ctx, b)
+	assert.Nil(t, err)
+
+	// own writes are not reported, external changes may be reported more than once
+	assert.Nil(t, b.Put("pub.json", []byte("[]")))
+	assert.Nil(t, b.Put("sub.json", []byte("[1]")))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "sub.json"), []byte("[1,2]"), 0600))
+	assert.Nil(t, os.Remove(filepath.Join(dir, "pub.json")))
+	seen := map[backend.WatchEvent]bool{}
+	for !seen[backend.WatchEvent{Op: backend.OpPut, Key: "sub.json"}] || !seen[backend.WatchEvent{Op: backend.OpDelete, Key: "pub.json"}] {
+		select {
+		case e := <-ch:
+			seen[e] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for external changes, got %v", seen)
+		}
+	}
+	assert.Len(t, seen, 2)
+
+	cancel()
+	for range ch { //nolint:revive
+	}
+
+	_, err = backend.WatchExternal(context.Background(), backend.NewMemoryBackend())
+	assert.ErrorIs(t, err, backend.ErrExternalWatchUnsupported)
+}
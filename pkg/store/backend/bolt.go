@@ -0,0 +1,215 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+var _ Backend = (*BoltBackend)(nil)
+var _ Appender = (*BoltBackend)(nil)
+var _ Batcher = (*BoltBackend)(nil)
+
+// Buckets of the bolt backend
+const (
+	// PublishersBucket holds the publisher list (pub.json)
+	PublishersBucket = "publishers"
+	// SubscriptionsBucket holds the subscription list (sub.json)
+	SubscriptionsBucket = "subscriptions"
+	// ClientsBucket holds one record per subscriber client (<clientID>.json)
+	ClientsBucket = "clients"
+	// MetaBucket holds every other key, such as the subscriber journal
+	MetaBucket = "meta"
+)
+
+var buckets = []string{PublishersBucket, SubscriptionsBucket, ClientsBucket, MetaBucket}
+
+const (
+	// boltFileMode the database is only readable by the owner
+	boltFileMode = 0600
+	// boltOpenTimeout is how long Open waits for the file lock held by another process
+	boltOpenTimeout = 5 * time.Second
+)
+
+// BoltBackend stores all keys in a single bbolt database file. Subscriber clients are
+// kept one record per client in their own bucket, so loading thousands of clients reads
+// a single file, and Batch applies multi-record updates in one transaction.
+type BoltBackend struct {
+	db       *bolt.DB
+	watchers watchers
+}
+
+// NewBoltBackend opens or creates the database at path, creating its directory if needed
+func NewBoltBackend(path string) (*BoltBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, boltFileMode, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, e := tx.CreateBucketIfNotExists([]byte(name)); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltBackend{db: db}, nil
+}
+
+// Close releases the database file
+func (b *BoltBackend) Close() error {
+	return b.db.Close()
+}
+
+// Get reads the record for key
+func (b *BoltBackend) Get(key string) (value []byte, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		value, err = (&boltTx{tx: tx}).Get(key)
+		return err
+	})
+	return
+}
+
+// Put writes the record for key
+func (b *BoltBackend) Put(key string, value []byte) error {
+	return b.Batch(func(tx Tx) error {
+		return tx.Put(key, value)
+	})
+}
+
+// Append adds record at the end of the record for key in a single transaction
+func (b *BoltBackend) Append(key string, record []byte) error {
+	return b.Batch(func(tx Tx) error {
+		current, err := tx.Get(key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return tx.Put(key, append(current, record...))
+	})
+}
+
+// Delete removes the record for key
+func (b *BoltBackend) Delete(key string) error {
+	return b.Batch(func(tx Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+// List returns the keys of every bucket
+func (b *BoltBackend) List() (keys []string, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if e := tx.Bucket([]byte(name)).ForEach(func(k, _ []byte) error {
+				keys = append(keys, string(k))
+				return nil
+			}); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Batch runs fn in a single read-write transaction. Watchers are notified once the
+// transaction is committed.
+func (b *BoltBackend) Batch(fn func(tx Tx) error) error {
+	t := &boltTx{}
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		t.tx = tx
+		return fn(t)
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range t.events {
+		b.watchers.notify(e.Op, e.Key)
+	}
+	return nil
+}
+
+// Watch reports the changes made through this backend until ctx is cancelled
+func (b *BoltBackend) Watch(ctx context.Context) (<-chan WatchEvent, error) {
+	return b.watchers.add(ctx), nil
+}
+
+// String returns the path of the database file
+func (b *BoltBackend) String() string {
+	return b.db.Path()
+}
+
+// boltTx implements Tx on top of a bbolt transaction
+type boltTx struct {
+	tx     *bolt.Tx
+	events []WatchEvent
+}
+
+func (t *boltTx) Get(key string) ([]byte, error) {
+	v := t.tx.Bucket([]byte(bucketFor(key))).Get([]byte(key))
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	// values returned by bbolt are only valid for the life of the transaction
+	return append([]byte{}, v...), nil
+}
+
+func (t *boltTx) Put(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	if err := t.tx.Bucket([]byte(bucketFor(key))).Put([]byte(key), append([]byte{}, value...)); err != nil {
+		return err
+	}
+	t.events = append(t.events, WatchEvent{Op: OpPut, Key: key})
+	return nil
+}
+
+func (t *boltTx) Delete(key string) error {
+	if err := t.tx.Bucket([]byte(bucketFor(key))).Delete([]byte(key)); err != nil {
+		return err
+	}
+	t.events = append(t.events, WatchEvent{Op: OpDelete, Key: key})
+	return nil
+}
+
+// bucketFor returns the bucket holding key
+func bucketFor(key string) string {
+	switch key {
+	case "pub.json":
+		return PublishersBucket
+	case "sub.json":
+		return SubscriptionsBucket
+	}
+	if name, ok := strings.CutSuffix(key, ".json"); ok && uuid.Validate(name) == nil {
+		return ClientsBucket
+	}
+	return MetaBucket
+}
@@ -0,0 +1,218 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/util/wait"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ ExternalWatcher = (*FileBackend)(nil)
+
+// DefaultPollInterval is how often a FileBackend looks for external changes when inotify is
+// not available
+const DefaultPollInterval = 2 * time.Second
+
+// ErrExternalWatchUnsupported is returned by WatchExternal for backends that can't detect
+// changes made by other processes
+var ErrExternalWatchUnsupported = errors.New("backend does not report external changes")
+
+// ExternalWatcher is implemented by backends that can report changes made by other processes,
+// for example an operator editing or restoring a store file.
+type ExternalWatcher interface {
+	// WatchExternal streams the keys changed outside of the backend until ctx is cancelled.
+	// Changes made through the backend itself are not reported.
+	WatchExternal(ctx context.Context) (<-chan WatchEvent, error)
+}
+
+// WatchExternal streams the keys of b changed by other processes, b must implement ExternalWatcher
+func WatchExternal(ctx context.Context, b Backend) (<-chan WatchEvent, error) {
+	if w, ok := b.(ExternalWatcher); ok {
+		return w.WatchExternal(ctx)
+	}
+	return nil, ErrExternalWatchUnsupported
+}
+
+// OnExternalChange calls fn each time another process changes a key of b selected by match,
+// until stopCh is closed. Changes received together are handled by a single call.
+func OnExternalChange(b Backend, match func(key string) bool, fn func(), stopCh <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := WatchExternal(ctx, b)
+	if err != nil {
+		cancel()
+		return err
+	}
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				changed := match(e.Key)
+				// an editor saving a file or a restore of several files produce a burst of events
+				for pending := true; pending; {
+					select {
+					case e, ok = <-events:
+						if !ok {
+							return
+						}
+						changed = changed || match(e.Key)
+					default:
+						pending = false
+					}
+				}
+				if changed {
+					fn()
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// fileStamp identifies the content of a file without reading it
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// WatchExternal streams the files of the backend directory created, changed or removed by other
+// processes. It relies on inotify where available and otherwise lists the directory every poll
+// interval, see WithPollInterval.
+func (f *FileBackend) WatchExternal(ctx context.Context) (<-chan WatchEvent, error) {
+	f.mu.Lock()
+	if f.known == nil {
+		f.known = map[string]fileStamp{}
+		f.scan(nil)
+	}
+	f.mu.Unlock()
+
+	ch := make(chan WatchEvent, watchBufferSize)
+	changed, err := watchDir(ctx, f.dir)
+	if err != nil {
+		log.Infof("watching %s every %s: %v", f.dir, f.pollInterval, err)
+		go func() {
+			defer close(ch)
+			wait.Until(func() { f.check(ctx, ch, "") }, f.pollInterval, ctx.Done())
+		}()
+		return ch, nil
+	}
+	go func() {
+		defer close(ch)
+		for name := range changed {
+			f.check(ctx, ch, name)
+		}
+	}()
+	return ch, nil
+}
+
+// check reports the external changes of the file name, or of every file when name is empty
+func (f *FileBackend) check(ctx context.Context, ch chan<- WatchEvent, name string) {
+	if name != "" && !isStoreFile(name) {
+		return
+	}
+	var events []WatchEvent
+	f.mu.Lock()
+	if name == "" {
+		f.scan(&events)
+	} else {
+		f.checkFile(name, &events)
+	}
+	f.mu.Unlock()
+	for _, e := range events {
+		select {
+		case ch <- e:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scan compares every file of the directory with its last known stamp. Callers must hold f.mu.
+func (f *FileBackend) scan(events *[]WatchEvent) {
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, file := range files {
+		if file.IsDir() || !isStoreFile(file.Name()) {
+			continue
+		}
+		seen[file.Name()] = true
+		f.checkFile(file.Name(), events)
+	}
+	for key := range f.known {
+		if !seen[key] {
+			f.checkFile(key, events)
+		}
+	}
+}
+
+// checkFile compares a file with its last known stamp, events is nil while the stamps are
+// initialized. Callers must hold f.mu.
+func (f *FileBackend) checkFile(key string, events *[]WatchEvent) {
+	stamp, ok := f.stat(key)
+	known, wasKnown := f.known[key]
+	switch {
+	case !ok && wasKnown:
+		delete(f.known, key)
+		if events != nil {
+			*events = append(*events, WatchEvent{Op: OpDelete, Key: key})
+		}
+	case ok && (!wasKnown || stamp != known):
+		f.known[key] = stamp
+		if events != nil {
+			*events = append(*events, WatchEvent{Op: OpPut, Key: key})
+		}
+	}
+}
+
+// recordWrite remembers the stamp of a file written by the backend so the change is not
+// reported as external. Callers must hold f.mu.
+func (f *FileBackend) recordWrite(key string) {
+	if f.known == nil {
+		return
+	}
+	if stamp, ok := f.stat(key); ok {
+		f.known[key] = stamp
+	} else {
+		delete(f.known, key)
+	}
+}
+
+func (f *FileBackend) stat(key string) (fileStamp, bool) {
+	info, err := os.Stat(f.path(key))
+	if err != nil || !info.Mode().IsRegular() {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// isStoreFile excludes the temporary files and backups written by the backend
+func isStoreFile(name string) bool {
+	return !strings.HasPrefix(name, tmpPrefix) && !strings.HasSuffix(name, BackupSuffix) && name == filepath.Base(name)
+}
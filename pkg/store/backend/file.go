@@ -0,0 +1,324 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var _ Backend = (*FileBackend)(nil)
+var _ BackupReader = (*FileBackend)(nil)
+var _ BackupRestorer = (*FileBackend)(nil)
+var _ BackupWriter = (*FileBackend)(nil)
+var _ Appender = (*FileBackend)(nil)
+
+const (
+	// BackupSuffix is appended to a key to name the last good copy of its file
+	BackupSuffix = ".bak"
+	// tmpPrefix marks files being written, they are never listed
+	tmpPrefix = ".tmp-"
+	// DefaultFileMode is the mode of the files written by a FileBackend, store files may hold
+	// endpoint URLs with credentials and are only readable by their owner
+	DefaultFileMode os.FileMode = 0600
+)
+
+// FileBackend stores every key as a file in a single directory.
+// This is the layout historically used by the v1 APIs (sub.json, pub.json, <clientID>.json).
+// Writes go to a temporary file which is synced and renamed over the target, so a crash
+// never leaves a truncated file behind; the replaced content is kept as <key>.bak unless it
+// fails the backup check.
+type FileBackend struct {
+	mu       sync.RWMutex
+	dir      string
+	mode     os.FileMode
+	watchers watchers
+	// known are the stamps of the files, set once external changes are watched
+	known        map[string]fileStamp
+	pollInterval time.Duration
+	checkBackup  BackupCheck
+}
+
+// FileOption configures a FileBackend created by NewFileBackend
+type FileOption func(*FileBackend)
+
+// BackupCheck returns an error if data, the content of key about to be replaced, must not
+// replace the backup of key
+type BackupCheck func(key string, data []byte) error
+
+// WithBackupCheck makes Put keep the existing backup when the content it replaces fails check,
+// so that a corrupted file never overwrites the last good copy. Without a check the replaced
+// content always becomes the backup.
+func WithBackupCheck(check BackupCheck) FileOption {
+	return func(f *FileBackend) {
+		f.checkBackup = check
+	}
+}
+
+// ValidJSON is a BackupCheck rejecting the values of .json keys that are not valid JSON, the
+// values of other keys are accepted
+func ValidJSON(key string, data []byte) error {
+	if strings.HasSuffix(key, ".json") && !json.Valid(data) {
+		return fmt.Errorf("%s is not valid JSON", key)
+	}
+	return nil
+}
+
+// WithFileMode sets the mode of the files written by the backend, defaults to DefaultFileMode
+func WithFileMode(mode os.FileMode) FileOption {
+	return func(f *FileBackend) {
+		f.mode = mode
+	}
+}
+
+// WithPollInterval sets how often WatchExternal lists the directory when inotify is not
+// available, defaults to DefaultPollInterval
+func WithPollInterval(d time.Duration) FileOption {
+	return func(f *FileBackend) {
+		f.pollInterval = d
+	}
+}
+
+// NewFileBackend creates a file backend rooted at dir, creating the directory if needed.
+// Existing files with more permissions than the backend mode are restricted to it.
+func NewFileBackend(dir string, opts ...FileOption) *FileBackend {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		_ = os.Mkdir(dir, 0700)
+	}
+	f := &FileBackend{dir: dir, mode: DefaultFileMode, pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.restrictFiles()
+	return f
+}
+
+// Dir returns the directory the backend stores files in
+func (f *FileBackend) Dir() string {
+	return f.dir
+}
+
+// Get reads the file for key
+func (f *FileBackend) Get(key string) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	b, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+// GetBackup reads the last good copy of the file for key
+func (f *FileBackend) GetBackup(key string) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	b, err := os.ReadFile(f.path(key) + BackupSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+// Put atomically replaces the file for key with value, keeping the previous content as backup
+// if it passes the backup check
+func (f *FileBackend) Put(key string, value []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := f.path(key)
+	if current, err := os.ReadFile(path); err == nil && len(current) > 0 {
+		if f.checkBackup != nil {
+			err = f.checkBackup(key, current)
+		}
+		if err != nil {
+			log.Warnf("keeping the backup of %s, the replaced content is not valid: %v", key, err)
+		} else if err = WriteFileAtomic(path+BackupSuffix, current, f.mode); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", key, err)
+		}
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := WriteFileAtomic(path, value, f.mode); err != nil {
+		return err
+	}
+	f.recordWrite(key)
+	f.watchers.notify(OpPut, key)
+	return nil
+}
+
+// RestoreBackup atomically replaces the file for key with its backup, the backup is kept
+// so that a crash during the recovery still leaves a good copy
+func (f *FileBackend) RestoreBackup(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := f.path(key)
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if err = WriteFileAtomic(path, backup, f.mode); err != nil {
+		return err
+	}
+	f.recordWrite(key)
+	f.watchers.notify(OpPut, key)
+	return nil
+}
+
+// PutBackup atomically replaces the backup of the file for key with value
+func (f *FileBackend) PutBackup(key string, value []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return WriteFileAtomic(f.path(key)+BackupSuffix, value, f.mode)
+}
+
+// Append writes record at the end of the file for key and syncs it to disk
+func (f *FileBackend) Append(key string, record []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path(key), os.O_APPEND|os.O_CREATE|os.O_WRONLY, f.mode)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(record); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	f.recordWrite(key)
+	f.watchers.notify(OpPut, key)
+	return nil
+}
+
+// Delete removes the file for key and its backup
+func (f *FileBackend) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := f.path(key)
+	for _, p := range []string{path, path + BackupSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	syncDir(f.dir)
+	f.recordWrite(key)
+	f.watchers.notify(OpDelete, key)
+	return nil
+}
+
+// List returns the names of all regular files in the backend directory
+func (f *FileBackend) List() (keys []string, err error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, tmpPrefix) || strings.HasSuffix(name, BackupSuffix) {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys, nil
+}
+
+// Watch streams changes made through this backend
+func (f *FileBackend) Watch(ctx context.Context) (<-chan WatchEvent, error) {
+	return f.watchers.add(ctx), nil
+}
+
+// String returns the location of the backend
+func (f *FileBackend) String() string {
+	return fmt.Sprintf("file://%s", f.dir)
+}
+
+// restrictFiles removes the permissions not granted by the backend mode from the existing
+// files, older versions wrote files readable by everyone
+func (f *FileBackend) restrictFiles() {
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&^f.mode == 0 {
+			continue
+		}
+		_ = os.Chmod(filepath.Join(f.dir, file.Name()), info.Mode().Perm()&f.mode)
+	}
+}
+
+func (f *FileBackend) path(key string) string {
+	return filepath.Join(f.dir, filepath.Base(key))
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory, syncs it and
+// renames it over path so readers see either the old or the new content, never a mix.
+func WriteFileAtomic(path string, data []byte, mode os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, tmpPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes directory entries so a rename or remove survives a crash
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package backend
+
+import (
+	"context"
+	"errors"
+)
+
+// watchDir is only implemented with inotify, other platforms poll the directory
+func watchDir(_ context.Context, _ string) (<-chan string, error) {
+	return nil, errors.New("inotify is only available on linux")
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+var _ Backend = (*MemoryBackend)(nil)
+var _ Appender = (*MemoryBackend)(nil)
+
+// MemoryBackend keeps all keys in memory. It is intended for tests and
+// for callers that do not need persistence across restarts.
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	data     map[string][]byte
+	watchers watchers
+}
+
+// NewMemoryBackend creates an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{data: map[string][]byte{}}
+}
+
+// Get returns a copy of the value stored for key
+func (m *MemoryBackend) Get(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if b, ok := m.data[key]; ok {
+		return append([]byte(nil), b...), nil
+	}
+	return nil, ErrNotFound
+}
+
+// Put stores a copy of value under key
+func (m *MemoryBackend) Put(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = append([]byte{}, value...)
+	m.watchers.notify(OpPut, key)
+	return nil
+}
+
+// Append adds a copy of record at the end of the value stored for key
+func (m *MemoryBackend) Append(key string, record []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = append(m.data[key], record...)
+	m.watchers.notify(OpPut, key)
+	return nil
+}
+
+// Delete removes key
+func (m *MemoryBackend) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	m.watchers.notify(OpDelete, key)
+	return nil
+}
+
+// List returns all keys in sorted order
+func (m *MemoryBackend) List() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.data))
+	for k := range m.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Watch streams changes made to the backend
+func (m *MemoryBackend) Watch(ctx context.Context) (<-chan WatchEvent, error) {
+	return m.watchers.add(ctx), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var watchBufferSize = 100
+
+// watchers fans out backend changes to all active Watch callers
+type watchers struct {
+	sync.Mutex
+	chans map[chan WatchEvent]struct{}
+}
+
+// add registers a new watcher which is removed and closed once ctx is done
+func (w *watchers) add(ctx context.Context) <-chan WatchEvent {
+	ch := make(chan WatchEvent, watchBufferSize)
+	w.Lock()
+	if w.chans == nil {
+		w.chans = make(map[chan WatchEvent]struct{})
+	}
+	w.chans[ch] = struct{}{}
+	w.Unlock()
+	go func() {
+		<-ctx.Done()
+		w.Lock()
+		delete(w.chans, ch)
+		close(ch)
+		w.Unlock()
+	}()
+	return ch
+}
+
+// notify sends the event to every watcher without blocking the writer
+func (w *watchers) notify(op Op, key string) {
+	w.Lock()
+	defer w.Unlock()
+	for ch := range w.chans {
+		select {
+		case ch <- WatchEvent{Op: op, Key: key}:
+		default:
+			log.Warnf("backend watcher is not keeping up, dropping %s event for %s", op, key)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+// CallOption configures a single call of a store API, such as the v1 pubsub and subscriber APIs
+type CallOption func(*Call)
+
+// Call holds the options of a single call
+type Call struct {
+	// Actor is recorded in the audit log as the author of the changes made by the call
+	Actor string
+}
+
+// AsActor records actor as the author of the changes made by the call instead of the default
+// actor of the API, a REST layer passes the identity of each request
+func AsActor(actor string) CallOption {
+	return func(c *Call) {
+		c.Actor = actor
+	}
+}
+
+// NewCall returns the options of a call whose actor defaults to actor
+func NewCall(actor string, opts ...CallOption) Call {
+	c := Call{Actor: actor}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
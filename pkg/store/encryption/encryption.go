@@ -0,0 +1,541 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ backend.Backend = (*Backend)(nil)
+var _ backend.Appender = (*Backend)(nil)
+var _ backend.Batcher = (*Backend)(nil)
+var _ backend.BackupReader = (*Backend)(nil)
+var _ backend.BackupRestorer = (*Backend)(nil)
+var _ backend.ExternalWatcher = (*Backend)(nil)
+
+// KeySize is the size in bytes of the key encryption keys and of the data keys (AES-256)
+const KeySize = 32
+
+// magic starts every encrypted frame, values without it are read as plaintext
+var magic = []byte{'c', 'n', 'e', 0x01}
+
+// ErrUnknownKey is returned when a value was encrypted with a key the provider does not know
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// ErrPlaintext is returned when a plaintext value is read from a backend requiring encryption
+var ErrPlaintext = errors.New("value is not encrypted")
+
+// KeyProvider supplies the key encryption keys
+type KeyProvider interface {
+	// CurrentKey returns the id and value of the key new values are encrypted with
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given id or an error wrapping ErrUnknownKey
+	Key(id string) ([]byte, error)
+}
+
+// Backend encrypts the values stored in another backend. Values are written as a sequence of
+// frames, one per Put or Append, each encrypted with a new data key and bound to the key it is
+// stored under. Unless RequireEncryption is set, plaintext values written before encryption was
+// enabled are still read, with a warning, and are encrypted by their next Put.
+type Backend struct {
+	backend backend.Backend
+	keys    KeyProvider
+	mu      sync.Mutex
+	// encrypted is the set of keys whose value is known to hold only complete encrypted frames
+	encrypted map[string]bool
+	// requireEncryption rejects plaintext values instead of migrating them
+	requireEncryption bool
+	logger            log.FieldLogger
+}
+
+// Option configures a Backend created by NewBackend
+type Option func(*Backend)
+
+// RequireEncryption makes the backend fail with ErrPlaintext on plaintext values, so that a value
+// replaced without the keys is not trusted. Use it once every value has been encrypted.
+func RequireEncryption() Option {
+	return func(e *Backend) {
+		e.requireEncryption = true
+	}
+}
+
+// WithLogger sets the logger used to report plaintext values, defaults to the logrus standard logger
+func WithLogger(logger log.FieldLogger) Option {
+	return func(e *Backend) {
+		e.logger = logger
+	}
+}
+
+// NewBackend encrypts the values stored in b with the keys of keys
+func NewBackend(b backend.Backend, keys KeyProvider, opts ...Option) *Backend {
+	e := &Backend{backend: b, keys: keys, encrypted: map[string]bool{}, logger: log.StandardLogger()}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Get returns the decrypted value stored for key
+func (e *Backend) Get(key string) ([]byte, error) {
+	data, err := e.backend.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.decrypt(key, data)
+}
+
+// GetBackup returns the decrypted last good value stored for key, ErrNotFound if the wrapped
+// backend keeps no backup
+func (e *Backend) GetBackup(key string) ([]byte, error) {
+	br, ok := e.backend.(backend.BackupReader)
+	if !ok {
+		return nil, backend.ErrNotFound
+	}
+	data, err := br.GetBackup(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.decrypt(key, data)
+}
+
+// RestoreBackup replaces the value stored for key with its backup without decrypting it, the
+// backup is kept
+func (e *Backend) RestoreBackup(key string) error {
+	defer e.forget(key)
+	if restorer, ok := e.backend.(backend.BackupRestorer); ok {
+		return restorer.RestoreBackup(key)
+	}
+	br, ok := e.backend.(backend.BackupReader)
+	if !ok {
+		return backend.ErrNotFound
+	}
+	data, err := br.GetBackup(key)
+	if err != nil {
+		return err
+	}
+	return e.backend.Put(key, data)
+}
+
+// Put encrypts value and stores it under key
+func (e *Backend) Put(key string, value []byte) error {
+	data, err := e.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	if err = e.backend.Put(key, data); err != nil {
+		return err
+	}
+	e.setEncrypted(key)
+	return nil
+}
+
+// Append encrypts record and adds it at the end of the value stored for key. A plaintext value
+// is encrypted first so that the value never mixes plaintext and encrypted frames, and a torn
+// frame left by an interrupted append is cut so that it does not swallow the new frame.
+func (e *Backend) Append(key string, record []byte) error {
+	if !e.isEncrypted(key) {
+		current, err := e.backend.Get(key)
+		if err != nil && !errors.Is(err, backend.ErrNotFound) {
+			return err
+		}
+		if len(current) > 0 && !bytes.HasPrefix(current, magic) {
+			if err = e.plaintext(key); err != nil {
+				return err
+			}
+			return e.Put(key, append(current, record...))
+		}
+		n, err := frames(current, func(string, []byte, []byte) error { return nil })
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if n < len(current) {
+			if err = e.backend.Put(key, current[:n]); err != nil {
+				return err
+			}
+		}
+		e.setEncrypted(key)
+	}
+	data, err := e.encrypt(key, record)
+	if err != nil {
+		return err
+	}
+	if err = backend.Append(e.backend, key, data); err != nil {
+		// the frame may have been written partially
+		e.forget(key)
+		return err
+	}
+	return nil
+}
+
+// Delete removes key
+func (e *Backend) Delete(key string) error {
+	if err := e.backend.Delete(key); err != nil {
+		return err
+	}
+	e.forget(key)
+	return nil
+}
+
+// List returns all keys stored in the wrapped backend
+func (e *Backend) List() ([]string, error) {
+	return e.backend.List()
+}
+
+// Watch streams changes made to the wrapped backend
+func (e *Backend) Watch(ctx context.Context) (<-chan backend.WatchEvent, error) {
+	return e.backend.Watch(ctx)
+}
+
+// WatchExternal streams the keys of the wrapped backend changed by other processes
+func (e *Backend) WatchExternal(ctx context.Context) (<-chan backend.WatchEvent, error) {
+	events, err := backend.WatchExternal(ctx, e.backend)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan backend.WatchEvent, cap(events))
+	go func() {
+		defer close(ch)
+		for event := range events {
+			// the new value may be plaintext
+			e.forget(event.Key)
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Batch runs fn in a single transaction of the wrapped backend when it implements
+// backend.Batcher, values read and written by fn are decrypted and encrypted
+func (e *Backend) Batch(fn func(tx backend.Tx) error) error {
+	var written []string
+	err := backend.Batch(e.backend, func(tx backend.Tx) error {
+		return fn(&encryptedTx{tx: tx, e: e, written: &written})
+	})
+	if err == nil {
+		for _, key := range written {
+			e.setEncrypted(key)
+		}
+	}
+	return err
+}
+
+// Rotate re-encrypts every value with the current key of the provider so that older keys can
+// be retired. The backup copies are re-encrypted too when the wrapped backend implements
+// backend.BackupWriter, otherwise they keep their key until they are replaced. Values already
+// encrypted only with the current key are left untouched. Values written while Rotate runs may
+// be lost, it must not run concurrently with other writes.
+func (e *Backend) Rotate() error {
+	id, _, err := e.keys.CurrentKey()
+	if err != nil {
+		return err
+	}
+	keys, err := e.backend.List()
+	if err != nil {
+		return err
+	}
+	br, _ := e.backend.(backend.BackupReader)
+	bw, _ := e.backend.(backend.BackupWriter)
+	var errs []error
+	for _, key := range keys {
+		value, changed, err := e.reencrypt(key, id, e.backend.Get)
+		if errors.Is(err, backend.ErrNotFound) {
+			continue
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		// the backup is read before the value is replaced, backends may back up the old value
+		var backup []byte
+		var backupChanged bool
+		if br != nil && bw != nil {
+			backup, backupChanged, err = e.reencrypt(key, id, br.GetBackup)
+			if err != nil && !errors.Is(err, backend.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("backup of %s: %w", key, err))
+			}
+		}
+		if changed {
+			if err = e.backend.Put(key, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+			e.setEncrypted(key)
+		}
+		if backup != nil && (changed || backupChanged) {
+			if err = bw.PutBackup(key, backup); err != nil {
+				errs = append(errs, fmt.Errorf("backup of %s: %w", key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reencrypt reads the value of key with get and returns it encrypted with the key id, changed
+// is false if the value already was
+func (e *Backend) reencrypt(key, id string, get func(string) ([]byte, error)) (data []byte, changed bool, err error) {
+	if data, err = get(key); err != nil {
+		return nil, false, err
+	}
+	current, err := encryptedWith(data, id)
+	if err != nil || current {
+		return data, false, err
+	}
+	value, err := e.decrypt(key, data)
+	if err != nil {
+		return nil, false, err
+	}
+	if data, err = e.encrypt(key, value); err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Close closes the wrapped backend if it can be closed
+func (e *Backend) Close() error {
+	if c, ok := e.backend.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// String returns the location of the wrapped backend
+func (e *Backend) String() string {
+	return fmt.Sprintf("encrypted+%v", e.backend)
+}
+
+func (e *Backend) isEncrypted(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.encrypted[key]
+}
+
+func (e *Backend) setEncrypted(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.encrypted[key] = true
+}
+
+func (e *Backend) forget(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.encrypted, key)
+}
+
+// encrypt seals value in a frame: magic | body length | key id length | key id |
+// wrapped data key length | wrapped data key | sealed value. The sealed value is bound to key.
+func (e *Backend) encrypt(key string, value []byte) ([]byte, error) {
+	id, kek, err := e.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("encryption key id %q is too long", id)
+	}
+	dek := make([]byte, KeySize)
+	if _, err = rand.Read(dek); err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(kek, dek, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(dek, value, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	body := make([]byte, 0, 2+len(id)+len(wrapped)+len(sealed))
+	body = append(body, byte(len(id)))
+	body = append(body, id...)
+	body = append(body, byte(len(wrapped)))
+	body = append(body, wrapped...)
+	body = append(body, sealed...)
+	frame := make([]byte, 0, len(magic)+4+len(body))
+	frame = append(frame, magic...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(body)))
+	return append(frame, body...), nil
+}
+
+// Decrypt returns the value of key held by data, the content stored for key in the wrapped
+// backend, for instance to check it in a backend.BackupCheck
+func (e *Backend) Decrypt(key string, data []byte) ([]byte, error) {
+	return e.decrypt(key, data)
+}
+
+// decrypt opens every frame of data and concatenates them, data without frames is plaintext
+func (e *Backend) decrypt(key string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, magic) {
+		if len(data) == 0 {
+			return data, nil
+		}
+		if err := e.plaintext(key); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	var value []byte
+	_, err := frames(data, func(id string, wrapped, sealed []byte) error {
+		kek, err := e.keys.Key(id)
+		if err != nil {
+			return err
+		}
+		dek, err := open(kek, wrapped, []byte(id))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt the data key of %s: %w", key, err)
+		}
+		plain, err := open(dek, sealed, []byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		value = append(value, plain...)
+		return nil
+	})
+	return value, err
+}
+
+// plaintext reports the plaintext value of key, an error if encryption is required
+func (e *Backend) plaintext(key string) error {
+	if e.requireEncryption {
+		return fmt.Errorf("%s: %w", key, ErrPlaintext)
+	}
+	e.logger.Warnf("%s is not encrypted, it will be encrypted by its next write", key)
+	return nil
+}
+
+// encryptedWith reports whether every frame of data is encrypted with the key id
+func encryptedWith(data []byte, id string) (bool, error) {
+	if !bytes.HasPrefix(data, magic) {
+		return len(data) == 0, nil
+	}
+	current := true
+	_, err := frames(data, func(frameID string, _, _ []byte) error {
+		current = current && frameID == id
+		return nil
+	})
+	return current, err
+}
+
+// frames calls fn with the key id, wrapped data key and sealed value of every frame of data and
+// returns the length of the complete frames. A torn frame at the end of data, left by an
+// interrupted append, is ignored.
+func frames(data []byte, fn func(id string, wrapped, sealed []byte) error) (complete int, err error) {
+	for complete < len(data) {
+		frame := data[complete:]
+		if len(frame) < len(magic)+4 {
+			return complete, nil
+		}
+		if !bytes.HasPrefix(frame, magic) {
+			return complete, fmt.Errorf("invalid encrypted frame")
+		}
+		n := int(binary.BigEndian.Uint32(frame[len(magic):]))
+		frame = frame[len(magic)+4:]
+		if n > len(frame) {
+			return complete, nil
+		}
+		body := frame[:n]
+		if len(body) < 1 || len(body) < 1+int(body[0])+1 {
+			return complete, fmt.Errorf("invalid encrypted frame")
+		}
+		id := string(body[1 : 1+body[0]])
+		body = body[1+len(id):]
+		if len(body) < 1+int(body[0]) {
+			return complete, fmt.Errorf("invalid encrypted frame")
+		}
+		wrapped, sealed := body[1:1+body[0]], body[1+body[0]:]
+		if err = fn(id, wrapped, sealed); err != nil {
+			return complete, err
+		}
+		complete += len(magic) + 4 + n
+	}
+	return complete, nil
+}
+
+// seal encrypts plaintext with AES-GCM and prepends the random nonce
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a value sealed by seal
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted value is too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptedTx encrypts the values written in a batch
+type encryptedTx struct {
+	tx      backend.Tx
+	e       *Backend
+	written *[]string
+}
+
+func (t *encryptedTx) Get(key string) ([]byte, error) {
+	data, err := t.tx.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return t.e.decrypt(key, data)
+}
+
+func (t *encryptedTx) Put(key string, value []byte) error {
+	data, err := t.e.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	if err = t.tx.Put(key, data); err != nil {
+		return err
+	}
+	*t.written = append(*t.written, key)
+	return nil
+}
+
+func (t *encryptedTx) Delete(key string) error {
+	return t.tx.Delete(key)
+}
@@ -0,0 +1,250 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/encryption"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "http://localhost:8080/event?token=secret"
+
+func newKeys(t *testing.T) *encryption.FileKeyProvider {
+	keys, err := encryption.NewFileKeyProvider(filepath.Join(t.TempDir(), "keys.json"))
+	assert.Nil(t, err)
+	return keys
+}
+
+func TestBackend_PutGet(t *testing.T) {
+	dir := t.TempDir()
+	files := backend.NewFileBackend(dir)
+	b := encryption.NewBackend(files, newKeys(t))
+	assert.Nil(t, b.Put("sub.json", []byte(secret)))
+	value, err := b.Get("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+
+	raw, err := os.ReadFile(filepath.Join(dir, "sub.json"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(raw), "secret")
+	info, err := os.Stat(filepath.Join(dir, "sub.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, backend.DefaultFileMode, info.Mode().Perm())
+
+	// the previous value is kept encrypted as backup
+	assert.Nil(t, b.Put("sub.json", []byte("second")))
+	value, err = b.GetBackup("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+
+	// values are bound to their key
+	assert.Nil(t, files.Put("pub.json", raw))
+	_, err = b.Get("pub.json")
+	assert.NotNil(t, err)
+	_, err = b.Get("missing.json")
+	assert.True(t, errors.Is(err, backend.ErrNotFound))
+}
+
+func TestBackend_Append(t *testing.T) {
+	mem := backend.NewMemoryBackend()
+	keys := newKeys(t)
+	b := encryption.NewBackend(mem, keys)
+	assert.Nil(t, mem.Put("journal.log", []byte("plain\n")))
+	assert.Nil(t, b.Append("journal.log", []byte("one\n")))
+	assert.Nil(t, b.Append("journal.log", []byte("two\n")))
+	value, err := b.Get("journal.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "plain\none\ntwo\n", string(value))
+	raw, _ := mem.Get("journal.log")
+	assert.NotContains(t, string(raw), "plain")
+
+	// a torn frame left by an interrupted append is ignored
+	assert.Nil(t, mem.Put("journal.log", raw[:len(raw)-3]))
+	value, err = b.Get("journal.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "plain\none\n", string(value))
+
+	// the torn frame is cut before the next append
+	assert.Nil(t, encryption.NewBackend(mem, keys).Append("journal.log", []byte("three\n")))
+	value, err = b.Get("journal.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "plain\none\nthree\n", string(value))
+}
+
+// tornAppender writes only half of the next appended record
+type tornAppender struct {
+	*backend.MemoryBackend
+	tear bool
+}
+
+func (b *tornAppender) Append(key string, record []byte) error {
+	if b.tear {
+		b.tear = false
+		_ = backend.Append(b.MemoryBackend, key, record[:len(record)/2])
+		return errors.New("disk full")
+	}
+	return backend.Append(b.MemoryBackend, key, record)
+}
+
+func TestBackend_AppendFailed(t *testing.T) {
+	mem := &tornAppender{MemoryBackend: backend.NewMemoryBackend()}
+	b := encryption.NewBackend(mem, newKeys(t))
+	assert.Nil(t, b.Append("journal.log", []byte("one\n")))
+	mem.tear = true
+	assert.NotNil(t, b.Append("journal.log", []byte("two\n")))
+	assert.Nil(t, b.Append("journal.log", []byte("three\n")))
+	value, err := b.Get("journal.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "one\nthree\n", string(value))
+}
+
+func TestBackend_Plaintext(t *testing.T) {
+	mem := backend.NewMemoryBackend()
+	assert.Nil(t, mem.Put("sub.json", []byte(secret)))
+	assert.Nil(t, mem.Put("journal.log", []byte("one\n")))
+	keys := newKeys(t)
+	var logs bytes.Buffer
+	logger := log.New()
+	logger.Out = &logs
+	b := encryption.NewBackend(mem, keys, encryption.WithLogger(logger))
+	value, err := b.Get("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+	assert.Contains(t, logs.String(), "sub.json is not encrypted")
+
+	// plaintext values are rejected once encryption is required
+	strict := encryption.NewBackend(mem, keys, encryption.RequireEncryption())
+	_, err = strict.Get("sub.json")
+	assert.True(t, errors.Is(err, encryption.ErrPlaintext))
+	assert.True(t, errors.Is(strict.Append("journal.log", []byte("two\n")), encryption.ErrPlaintext))
+	raw, _ := mem.Get("journal.log")
+	assert.Equal(t, "one\n", string(raw))
+	assert.Nil(t, strict.Put("sub.json", []byte(secret)))
+	value, err = strict.Get("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+}
+
+func TestBackend_Batch(t *testing.T) {
+	bolt, err := backend.NewBoltBackend(filepath.Join(t.TempDir(), "store.db"))
+	assert.Nil(t, err)
+	b := encryption.NewBackend(bolt, newKeys(t))
+	defer b.Close()
+	err = b.Batch(func(tx backend.Tx) error {
+		if e := tx.Put("pub.json", []byte(secret)); e != nil {
+			return e
+		}
+		value, e := tx.Get("pub.json")
+		assert.Equal(t, secret, string(value))
+		return e
+	})
+	assert.Nil(t, err)
+	raw, _ := bolt.Get("pub.json")
+	assert.NotContains(t, string(raw), "secret")
+	value, err := b.Get("pub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+}
+
+func TestBackend_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := encryption.NewFileKeyProvider(path)
+	assert.Nil(t, err)
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	old, _, err := keys.CurrentKey()
+	assert.Nil(t, err)
+
+	mem := backend.NewMemoryBackend()
+	b := encryption.NewBackend(mem, keys)
+	assert.Nil(t, b.Put("sub.json", []byte(secret)))
+	assert.Nil(t, b.Append("audit.log", []byte("one\n")))
+	current, err := keys.RotateKey()
+	assert.Nil(t, err)
+	assert.NotEqual(t, old, current)
+	assert.Nil(t, b.Append("audit.log", []byte("two\n")))
+
+	// values encrypted with the old key are still readable, also by another process
+	reloaded, err := encryption.NewFileKeyProvider(path)
+	assert.Nil(t, err)
+	value, err := encryption.NewBackend(mem, reloaded).Get("audit.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "one\ntwo\n", string(value))
+
+	assert.NotNil(t, keys.RemoveKey(current))
+	assert.Nil(t, b.Rotate())
+	assert.Nil(t, keys.RemoveKey(old))
+	b = encryption.NewBackend(mem, keys)
+	value, err = b.Get("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+	value, err = b.Get("audit.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "one\ntwo\n", string(value))
+
+	_, err = keys.Key(old)
+	assert.True(t, errors.Is(err, encryption.ErrUnknownKey))
+}
+
+func TestBackend_RotateBackups(t *testing.T) {
+	keys := newKeys(t)
+	old, _, err := keys.CurrentKey()
+	assert.Nil(t, err)
+	b := encryption.NewBackend(backend.NewFileBackend(t.TempDir()), keys)
+	assert.Nil(t, b.Put("sub.json", []byte("first")))
+	assert.Nil(t, b.Put("sub.json", []byte(secret)))
+	_, err = keys.RotateKey()
+	assert.Nil(t, err)
+	assert.Nil(t, b.Rotate())
+	assert.Nil(t, keys.RemoveKey(old))
+
+	value, err := b.Get("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, string(value))
+	value, err = b.GetBackup("sub.json")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", string(value))
+}
+
+func TestBackend_RestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	b := encryption.NewBackend(backend.NewFileBackend(dir), newKeys(t))
+	assert.Nil(t, b.Put("sub.json", []byte(`["a"]`)))
+	assert.Nil(t, b.Put("sub.json", []byte(`["b"]`)))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "sub.json"), []byte("garbage"), backend.DefaultFileMode))
+
+	var list []string
+	err := backend.LoadWithFallback(b, "sub.json", func(data []byte) error { return json.Unmarshal(data, &list) })
+	var corruption *backend.CorruptionError
+	assert.True(t, errors.As(err, &corruption))
+	assert.True(t, corruption.Recovered)
+	assert.Equal(t, []string{"a"}, list)
+	// the backup is kept and still decrypts
+	for _, get := range []func(string) ([]byte, error){b.Get, b.GetBackup} {
+		value, err := get("sub.json")
+		assert.Nil(t, err)
+		assert.Equal(t, `["a"]`, string(value))
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+)
+
+var _ KeyProvider = (*FileKeyProvider)(nil)
+
+// keyFileMode is the mode of key files, they must only be readable by their owner
+const keyFileMode = 0600
+
+// keyRing is the content of a key file
+type keyRing struct {
+	// Current is the id of the key new values are encrypted with
+	Current string `json:"current"`
+	// Keys maps key ids to base64 encoded keys
+	Keys map[string][]byte `json:"keys"`
+}
+
+// FileKeyProvider keeps the key encryption keys in a local file. A new key is generated when the
+// file does not exist. Older keys stay in the file after a rotation so that values encrypted
+// with them can still be read.
+type FileKeyProvider struct {
+	mu   sync.RWMutex
+	path string
+	ring keyRing
+}
+
+// NewFileKeyProvider loads the keys from path, creating the file with a new key if needed
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	f := &FileKeyProvider{path: path}
+	err := f.load()
+	if errors.Is(err, os.ErrNotExist) {
+		_, err = f.RotateKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// CurrentKey returns the key new values are encrypted with
+func (f *FileKeyProvider) CurrentKey() (string, []byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	key, ok := f.ring.Keys[f.ring.Current]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: current key %q is missing from %s", ErrUnknownKey, f.ring.Current, f.path)
+	}
+	return f.ring.Current, key, nil
+}
+
+// Key returns the key with the given id. The file is read again if the key is unknown, so
+// keys added by another process are found.
+func (f *FileKeyProvider) Key(id string) ([]byte, error) {
+	f.mu.RLock()
+	key, ok := f.ring.Keys[id]
+	f.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if key, ok = f.ring.Keys[id]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// RotateKey generates a new current key and returns its id
+func (f *FileKeyProvider) RotateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ring := keyRing{Keys: map[string][]byte{}}
+	for id, k := range f.ring.Keys {
+		ring.Keys[id] = k
+	}
+	id := strconv.FormatInt(time.Now().UnixNano(), 36)
+	for _, ok := ring.Keys[id]; ok; _, ok = ring.Keys[id] {
+		id += "0"
+	}
+	ring.Current = id
+	ring.Keys[id] = key
+	if err := writeKeyRing(f.path, ring); err != nil {
+		return "", err
+	}
+	f.ring = ring
+	return id, nil
+}
+
+// RemoveKey deletes a key which is no longer used, the current key can't be removed
+func (f *FileKeyProvider) RemoveKey(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id == f.ring.Current {
+		return fmt.Errorf("key %q is the current key", id)
+	}
+	if _, ok := f.ring.Keys[id]; !ok {
+		return nil
+	}
+	ring := keyRing{Current: f.ring.Current, Keys: map[string][]byte{}}
+	for k, v := range f.ring.Keys {
+		if k != id {
+			ring.Keys[k] = v
+		}
+	}
+	if err := writeKeyRing(f.path, ring); err != nil {
+		return err
+	}
+	f.ring = ring
+	return nil
+}
+
+func (f *FileKeyProvider) load() error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	var ring keyRing
+	if err = json.Unmarshal(data, &ring); err != nil {
+		return fmt.Errorf("invalid key file %s: %w", f.path, err)
+	}
+	for id, key := range ring.Keys {
+		if len(key) != KeySize {
+			return fmt.Errorf("invalid key file %s: key %q is %d bytes long, expected %d", f.path, id, len(key), KeySize)
+		}
+	}
+	f.mu.Lock()
+	f.ring = ring
+	f.mu.Unlock()
+	return nil
+}
+
+// writeKeyRing replaces the key file atomically so a crash never loses the keys
+func writeKeyRing(path string, ring keyRing) error {
+	data, err := json.Marshal(ring)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return backend.WriteFileAtomic(path, data, keyFileMode)
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+)
+
+// Kinds of records limited by a Quota
+const (
+	QuotaSubscriptions = "subscriptions"
+	QuotaClients       = "clients"
+)
+
+// Quota limits the number of records of a namespace, a zero limit is unlimited
+type Quota struct {
+	MaxSubscriptions int
+	MaxClients       int
+}
+
+// ErrQuotaExceeded is matched by errors.Is for every *QuotaError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError is returned when a create would exceed the quota of a namespace
+type QuotaError struct {
+	Namespace string
+	Kind      string
+	Limit     int
+}
+
+// Error implements error
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("namespace %s: quota of %d %s exceeded", e.Namespace, e.Limit, e.Kind)
+}
+
+// Is reports ErrQuotaExceeded
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// CheckQuota returns a *QuotaError if adding n records of kind to the count records of
+// namespace would exceed limit
+func CheckQuota(namespace, kind string, limit, count, n int) error {
+	if limit <= 0 || count+n <= limit {
+		return nil
+	}
+	return &QuotaError{Namespace: pubsub.Namespace(namespace), Kind: kind, Limit: limit}
+}
+
+// Quotas holds the quota of every namespace, namespaces without their own quota use the default one
+type Quotas struct {
+	sync.RWMutex
+	defaultQuota Quota
+	namespaces   map[string]Quota
+}
+
+// Set sets the quota of namespace
+func (q *Quotas) Set(namespace string, quota Quota) {
+	q.Lock()
+	defer q.Unlock()
+	if q.namespaces == nil {
+		q.namespaces = map[string]Quota{}
+	}
+	q.namespaces[pubsub.Namespace(namespace)] = quota
+}
+
+// SetDefault sets the quota of the namespaces without their own quota
+func (q *Quotas) SetDefault(quota Quota) {
+	q.Lock()
+	defer q.Unlock()
+	q.defaultQuota = quota
+}
+
+// Get returns the quota of namespace
+func (q *Quotas) Get(namespace string) Quota {
+	q.RLock()
+	defer q.RUnlock()
+	if quota, ok := q.namespaces[pubsub.Namespace(namespace)]; ok {
+		return quota
+	}
+	return q.defaultQuota
+}
+
+// InNamespace returns a copy of the pub/subs of namespace keyed by their store key
+func (ps *PubSubStore) InNamespace(namespace string) map[string]*pubsub.PubSub {
+	namespace = pubsub.Namespace(namespace)
+	ps.RLock()
+	defer ps.RUnlock()
+	result := map[string]*pubsub.PubSub{}
+	for key, s := range ps.Store {
+		if s.GetNamespace() == namespace {
+			c := s.Copy()
+			result[key] = &c
+		}
+	}
+	return result
+}
+
+// CountNamespace returns the number of pub/subs of namespace
+func (ps *PubSubStore) CountNamespace(namespace string) (n int) {
+	namespace = pubsub.Namespace(namespace)
+	ps.RLock()
+	defer ps.RUnlock()
+	for _, s := range ps.Store {
+		if s.GetNamespace() == namespace {
+			n++
+		}
+	}
+	return
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotas(t *testing.T) {
+	var q store.Quotas
+	assert.Equal(t, store.Quota{}, q.Get("a"))
+	q.SetDefault(store.Quota{MaxSubscriptions: 2})
+	q.Set("a", store.Quota{MaxSubscriptions: 5, MaxClients: 1})
+	q.Set("", store.Quota{MaxClients: 3})
+	assert.Equal(t, 5, q.Get("a").MaxSubscriptions)
+	assert.Equal(t, 2, q.Get("b").MaxSubscriptions)
+	assert.Equal(t, 3, q.Get(pubsub.DefaultNamespace).MaxClients)
+
+	assert.Nil(t, store.CheckQuota("a", store.QuotaClients, 0, 100, 1))
+	assert.Nil(t, store.CheckQuota("a", store.QuotaClients, 2, 1, 1))
+	err := store.CheckQuota("a", store.QuotaClients, 2, 1, 2)
+	assert.True(t, errors.Is(err, store.ErrQuotaExceeded))
+	assert.Equal(t, "namespace a: quota of 2 clients exceeded", err.Error())
+}
+
+func TestPubSubStore_InNamespace(t *testing.T) {
+	ps := &store.PubSubStore{}
+	ps.Set("1", pubsub.PubSub{ID: "1", Resource: "/a"})
+	ps.Set("2", pubsub.PubSub{ID: "2", Resource: "/a", Namespace: "tenant", EndPointURI: types.ParseURI("http://localhost:9090/ack")})
+	ps.Set("3", pubsub.PubSub{ID: "3", Resource: "/b", Namespace: "tenant"})
+
+	assert.Equal(t, 1, ps.CountNamespace(""))
+	assert.Equal(t, 1, ps.CountNamespace(pubsub.DefaultNamespace))
+	assert.Equal(t, 2, ps.CountNamespace("tenant"))
+	subs := ps.InNamespace("tenant")
+	assert.Len(t, subs, 2)
+	subs["2"].Resource = "/changed"
+	subs["2"].EndPointURI.Path = "/changed"
+	assert.Equal(t, "/a", ps.Get("2").Resource)
+	assert.Equal(t, "http://localhost:9090/ack", ps.Get("2").EndPointURI.String())
+}
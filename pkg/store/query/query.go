@@ -0,0 +1,187 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+const (
+	// DefaultLimit is the page size used when Request.Limit is not set
+	DefaultLimit = 100
+	// MaxLimit is the largest page size returned
+	MaxLimit = 1000
+)
+
+// Filter selects subscriptions, unset fields match everything
+type Filter struct {
+	// ResourcePrefix matches subscriptions whose resource address starts with the prefix
+	ResourcePrefix string
+	// ClientID matches subscriptions of a single client
+	ClientID uuid.UUID
+	// Namespace matches subscriptions of a single namespace, use pubsub.DefaultNamespace
+	// for the subscriptions created without one
+	Namespace string
+	// EndpointHost matches the host, with or without port, of the subscription endpoint
+	EndpointHost string
+	// Status matches subscriptions of clients with the given status
+	Status *subscriber.Status
+	// CreatedAfter matches subscriptions created at or after the time
+	CreatedAfter time.Time
+	// CreatedBefore matches subscriptions created before the time
+	CreatedBefore time.Time
+}
+
+// Request is a page of a query
+type Request struct {
+	Filter
+	// Limit is the maximum number of items returned, DefaultLimit if not set
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+}
+
+// Item is a subscription returned by a query. Items are copies, changing them does not
+// change the store.
+type Item struct {
+	// ClientID of the subscriber holding the subscription, uuid.Nil for the pub/sub store
+	ClientID uuid.UUID
+	// Status of the subscriber holding the subscription, nil for the pub/sub store
+	Status *subscriber.Status
+	// Subscription data
+	Subscription pubsub.PubSub
+}
+
+// Page is the result of a query
+type Page struct {
+	Items []Item
+	// NextCursor returns the next page, empty on the last page
+	NextCursor string
+}
+
+// cursor is the sort key of the last item of a page
+type cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ClientID  uuid.UUID `json:"c"`
+	ID        string    `json:"i"`
+}
+
+func keyOf(item *Item) cursor {
+	return cursor{CreatedAt: item.Subscription.CreatedAt, ClientID: item.ClientID, ID: item.Subscription.ID}
+}
+
+func (c cursor) less(o cursor) bool {
+	if !c.CreatedAt.Equal(o.CreatedAt) {
+		return c.CreatedAt.Before(o.CreatedAt)
+	}
+	if c.ClientID != o.ClientID {
+		return c.ClientID.String() < o.ClientID.String()
+	}
+	return c.ID < o.ID
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor %q", s)
+	}
+	return c, nil
+}
+
+// Match reports whether item is selected by the filter
+func (f *Filter) Match(item *Item) bool {
+	sub := &item.Subscription
+	if f.ResourcePrefix != "" && !strings.HasPrefix(sub.GetResource(), f.ResourcePrefix) {
+		return false
+	}
+	if f.ClientID != uuid.Nil && item.ClientID != f.ClientID {
+		return false
+	}
+	if f.Namespace != "" && sub.GetNamespace() != f.Namespace {
+		return false
+	}
+	if f.EndpointHost != "" {
+		if sub.EndPointURI == nil || (sub.EndPointURI.Host != f.EndpointHost && sub.EndPointURI.Hostname() != f.EndpointHost) {
+			return false
+		}
+	}
+	if f.Status != nil && (item.Status == nil || *item.Status != *f.Status) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && sub.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !sub.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// Run filters items, orders them by creation time, client id and subscription id and returns
+// the page after req.Cursor. Pages stay consistent while subscriptions are added or removed.
+func Run(items []Item, req Request) (Page, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	} else if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	var after *cursor
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		after = &c
+	}
+	selected := make([]Item, 0, len(items))
+	for i := range items {
+		if !req.Match(&items[i]) {
+			continue
+		}
+		if after != nil && !after.less(keyOf(&items[i])) {
+			continue
+		}
+		selected = append(selected, items[i])
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return keyOf(&selected[i]).less(keyOf(&selected[j]))
+	})
+	page := Page{}
+	if len(selected) > limit {
+		selected = selected[:limit]
+		page.NextCursor = encodeCursor(keyOf(&selected[limit-1]))
+	}
+	page.Items = selected
+	return page, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query_test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store/query"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+	"github.com/redhat-cne/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	start    = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clientA  = uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	clientB  = uuid.MustParse("123e4567-e89b-12d3-a456-426614174001")
+	active   = subscriber.Active
+	inactive = subscriber.InActive
+)
+
+func items() []query.Item {
+	var list []query.Item
+	for i := 0; i < 10; i++ {
+		clientID, status, host := clientA, &active, "consumer-a:9090"
+		if i%2 == 1 {
+			clientID, status, host = clientB, &inactive, "consumer-b:9090"
+		}
+		list = append(list, query.Item{
+			ClientID: clientID,
+			Status:   status,
+			Subscription: pubsub.PubSub{
+				ID:          fmt.Sprintf("sub-%d", i),
+				Resource:    fmt.Sprintf("/cluster/node/worker-%d/sync/ptp-status/lock-state", i%3),
+				EndPointURI: &types.URI{URL: url.URL{Scheme: "http", Host: host, Path: "/event"}},
+				CreatedAt:   start.Add(time.Duration(9-i) * time.Minute),
+			},
+		})
+	}
+	return list
+}
+
+func TestRun_Filters(t *testing.T) {
+	tests := map[string]struct {
+		filter query.Filter
+		count  int
+	}{
+		"none":           {filter: query.Filter{}, count: 10},
+		"resourcePrefix": {filter: query.Filter{ResourcePrefix: "/cluster/node/worker-0/"}, count: 4},
+		"clientID":       {filter: query.Filter{ClientID: clientB}, count: 5},
+		"endpointHost":   {filter: query.Filter{EndpointHost: "consumer-a"}, count: 5},
+		"endpointPort":   {filter: query.Filter{EndpointHost: "consumer-b:9090"}, count: 5},
+		"status":         {filter: query.Filter{Status: &inactive}, count: 5},
+		"createdAfter":   {filter: query.Filter{CreatedAfter: start.Add(5 * time.Minute)}, count: 5},
+		"createdBefore":  {filter: query.Filter{CreatedBefore: start.Add(5 * time.Minute)}, count: 5},
+		"combined":       {filter: query.Filter{ClientID: clientA, CreatedBefore: start.Add(5 * time.Minute)}, count: 2},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := query.Run(items(), query.Request{Filter: tc.filter})
+			assert.Nil(t, err)
+			assert.Len(t, page.Items, tc.count)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestRun_Pagination(t *testing.T) {
+	list := items()
+	var ids []string
+	req := query.Request{Limit: 3}
+	for pages := 0; ; pages++ {
+		assert.Less(t, pages, 4)
+		page, err := query.Run(list, req)
+		assert.Nil(t, err)
+		for _, item := range page.Items {
+			ids = append(ids, item.Subscription.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+		// a subscription added between pages sorts after the cursor and is not skipped
+		if pages == 0 {
+			list = append(list, query.Item{Subscription: pubsub.PubSub{ID: "late", CreatedAt: start.Add(time.Hour)}})
+		}
+	}
+	// ordered by creation time, sub-9 was created first
+	assert.Equal(t, []string{"sub-9", "sub-8", "sub-7", "sub-6", "sub-5", "sub-4", "sub-3", "sub-2", "sub-1", "sub-0", "late"}, ids)
+
+	_, err := query.Run(list, query.Request{Cursor: "not a cursor"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AnyRevision disables the revision check of a conditional update or delete
+const AnyRevision uint64 = 0
+
+// ErrConflict is matched by errors.Is for every *ConflictError
+var ErrConflict = errors.New("revision conflict")
+
+// ConflictError is returned by conditional updates and deletes when the stored revision
+// is not the expected one. Current is 0 if the record does not exist.
+type ConflictError struct {
+	Key      string
+	Expected uint64
+	Current  uint64
+}
+
+// Error implements error
+func (e *ConflictError) Error() string {
+	if e.Current == 0 {
+		return fmt.Sprintf("%s: expected revision %d, record does not exist", e.Key, e.Expected)
+	}
+	return fmt.Sprintf("%s: expected revision %d, current revision is %d", e.Key, e.Expected, e.Current)
+}
+
+// Is reports ErrConflict
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// CheckRevision returns a *ConflictError unless expected is AnyRevision or equals current
+func CheckRevision(key string, expected, current uint64) error {
+	if expected == AnyRevision || expected == current {
+		return nil
+	}
+	return &ConflictError{Key: key, Expected: expected, Current: current}
+}
+
+// ETag formats a revision as a strong HTTP entity tag
+func ETag(revision uint64) string {
+	return strconv.Quote(strconv.FormatUint(revision, 10))
+}
+
+// ParseETag returns the revision of an If-Match header value written by ETag, "*" is AnyRevision
+func ParseETag(tag string) (uint64, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "*" {
+		return AnyRevision, nil
+	}
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(tag, "W/"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid entity tag %q", tag)
+	}
+	revision, err := strconv.ParseUint(unquoted, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid entity tag %q", tag)
+	}
+	return revision, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRevision(t *testing.T) {
+	assert.Nil(t, store.CheckRevision("a", store.AnyRevision, 7))
+	assert.Nil(t, store.CheckRevision("a", 7, 7))
+
+	err := store.CheckRevision("a", 6, 7)
+	assert.True(t, errors.Is(err, store.ErrConflict))
+	var conflict *store.ConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, store.ConflictError{Key: "a", Expected: 6, Current: 7}, *conflict)
+	assert.Contains(t, store.CheckRevision("a", 6, 0).Error(), "does not exist")
+}
+
+func TestParseETag(t *testing.T) {
+	testCases := map[string]struct {
+		tag      string
+		revision uint64
+		wantErr  bool
+	}{
+		"strong":   {tag: store.ETag(42), revision: 42},
+		"weak":     {tag: `W/"3"`, revision: 3},
+		"any":      {tag: " * ", revision: store.AnyRevision},
+		"unquoted": {tag: "42", wantErr: true},
+		"numeric":  {tag: `"abc"`, wantErr: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			revision, err := store.ParseETag(tc.tag)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.revision, revision)
+		})
+	}
+}
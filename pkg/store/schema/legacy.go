@@ -0,0 +1,103 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import "fmt"
+
+// legacyPubSubFields maps the pre O-RAN pub/sub field names to the current ones
+var legacyPubSubFields = map[string]string{
+	"id":          "SubscriptionId",
+	"endpointUri": "EndpointUri",
+	"uriLocation": "UriLocation",
+	"resource":    "ResourceAddress",
+}
+
+// legacySubscriberFields maps the pre O-RAN subscriber field names to the current ones
+var legacySubscriberFields = map[string]string{
+	"endPointURI": "EndpointUri",
+	"endpointUri": "EndpointUri",
+}
+
+// legacyStoreFields maps the field names of the subscription store to the current ones
+var legacyStoreFields = map[string]string{
+	"Store": "store",
+}
+
+// hasLegacyFieldNames reports whether an unversioned document uses any pre O-RAN field name
+func hasLegacyFieldNames(kind Kind, doc interface{}) bool {
+	found := false
+	walk(kind, doc, func(obj map[string]interface{}, renames map[string]string) {
+		for old := range renames {
+			if _, ok := obj[old]; ok {
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// migrateLegacyFieldNames renames the pre O-RAN field names, current names win on conflict
+func migrateLegacyFieldNames(kind Kind, doc interface{}) (interface{}, error) {
+	switch kind {
+	case KindPubSubList, KindSubscriber:
+	default:
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+	walk(kind, doc, func(obj map[string]interface{}, renames map[string]string) {
+		for old, current := range renames {
+			v, ok := obj[old]
+			if !ok {
+				continue
+			}
+			delete(obj, old)
+			if _, exists := obj[current]; !exists {
+				obj[current] = v
+			}
+		}
+	})
+	return doc, nil
+}
+
+// walk calls fn for every object of doc together with the renames that apply to it
+func walk(kind Kind, doc interface{}, fn func(obj map[string]interface{}, renames map[string]string)) {
+	switch kind {
+	case KindPubSubList:
+		list, _ := doc.([]interface{})
+		for _, item := range list {
+			if obj, ok := item.(map[string]interface{}); ok {
+				fn(obj, legacyPubSubFields)
+			}
+		}
+	case KindSubscriber:
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fn(obj, legacySubscriberFields)
+		subStore, ok := obj["subStore"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		fn(subStore, legacyStoreFields)
+		for _, key := range []string{"store", "Store"} {
+			subs, _ := subStore[key].(map[string]interface{})
+			for _, item := range subs {
+				if sub, ok := item.(map[string]interface{}); ok {
+					fn(sub, legacyPubSubFields)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Kind identifies the payload stored in a file
+type Kind string
+
+const (
+	// KindPubSubList a list of publishers or subscriptions (pub.json, sub.json)
+	KindPubSubList Kind = "pubsub-list"
+	// KindSubscriber a subscriber client with its subscriptions (<clientID>.json)
+	KindSubscriber Kind = "subscriber"
+	// KindArchive an export of the complete pub/sub state of a node
+	KindArchive Kind = "archive"
+)
+
+const (
+	// VersionLegacy is the unversioned layout written before the O-RAN field names, i.e. pub/sub
+	// objects with id, endpointUri, uriLocation and resource and subscribers with endPointURI.
+	VersionLegacy = 0
+	// VersionUnversioned is the unversioned layout using the O-RAN field names SubscriptionId,
+	// EndpointUri, UriLocation and ResourceAddress.
+	VersionUnversioned = 1
+	// VersionEnvelope wraps the payload in an Envelope.
+	VersionEnvelope = 2
+	// CurrentVersion is the version written by this release
+	CurrentVersion = VersionEnvelope
+)
+
+// Envelope is the versioned wrapper written around every store file
+type Envelope struct {
+	Version int             `json:"version"`
+	Kind    Kind            `json:"kind"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Migration upgrades a decoded payload from version n to n+1. The document is the generic JSON
+// decoding of the payload (maps, slices, json.Number, strings, bools and nil).
+type Migration func(kind Kind, doc interface{}) (interface{}, error)
+
+// Registry holds the migrations between store file versions
+type Registry struct {
+	sync.RWMutex
+	current    int
+	migrations map[int]Migration
+}
+
+// NewRegistry returns an empty registry upgrading files to version current
+func NewRegistry(current int) *Registry {
+	return &Registry{current: current, migrations: map[int]Migration{}}
+}
+
+// DefaultRegistry is used by the package level functions and the v1 store APIs
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry(CurrentVersion)
+	_ = r.Register(VersionLegacy, migrateLegacyFieldNames)
+	_ = r.Register(VersionUnversioned, func(_ Kind, doc interface{}) (interface{}, error) {
+		// only the envelope was added
+		return doc, nil
+	})
+	return r
+}
+
+// Current returns the version written by the registry
+func (r *Registry) Current() int {
+	return r.current
+}
+
+// Register adds the migration upgrading files from version from to from+1
+func (r *Registry) Register(from int, m Migration) error {
+	r.Lock()
+	defer r.Unlock()
+	if from < 0 || from >= r.current {
+		return fmt.Errorf("migration from version %d is outside of [0, %d)", from, r.current)
+	}
+	if _, ok := r.migrations[from]; ok {
+		return fmt.Errorf("migration from version %d is already registered", from)
+	}
+	r.migrations[from] = m
+	return nil
+}
+
+// Wrap encodes payload in an envelope of the current version
+func (r *Registry) Wrap(kind Kind, payload []byte) ([]byte, error) {
+	return json.MarshalIndent(&Envelope{Version: r.current, Kind: kind, Data: payload}, "", " ")
+}
+
+// Unwrap returns the payload of a store file upgraded to the current version together with the
+// version the file was written with. Empty input is returned as is.
+func (r *Registry) Unwrap(kind Kind, data []byte) (payload []byte, version int, err error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data, r.current, nil
+	}
+	var doc interface{}
+	if version, doc, err = r.decode(kind, data); err != nil {
+		return nil, version, err
+	}
+	if version > r.current {
+		return nil, version, fmt.Errorf("%s file version %d is newer than supported version %d", kind, version, r.current)
+	}
+	if version == r.current {
+		if env, ok := envelope(data); ok {
+			return env.Data, version, nil
+		}
+	}
+	r.RLock()
+	defer r.RUnlock()
+	for v := version; v < r.current; v++ {
+		m, ok := r.migrations[v]
+		if !ok {
+			return nil, version, fmt.Errorf("no migration registered for %s from version %d", kind, v)
+		}
+		if doc, err = m(kind, doc); err != nil {
+			return nil, version, fmt.Errorf("migrating %s from version %d: %w", kind, v, err)
+		}
+	}
+	payload, err = json.Marshal(doc)
+	return payload, version, err
+}
+
+// decode detects the version of data and returns its payload as a generic document
+func (r *Registry) decode(kind Kind, data []byte) (int, interface{}, error) {
+	if env, ok := envelope(data); ok {
+		if env.Kind != kind {
+			return env.Version, nil, fmt.Errorf("expected %s file, found %s", kind, env.Kind)
+		}
+		doc, err := decodeGeneric(env.Data)
+		return env.Version, doc, err
+	}
+	doc, err := decodeGeneric(data)
+	if err != nil {
+		return VersionLegacy, nil, err
+	}
+	if hasLegacyFieldNames(kind, doc) {
+		return VersionLegacy, doc, nil
+	}
+	return VersionUnversioned, doc, nil
+}
+
+// envelope reports whether data is a versioned envelope
+func envelope(data []byte) (*Envelope, bool) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, false
+	}
+	_, hasVersion := probe["version"]
+	_, hasData := probe["data"]
+	if !hasVersion || !hasData {
+		return nil, false
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, false
+	}
+	return &env, true
+}
+
+func decodeGeneric(data []byte) (interface{}, error) {
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Wrap encodes payload in an envelope of the current version using the DefaultRegistry
+func Wrap(kind Kind, payload []byte) ([]byte, error) {
+	return DefaultRegistry.Wrap(kind, payload)
+}
+
+// Unwrap upgrades a store file to the current version using the DefaultRegistry
+func Unwrap(kind Kind, data []byte) ([]byte, int, error) {
+	return DefaultRegistry.Unwrap(kind, data)
+}
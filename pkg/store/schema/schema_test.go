@@ -0,0 +1,133 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	legacyPubSubList = `[{"id":"1","endpointUri":"http://localhost:9090/ack/event",` +
+		`"uriLocation":"http://localhost:8080/api/ocloudNotifications/v1/subscriptions/1","resource":"/east-edge-10/vdu3/o-ran-sync/sync-group/sync-status/sync-state"}]`
+	unversionedPubSubList = `[{"SubscriptionId":"1","EndpointUri":"http://localhost:9090/ack/event",` +
+		`"UriLocation":"http://localhost:8080/api/ocloudNotifications/v1/subscriptions/1","ResourceAddress":"/east-edge-10/vdu3/o-ran-sync/sync-group/sync-status/sync-state"}]`
+	legacySubscriber = `{"clientID":"123e4567-e89b-12d3-a456-426614174000","endPointURI":"http://localhost:8080/health",` +
+		`"subStore":{"Store":{"1":{"id":"1","endpointUri":"http://localhost:9090/ack/event","resource":"/test/1"}}},"status":1}`
+	unversionedSubscriber = `{"clientID":"123e4567-e89b-12d3-a456-426614174000","EndpointUri":"http://localhost:8080/health",` +
+		`"subStore":{"store":{"1":{"SubscriptionId":"1","EndpointUri":"http://localhost:9090/ack/event","ResourceAddress":"/test/1"}}},"status":1}`
+)
+
+func TestUnwrap_PubSubList(t *testing.T) {
+	wrapped, err := schema.Wrap(schema.KindPubSubList, []byte(unversionedPubSubList))
+	assert.Nil(t, err)
+	tests := map[string]struct {
+		data    string
+		version int
+	}{
+		"legacy":      {data: legacyPubSubList, version: schema.VersionLegacy},
+		"unversioned": {data: unversionedPubSubList, version: schema.VersionUnversioned},
+		"envelope":    {data: string(wrapped), version: schema.VersionEnvelope},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			payload, version, err := schema.Unwrap(schema.KindPubSubList, []byte(tc.data))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.version, version)
+			var list []pubsub.PubSub
+			assert.Nil(t, json.Unmarshal(payload, &list))
+			assert.Len(t, list, 1)
+			assert.Equal(t, "1", list[0].ID)
+			assert.Equal(t, "http://localhost:9090/ack/event", list[0].GetEndpointURI())
+			assert.Equal(t, "/east-edge-10/vdu3/o-ran-sync/sync-group/sync-status/sync-state", list[0].Resource)
+		})
+	}
+}
+
+func TestUnwrap_Subscriber(t *testing.T) {
+	wrapped, err := schema.Wrap(schema.KindSubscriber, []byte(unversionedSubscriber))
+	assert.Nil(t, err)
+	tests := map[string]struct {
+		data    string
+		version int
+	}{
+		"legacy":      {data: legacySubscriber, version: schema.VersionLegacy},
+		"unversioned": {data: unversionedSubscriber, version: schema.VersionUnversioned},
+		"envelope":    {data: string(wrapped), version: schema.VersionEnvelope},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			payload, version, err := schema.Unwrap(schema.KindSubscriber, []byte(tc.data))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.version, version)
+			var s subscriber.Subscriber
+			assert.Nil(t, json.Unmarshal(payload, &s))
+			assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", s.ClientID.String())
+			assert.Equal(t, "http://localhost:8080/health", s.GetEndPointURI())
+			assert.Len(t, s.SubStore.Store, 1)
+			assert.Equal(t, "/test/1", s.SubStore.Store["1"].Resource)
+			assert.Equal(t, "http://localhost:9090/ack/event", s.SubStore.Store["1"].GetEndpointURI())
+		})
+	}
+}
+
+func TestUnwrap_Errors(t *testing.T) {
+	_, _, err := schema.Unwrap(schema.KindSubscriber, []byte(`{"version":99,"kind":"subscriber","data":{}}`))
+	assert.NotNil(t, err)
+	wrapped, err := schema.Wrap(schema.KindPubSubList, []byte(`[]`))
+	assert.Nil(t, err)
+	_, _, err = schema.Unwrap(schema.KindSubscriber, wrapped)
+	assert.NotNil(t, err)
+	_, _, err = schema.Unwrap(schema.KindPubSubList, []byte(`[{`))
+	assert.NotNil(t, err)
+	payload, _, err := schema.Unwrap(schema.KindPubSubList, []byte{})
+	assert.Nil(t, err)
+	assert.Empty(t, payload)
+}
+
+func TestRegistry_Migrations(t *testing.T) {
+	r := schema.NewRegistry(3)
+	rename := func(from, to string) schema.Migration {
+		return func(_ schema.Kind, doc interface{}) (interface{}, error) {
+			obj := doc.(map[string]interface{})
+			obj[to] = obj[from]
+			delete(obj, from)
+			return obj, nil
+		}
+	}
+	assert.Nil(t, r.Register(1, rename("a", "b")))
+	assert.NotNil(t, r.Register(1, rename("a", "b")))
+	assert.NotNil(t, r.Register(3, rename("a", "b")))
+	data := []byte(`{"version":1,"kind":"subscriber","data":{"a":1}}`)
+	// version 2 has no migration registered yet
+	_, _, err := r.Unwrap(schema.KindSubscriber, data)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, r.Register(2, rename("b", "c")))
+	payload, version, err := r.Unwrap(schema.KindSubscriber, data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, version)
+	assert.JSONEq(t, `{"c":1}`, string(payload))
+
+	wrapped, err := r.Wrap(schema.KindSubscriber, payload)
+	assert.Nil(t, err)
+	assert.Contains(t, string(wrapped), fmt.Sprintf(`"version": %d`, r.Current()))
+}
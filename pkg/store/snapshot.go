@@ -0,0 +1,143 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"maps"
+	"sort"
+	"sync/atomic"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/resource"
+)
+
+// Snapshot is an immutable point-in-time view of a PubSubStore. It is safe for concurrent use
+// and is not affected by later changes of the store.
+type Snapshot struct {
+	revision uint64
+	items    map[string]*pubsub.PubSub
+	// compiled holds the matchers of the resources of items, they are not modified once compiled
+	compiled map[string]*resource.Matcher
+}
+
+// Revision identifies the state of the store the snapshot was taken from, it increases with
+// every change made through Set, Delete and DeleteAll
+func (s *Snapshot) Revision() uint64 {
+	return s.revision
+}
+
+// Len returns the number of pub/subs in the snapshot
+func (s *Snapshot) Len() int {
+	return len(s.items)
+}
+
+// Get returns a copy of the pub/sub stored under key
+func (s *Snapshot) Get(key string) (pubsub.PubSub, bool) {
+	if v, ok := s.items[key]; ok {
+		return v.Copy(), true
+	}
+	return pubsub.PubSub{}, false
+}
+
+// GetByResource returns a copy of the pub/sub whose resource matches address, see
+// PubSubStore.GetByResource
+func (s *Snapshot) GetByResource(address string) (pubsub.PubSub, bool) {
+	return getByResource(s.items, s.compiled, address)
+}
+
+// Range calls fn with a copy of every pub/sub until fn returns false, in no particular order
+func (s *Snapshot) Range(fn func(key string, v pubsub.PubSub) bool) {
+	for key, v := range s.items {
+		if !fn(key, v.Copy()) {
+			return
+		}
+	}
+}
+
+// List returns copies of the pub/subs sorted by key
+func (s *Snapshot) List() []pubsub.PubSub {
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := make([]pubsub.PubSub, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, s.items[key].Copy())
+	}
+	return list
+}
+
+// Map returns copies of the pub/subs keyed by their store key, the caller owns the result
+func (s *Snapshot) Map() map[string]*pubsub.PubSub {
+	m := make(map[string]*pubsub.PubSub, len(s.items))
+	for key, v := range s.items {
+		c := v.Copy()
+		m[key] = &c
+	}
+	return m
+}
+
+// views holds the revision of a PubSubStore and the snapshot of that revision
+type views struct {
+	revision uint64
+	// current is nil until a snapshot of the revision is requested
+	current atomic.Pointer[Snapshot]
+}
+
+// Snapshot returns a consistent view of the store. The view is copied on the first call after
+// a change and shared by the following calls until the next change, readers never hold the store
+// lock while they use it. Revisions count the changes made since the first snapshot was taken.
+// Changes made to Store directly instead of through Set, Delete and DeleteAll are not seen by a
+// snapshot taken before them.
+func (ps *PubSubStore) Snapshot() *Snapshot {
+	v := ps.views.Load()
+	if v != nil {
+		if s := v.current.Load(); s != nil {
+			return s
+		}
+	}
+	ps.RLock()
+	defer ps.RUnlock()
+	if v == nil {
+		// another reader may have started tracking the snapshots meanwhile
+		ps.views.CompareAndSwap(nil, &views{})
+		v = ps.views.Load()
+	}
+	if s := v.current.Load(); s != nil {
+		return s
+	}
+	s := &Snapshot{revision: v.revision, items: make(map[string]*pubsub.PubSub, len(ps.Store))}
+	if m := ps.matchers.Load(); m != nil {
+		s.compiled = maps.Clone(m.compiled)
+	} else {
+		s.compiled = newMatchers(ps.Store).compiled
+	}
+	for key, item := range ps.Store {
+		c := item.Copy()
+		s.items[key] = &c
+	}
+	// writers hold the write lock, the snapshot can't be outdated before RUnlock
+	v.current.Store(s)
+	return s
+}
+
+// changed invalidates the current snapshot, callers must hold the write lock
+func (ps *PubSubStore) changed() {
+	if v := ps.views.Load(); v != nil {
+		v.revision++
+		v.current.Store(nil)
+	}
+}
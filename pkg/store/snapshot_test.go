@@ -0,0 +1,161 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSubStore_Snapshot(t *testing.T) {
+	ps := &store.PubSubStore{}
+	empty := ps.Snapshot()
+	assert.Equal(t, uint64(0), empty.Revision())
+	assert.Equal(t, 0, empty.Len())
+
+	ps.Set("2", pubsub.PubSub{ID: "2", Resource: "/cluster/node/*/sync"})
+	ps.Set("1", pubsub.PubSub{ID: "1", Resource: "/a"})
+	s := ps.Snapshot()
+	assert.Same(t, s, ps.Snapshot())
+	assert.Equal(t, uint64(2), s.Revision())
+	assert.Equal(t, []pubsub.PubSub{{ID: "1", Resource: "/a"}, {ID: "2", Resource: "/cluster/node/*/sync"}}, s.List())
+	found, ok := s.GetByResource("/cluster/node/n1/sync")
+	assert.True(t, ok)
+	assert.Equal(t, "2", found.ID)
+
+	// later changes and changes to the copies are not seen by the snapshot
+	ps.Delete("1")
+	ps.Set("2", pubsub.PubSub{ID: "2", Resource: "/b"})
+	m := s.Map()
+	m["2"].Resource = "/c"
+	delete(m, "1")
+	v, ok := s.Get("1")
+	assert.True(t, ok)
+	assert.Equal(t, "/a", v.Resource)
+	v, _ = s.Get("2")
+	assert.Equal(t, "/cluster/node/*/sync", v.Resource)
+	assert.Equal(t, 0, empty.Len())
+
+	next := ps.Snapshot()
+	assert.Equal(t, uint64(4), next.Revision())
+	assert.Equal(t, []pubsub.PubSub{{ID: "2", Resource: "/b"}}, next.List())
+	ps.DeleteAll()
+	assert.Equal(t, 0, ps.Snapshot().Len())
+	assert.Equal(t, 1, next.Len())
+}
+
+func TestPubSubStore_GetByResource(t *testing.T) {
+	ps := &store.PubSubStore{}
+	ps.Set("1", pubsub.PubSub{ID: "1", Resource: "/cluster/node/*/sync"})
+	ps.Set("2", pubsub.PubSub{ID: "2", Resource: "/cluster/node/*/sync", Namespace: "tenant"})
+	ps.Set("3", pubsub.PubSub{ID: "3", Resource: "/cluster/node/n1/sync"})
+	ps.Set("4", pubsub.PubSub{ID: "4", Resource: "/cluster/*/ptp-*"})
+	found, ok := ps.GetByResource("/cluster/node/n1/sync")
+	assert.True(t, ok)
+	assert.Equal(t, "3", found.ID)
+	_, ok = ps.GetByResource("/cluster/node/n2/sync")
+	assert.True(t, ok)
+	// invalid patterns are only matched exactly
+	_, ok = ps.GetByResource("/cluster/node/ptp-1")
+	assert.False(t, ok)
+	found, ok = ps.GetByResource("/cluster/*/ptp-*")
+	assert.True(t, ok)
+	assert.Equal(t, "4", found.ID)
+
+	// a pattern is matched until the last pub/sub using it is changed or deleted
+	ps.Delete("1")
+	_, ok = ps.GetByResource("/cluster/node/n2/sync")
+	assert.True(t, ok)
+	ps.Set("2", pubsub.PubSub{ID: "2", Resource: "/sync/**"})
+	_, ok = ps.GetByResource("/cluster/node/n2/sync")
+	assert.False(t, ok)
+	found, ok = ps.GetByResource("/sync/ptp-status/lock-state")
+	assert.True(t, ok)
+	assert.Equal(t, "2", found.ID)
+	found, ok = ps.Snapshot().GetByResource("/sync/ptp-status/lock-state")
+	assert.True(t, ok)
+	assert.Equal(t, "2", found.ID)
+	ps.DeleteAll()
+	_, ok = ps.GetByResource("/sync/ptp-status/lock-state")
+	assert.False(t, ok)
+
+	// pub/subs stored without Set are matched too
+	ps.Store["5"] = &pubsub.PubSub{ID: "5", Resource: "/cluster/**"}
+	found, ok = ps.GetByResource("/cluster/node/n1/sync")
+	assert.True(t, ok)
+	assert.Equal(t, "5", found.ID)
+}
+
+func TestPubSubStore_SnapshotURIs(t *testing.T) {
+	ps := &store.PubSubStore{}
+	ps.Set("1", pubsub.PubSub{ID: "1", Resource: "/a", EndPointURI: types.ParseURI("http://consumer:9090/event"),
+		URILocation: types.ParseURI("http://localhost:8089/subscriptions/1")})
+	s := ps.Snapshot()
+
+	// the URIs of the copies are not shared with the snapshot or the store
+	v, _ := s.Get("1")
+	v.EndPointURI.Host = "get"
+	found, _ := s.GetByResource("/a")
+	found.URILocation.Host = "resource"
+	s.List()[0].EndPointURI.Host = "list"
+	s.Map()["1"].URILocation.Host = "map"
+	s.Range(func(_ string, v pubsub.PubSub) bool {
+		v.EndPointURI.Host = "range"
+		return true
+	})
+	for _, v := range []pubsub.PubSub{*ps.Store["1"], *ps.Snapshot().Map()["1"]} {
+		assert.Equal(t, "http://consumer:9090/event", v.GetEndpointURI())
+		assert.Equal(t, "http://localhost:8089/subscriptions/1", v.GetURILocation())
+	}
+}
+
+func TestPubSubStore_SnapshotConcurrent(t *testing.T) {
+	ps := &store.PubSubStore{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprint(i % 10)
+			ps.Set(key, pubsub.PubSub{ID: key})
+			if i%3 == 0 {
+				ps.Delete(key)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var last uint64
+		for i := 0; i < 1000; i++ {
+			s := ps.Snapshot()
+			assert.GreaterOrEqual(t, s.Revision(), last)
+			last = s.Revision()
+			n := 0
+			s.Range(func(key string, v pubsub.PubSub) bool {
+				assert.Equal(t, key, v.ID)
+				n++
+				return true
+			})
+			assert.Equal(t, s.Len(), n)
+		}
+	}()
+	wg.Wait()
+}
@@ -15,9 +15,12 @@
 package store
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/resource"
 )
 
 // PubSubStore  defines pub/sub store struct
@@ -25,6 +28,50 @@ type PubSubStore struct {
 	sync.RWMutex
 	// PublisherStore stores publishers in a map
 	Store map[string]*pubsub.PubSub `json:"store" omit:"empty"`
+	// watchers receive the changes made through Set, Delete and DeleteAll
+	watchers Watchers[PubSubEvent]
+	// views tracks the snapshots, nil until the first one is requested
+	views atomic.Pointer[views]
+	// matchers holds the compiled resources of Store, nil until the first lookup by resource
+	// and then kept up to date by Set, Delete and DeleteAll
+	matchers atomic.Pointer[matchers]
+}
+
+// matchers holds the resource patterns of a store compiled once, with the number of pub/subs
+// using each of them. Resources without wildcard and invalid patterns have a nil matcher.
+type matchers struct {
+	compiled map[string]*resource.Matcher
+	refs     map[string]int
+}
+
+// newMatchers compiles the resources of items
+func newMatchers(items map[string]*pubsub.PubSub) *matchers {
+	m := &matchers{compiled: map[string]*resource.Matcher{}, refs: map[string]int{}}
+	for _, s := range items {
+		m.add(s.Resource)
+	}
+	return m
+}
+
+func (m *matchers) add(pattern string) {
+	if m.refs[pattern] == 0 {
+		var compiled *resource.Matcher
+		if resource.HasWildcard(pattern) {
+			// invalid patterns are only matched exactly
+			compiled, _ = resource.Compile(pattern)
+		}
+		m.compiled[pattern] = compiled
+	}
+	m.refs[pattern]++
+}
+
+func (m *matchers) remove(pattern string) {
+	if m.refs[pattern] <= 1 {
+		delete(m.refs, pattern)
+		delete(m.compiled, pattern)
+		return
+	}
+	m.refs[pattern]--
 }
 
 // Get ...
@@ -37,6 +84,46 @@ func (ps *PubSubStore) Get(subID string) pubsub.PubSub {
 	return pubsub.PubSub{}
 }
 
+// GetByResource returns the pub/sub whose resource matches address, an exact match is
+// preferred over a wildcard pattern such as /cluster/node/*/sync or /sync/ptp-status/**
+func (ps *PubSubStore) GetByResource(address string) (pubsub.PubSub, bool) {
+	ps.RLock()
+	defer ps.RUnlock()
+	m := ps.matchers.Load()
+	if m == nil {
+		// writers hold the write lock, another reader may only store the same matchers
+		ps.matchers.CompareAndSwap(nil, newMatchers(ps.Store))
+		m = ps.matchers.Load()
+	}
+	return getByResource(ps.Store, m.compiled, address)
+}
+
+// getByResource looks address up in items, compiled holds the matchers of their resources
+func getByResource(items map[string]*pubsub.PubSub, compiled map[string]*resource.Matcher, address string) (pubsub.PubSub, bool) {
+	var found *pubsub.PubSub
+	for _, s := range items {
+		if s.GetResource() == address {
+			return s.Copy(), true
+		}
+		if found == nil && match(compiled, s.GetResource(), address) {
+			found = s
+		}
+	}
+	if found != nil {
+		return found.Copy(), true
+	}
+	return pubsub.PubSub{}, false
+}
+
+// match reports whether pattern matches address, patterns missing from compiled were stored
+// without Set and are compiled again
+func match(compiled map[string]*resource.Matcher, pattern, address string) bool {
+	if m, ok := compiled[pattern]; ok {
+		return m != nil && m.Match(address)
+	}
+	return resource.Match(pattern, address)
+}
+
 // Set is a wrapper for setting the value of a key in the underlying map
 func (ps *PubSubStore) Set(key string, val pubsub.PubSub) {
 	ps.Lock()
@@ -46,16 +133,57 @@ func (ps *PubSubStore) Set(key string, val pubsub.PubSub) {
 		EndPointURI: val.EndPointURI,
 		URILocation: val.URILocation,
 		Resource:    val.Resource,
+		CreatedAt:   val.CreatedAt,
+		Revision:    val.Revision,
+		Namespace:   val.Namespace,
 	}
 	if ps.Store == nil {
 		ps.Store = make(map[string]*pubsub.PubSub)
 	}
+	eventType := Added
+	m := ps.matchers.Load()
+	if prev, ok := ps.Store[key]; ok {
+		eventType = Updated
+		if m != nil {
+			m.remove(prev.Resource)
+		}
+	}
 	ps.Store[key] = storeSub
+	if m != nil {
+		m.add(storeSub.Resource)
+	}
+	ps.changed()
+	ps.watchers.Notify(PubSubEvent{Type: eventType, Key: key, PubSub: *storeSub}, PubSubEvent{Type: Resync})
 }
 
 // Delete ... delete from store
 func (ps *PubSubStore) Delete(key string) {
 	ps.Lock()
 	defer ps.Unlock()
-	delete(ps.Store, key)
+	if s, ok := ps.Store[key]; ok {
+		delete(ps.Store, key)
+		if m := ps.matchers.Load(); m != nil {
+			m.remove(s.Resource)
+		}
+		ps.changed()
+		ps.watchers.Notify(PubSubEvent{Type: Deleted, Key: key, PubSub: *s}, PubSubEvent{Type: Resync})
+	}
+}
+
+// DeleteAll ... empty the store
+func (ps *PubSubStore) DeleteAll() {
+	ps.Lock()
+	defer ps.Unlock()
+	for key, s := range ps.Store {
+		ps.watchers.Notify(PubSubEvent{Type: Deleted, Key: key, PubSub: *s}, PubSubEvent{Type: Resync})
+	}
+	ps.Store = make(map[string]*pubsub.PubSub)
+	ps.matchers.Store(nil)
+	ps.changed()
+}
+
+// Watch streams the changes made to the store until ctx is cancelled. A watcher that falls
+// behind by more than WatchBufferSize events receives a Resync event and should list the store again.
+func (ps *PubSubStore) Watch(ctx context.Context) <-chan PubSubEvent {
+	return ps.watchers.Add(ctx)
 }
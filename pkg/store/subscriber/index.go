@@ -0,0 +1,150 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/redhat-cne/sdk-go/pkg/resource"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+// index maps resource addresses and subscription ids to the clients subscribed to them.
+// Wildcard resources are compiled once and kept apart, so a lookup costs one map access plus
+// one match per distinct pattern. The subscription store of a client is shared with callers,
+// so the keys indexed for each client are remembered to be able to remove them once the
+// subscriptions changed.
+type index struct {
+	byResource map[string]map[uuid.UUID]struct{}
+	bySubID    map[string]map[uuid.UUID]struct{}
+	patterns   map[string]*resource.Matcher
+	keys       map[uuid.UUID]indexKeys
+}
+
+// indexKeys are the keys a client is currently indexed under
+type indexKeys struct {
+	resources []string
+	subIDs    []string
+}
+
+// reindex replaces the entries of clientID with the subscriptions of s, a nil s removes them
+func (idx *index) reindex(clientID uuid.UUID, s *subscriber.Subscriber) {
+	if idx.keys == nil {
+		idx.byResource = map[string]map[uuid.UUID]struct{}{}
+		idx.bySubID = map[string]map[uuid.UUID]struct{}{}
+		idx.patterns = map[string]*resource.Matcher{}
+		idx.keys = map[uuid.UUID]indexKeys{}
+	}
+	if old, ok := idx.keys[clientID]; ok {
+		for _, r := range old.resources {
+			remove(idx.byResource, r, clientID)
+			if _, ok := idx.byResource[r]; !ok {
+				delete(idx.patterns, r)
+			}
+		}
+		for _, id := range old.subIDs {
+			remove(idx.bySubID, id, clientID)
+		}
+		delete(idx.keys, clientID)
+	}
+	if s == nil || s.SubStore == nil {
+		return
+	}
+	var keys indexKeys
+	s.SubStore.RLock()
+	for _, sub := range s.SubStore.Store {
+		keys.resources = append(keys.resources, sub.GetResource())
+		keys.subIDs = append(keys.subIDs, sub.GetID())
+	}
+	s.SubStore.RUnlock()
+	for _, r := range keys.resources {
+		add(idx.byResource, r, clientID)
+		if _, ok := idx.patterns[r]; !ok && resource.HasWildcard(r) {
+			// invalid patterns are only matched exactly
+			if m, err := resource.Compile(r); err == nil {
+				idx.patterns[r] = m
+			}
+		}
+	}
+	for _, id := range keys.subIDs {
+		add(idx.bySubID, id, clientID)
+	}
+	idx.keys[clientID] = keys
+}
+
+func add(m map[string]map[uuid.UUID]struct{}, key string, clientID uuid.UUID) {
+	clients, ok := m[key]
+	if !ok {
+		clients = map[uuid.UUID]struct{}{}
+		m[key] = clients
+	}
+	clients[clientID] = struct{}{}
+}
+
+func remove(m map[string]map[uuid.UUID]struct{}, key string, clientID uuid.UUID) {
+	if clients, ok := m[key]; ok {
+		delete(clients, clientID)
+		if len(clients) == 0 {
+			delete(m, key)
+		}
+	}
+}
+
+// ByResource returns the clients with a subscription matching address, either exactly or
+// through a wildcard pattern
+func (ss *Store) ByResource(address string) []subscriber.Subscriber {
+	ss.RLock()
+	defer ss.RUnlock()
+	clientIDs := ss.index.byResource[address]
+	var merged map[uuid.UUID]struct{}
+	for pattern, m := range ss.index.patterns {
+		if pattern == address || !m.Match(address) {
+			continue
+		}
+		if merged == nil {
+			merged = make(map[uuid.UUID]struct{}, len(clientIDs))
+			for clientID := range clientIDs {
+				merged[clientID] = struct{}{}
+			}
+		}
+		for clientID := range ss.index.byResource[pattern] {
+			merged[clientID] = struct{}{}
+		}
+	}
+	if merged != nil {
+		return ss.lookup(merged)
+	}
+	return ss.lookup(clientIDs)
+}
+
+// BySubID returns the clients holding the subscription subID
+func (ss *Store) BySubID(subID string) []subscriber.Subscriber {
+	ss.RLock()
+	defer ss.RUnlock()
+	return ss.lookup(ss.index.bySubID[subID])
+}
+
+func (ss *Store) lookup(clientIDs map[uuid.UUID]struct{}) []subscriber.Subscriber {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	clients := make([]subscriber.Subscriber, 0, len(clientIDs))
+	for clientID := range clientIDs {
+		if s, ok := ss.Store[clientID]; ok {
+			clients = append(clients, *s)
+		}
+	}
+	return clients
+}
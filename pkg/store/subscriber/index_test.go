@@ -0,0 +1,73 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	SubscriberStore "github.com/redhat-cne/sdk-go/pkg/store/subscriber"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(clientID uuid.UUID, subs ...pubsub.PubSub) subscriber.Subscriber {
+	s := subscriber.New(clientID)
+	s.AddSubscription(subs...)
+	return *s
+}
+
+func TestStore_Index(t *testing.T) {
+	ss := &SubscriberStore.Store{Store: map[uuid.UUID]*subscriber.Subscriber{}}
+	one, two := uuid.New(), uuid.New()
+	ss.Set(one, newClient(one, pubsub.PubSub{ID: "1", Resource: "/a"}, pubsub.PubSub{ID: "2", Resource: "/b"}))
+	ss.Set(two, newClient(two, pubsub.PubSub{ID: "3", Resource: "/a"}))
+
+	assert.Len(t, ss.ByResource("/a"), 2)
+	assert.Len(t, ss.ByResource("/b"), 1)
+	assert.Empty(t, ss.ByResource("/c"))
+	assert.Equal(t, one, ss.BySubID("2")[0].ClientID)
+
+	// subscriptions removed from the shared subscription store are unindexed on Set
+	client, _ := ss.Get(one)
+	client.SubStore.Delete("2")
+	ss.Set(one, client)
+	assert.Empty(t, ss.ByResource("/b"))
+	assert.Empty(t, ss.BySubID("2"))
+
+	ss.Delete(two)
+	assert.Len(t, ss.ByResource("/a"), 1)
+	assert.Empty(t, ss.BySubID("3"))
+}
+
+func benchmarkByResource(b *testing.B, clients int) {
+	ss := &SubscriberStore.Store{Store: map[uuid.UUID]*subscriber.Subscriber{}}
+	for i := 0; i < clients; i++ {
+		clientID := uuid.New()
+		ss.Set(clientID, newClient(clientID,
+			pubsub.PubSub{ID: uuid.NewString(), Resource: fmt.Sprintf("/cluster/node/%d/sync/ptp-status/lock-state", i)},
+			pubsub.PubSub{ID: uuid.NewString(), Resource: "/cluster/node/shared/sync/sync-status/sync-state"}))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ss.ByResource("/cluster/node/1/sync/ptp-status/lock-state")
+	}
+}
+
+func BenchmarkStore_ByResource10(b *testing.B)    { benchmarkByResource(b, 10) }
+func BenchmarkStore_ByResource1000(b *testing.B)  { benchmarkByResource(b, 1000) }
+func BenchmarkStore_ByResource10000(b *testing.B) { benchmarkByResource(b, 10000) }
@@ -0,0 +1,54 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+)
+
+// InNamespace returns the ids of the clients of namespace
+func (ss *Store) InNamespace(namespace string) []uuid.UUID {
+	namespace = pubsub.Namespace(namespace)
+	ss.RLock()
+	defer ss.RUnlock()
+	var clientIDs []uuid.UUID
+	for clientID, s := range ss.Store {
+		if s.GetNamespace() == namespace {
+			clientIDs = append(clientIDs, clientID)
+		}
+	}
+	return clientIDs
+}
+
+// CountNamespace returns the number of clients of namespace and of their subscriptions
+func (ss *Store) CountNamespace(namespace string) (clients, subscriptions int) {
+	namespace = pubsub.Namespace(namespace)
+	ss.RLock()
+	defer ss.RUnlock()
+	for _, s := range ss.Store {
+		if s.GetNamespace() != namespace {
+			continue
+		}
+		clients++
+		if s.SubStore != nil {
+			s.SubStore.RLock()
+			subscriptions += len(s.SubStore.Store)
+			s.SubStore.RUnlock()
+		}
+	}
+	return
+}
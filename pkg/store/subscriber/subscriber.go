@@ -1,25 +1,49 @@
 package subscriber
 
 import (
+	"context"
 	"sync"
 
 	"github.com/google/uuid"
 
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
 	"github.com/redhat-cne/sdk-go/pkg/subscriber"
 )
 
-// Store  defines subscribers connection store struct
+// Event is a change of the subscriber Store
+type Event struct {
+	Type store.EventType
+	// ClientID of the subscriber, uuid.Nil for store.Resync
+	ClientID uuid.UUID
+	// Subscriber is a copy of the new value, or of the removed value for store.Deleted
+	Subscriber subscriber.Subscriber
+}
+
+// Store  defines subscribers connection store struct.
+// Changes to the subscriptions of a client are picked up by the watchers and the resource and
+// subscription id indexes when the client is stored again with Set.
 type Store struct {
 	sync.RWMutex
 	// Store stores subscribers in a map
 	Store map[uuid.UUID]*subscriber.Subscriber
+	// watchers receive the changes made through Set and Delete
+	watchers store.Watchers[Event]
+	// index of the clients by resource address and subscription id
+	index index
 }
 
 // Set is a wrapper for setting the value of a key in the underlying map
 func (ss *Store) Set(clientID uuid.UUID, val subscriber.Subscriber) {
 	ss.Lock()
 	defer ss.Unlock()
+	eventType := store.Added
+	if _, ok := ss.Store[clientID]; ok {
+		eventType = store.Updated
+	}
 	ss.Store[clientID] = &val
+	ss.index.reindex(clientID, &val)
+	ss.notify(eventType, clientID, &val)
 }
 
 // Get is a wrapper for Getting the value of a key in the underlying map
@@ -36,5 +60,35 @@ func (ss *Store) Get(clientID uuid.UUID) (subscriber.Subscriber, bool) {
 func (ss *Store) Delete(clientID uuid.UUID) {
 	ss.Lock()
 	defer ss.Unlock()
-	delete(ss.Store, clientID)
+	if s, ok := ss.Store[clientID]; ok {
+		delete(ss.Store, clientID)
+		ss.index.reindex(clientID, nil)
+		ss.notify(store.Deleted, clientID, s)
+	}
+}
+
+// Watch streams the changes made to the store until ctx is cancelled. A watcher that falls
+// behind by more than store.WatchBufferSize events receives a store.Resync event and should
+// list the store again.
+func (ss *Store) Watch(ctx context.Context) <-chan Event {
+	return ss.watchers.Add(ctx)
+}
+
+// notify sends a copy of s to the watchers, the subscriptions are copied since the
+// subscription store is shared with the caller
+func (ss *Store) notify(eventType store.EventType, clientID uuid.UUID, s *subscriber.Subscriber) {
+	if ss.watchers.Len() == 0 {
+		return
+	}
+	c := *s
+	if s.SubStore != nil {
+		c.SubStore = &store.PubSubStore{Store: map[string]*pubsub.PubSub{}}
+		s.SubStore.RLock()
+		for k, v := range s.SubStore.Store {
+			sub := *v
+			c.SubStore.Store[k] = &sub
+		}
+		s.SubStore.RUnlock()
+	}
+	ss.watchers.Notify(Event{Type: eventType, ClientID: clientID, Subscriber: c}, Event{Type: store.Resync})
 }
@@ -0,0 +1,110 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+)
+
+// WatchBufferSize is the number of events buffered for each watcher before it is resynced
+const WatchBufferSize = 100
+
+// EventType is the kind of change reported by Watch
+type EventType int
+
+const (
+	// Added an entry was added to the store
+	Added EventType = iota
+	// Updated an existing entry was replaced
+	Updated
+	// Deleted an entry was removed from the store
+	Deleted
+	// Resync the watcher fell behind and events were dropped; the store must be listed again
+	Resync
+)
+
+// String represent of EventType enum
+func (e EventType) String() string {
+	return [...]string{"ADDED", "UPDATED", "DELETED", "RESYNC"}[e]
+}
+
+// PubSubEvent is a change of a PubSubStore
+type PubSubEvent struct {
+	Type EventType
+	// Key of the entry, empty for Resync
+	Key string
+	// PubSub is a copy of the new value, or of the removed value for Deleted
+	PubSub pubsub.PubSub
+}
+
+// Watchers fans out store events to every active Watch caller. Notify never blocks: when a
+// watcher buffer is full its pending events are discarded and replaced by a single resync
+// event, after which the watcher receives new events again. The zero value is ready to use.
+type Watchers[E any] struct {
+	mu    sync.Mutex
+	chans map[chan E]struct{}
+}
+
+// Add registers a watcher which is removed and closed once ctx is done
+func (w *Watchers[E]) Add(ctx context.Context) <-chan E {
+	ch := make(chan E, WatchBufferSize)
+	w.mu.Lock()
+	if w.chans == nil {
+		w.chans = make(map[chan E]struct{})
+	}
+	w.chans[ch] = struct{}{}
+	w.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		w.mu.Lock()
+		delete(w.chans, ch)
+		close(ch)
+		w.mu.Unlock()
+	}()
+	return ch
+}
+
+// Notify sends e to every watcher, watchers that are not keeping up receive resync instead
+func (w *Watchers[E]) Notify(e, resync E) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.chans {
+		select {
+		case ch <- e:
+			continue
+		default:
+		}
+	drain:
+		for {
+			select {
+			case <-ch:
+			default:
+				break drain
+			}
+		}
+		// only Notify sends on ch and it holds mu, so the drained buffer has room
+		ch <- resync
+	}
+}
+
+// Len returns the number of active watchers
+func (w *Watchers[E]) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.chans)
+}
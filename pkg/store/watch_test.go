@@ -0,0 +1,67 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSubStore_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ps := &store.PubSubStore{}
+	events := ps.Watch(ctx)
+
+	ps.Set("1", pubsub.PubSub{ID: "1", Resource: "/a"})
+	ps.Set("1", pubsub.PubSub{ID: "1", Resource: "/b"})
+	ps.Delete("1")
+	ps.Delete("1")
+	ps.Set("2", pubsub.PubSub{ID: "2", Resource: "/c"})
+	ps.DeleteAll()
+
+	expected := []store.PubSubEvent{
+		{Type: store.Added, Key: "1", PubSub: pubsub.PubSub{ID: "1", Resource: "/a"}},
+		{Type: store.Updated, Key: "1", PubSub: pubsub.PubSub{ID: "1", Resource: "/b"}},
+		{Type: store.Deleted, Key: "1", PubSub: pubsub.PubSub{ID: "1", Resource: "/b"}},
+		{Type: store.Added, Key: "2", PubSub: pubsub.PubSub{ID: "2", Resource: "/c"}},
+		{Type: store.Deleted, Key: "2", PubSub: pubsub.PubSub{ID: "2", Resource: "/c"}},
+	}
+	for _, e := range expected {
+		assert.Equal(t, e, <-events)
+	}
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestPubSubStore_WatchResync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ps := &store.PubSubStore{}
+	events := ps.Watch(ctx)
+	for i := 0; i <= store.WatchBufferSize; i++ {
+		ps.Set("1", pubsub.PubSub{ID: "1"})
+	}
+	// the overflow replaced the buffered events with a resync
+	assert.Equal(t, store.Resync, (<-events).Type)
+	ps.Delete("1")
+	e := <-events
+	assert.Equal(t, store.Deleted, e.Type)
+	assert.Equal(t, "1", e.Key)
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"time"
+)
+
+// MaxDeliveryHistory is the number of deliveries kept per subscriber to evaluate a FailurePolicy
+const MaxDeliveryHistory = 128
+
+// Delivery is the outcome of an attempt to deliver an event to a subscriber
+type Delivery struct {
+	Time   time.Time
+	Failed bool
+}
+
+// FailurePolicy decides when a subscriber failed too often to keep delivering events to it
+type FailurePolicy interface {
+	// Exceeded is called after a failed delivery at now with the health of the subscriber and
+	// its last deliveries, oldest first. It reports whether the subscriber must be quarantined
+	// or deleted.
+	Exceeded(h Health, deliveries []Delivery, now time.Time) bool
+}
+
+// ConsecutiveFailures is exceeded once the given number of deliveries failed in a row
+type ConsecutiveFailures int
+
+// Exceeded implements FailurePolicy
+func (n ConsecutiveFailures) Exceeded(h Health, _ []Delivery, _ time.Time) bool {
+	return h.Failures >= int(n)
+}
+
+// FailureRate is exceeded once the share of failed deliveries within Window reaches MaxRate.
+// The rate is computed over the last MaxDeliveryHistory deliveries at most.
+type FailureRate struct {
+	Window time.Duration
+	// MinDeliveries within the window before the rate is evaluated
+	MinDeliveries int
+	// MaxRate of failed deliveries between 0 and 1
+	MaxRate float64
+}
+
+// Exceeded implements FailurePolicy
+func (r FailureRate) Exceeded(_ Health, deliveries []Delivery, now time.Time) bool {
+	since := now.Add(-r.Window)
+	var total, failed int
+	for _, d := range deliveries {
+		if d.Time.Before(since) {
+			continue
+		}
+		total++
+		if d.Failed {
+			failed++
+		}
+	}
+	if total == 0 || total < r.MinDeliveries {
+		return false
+	}
+	return float64(failed)/float64(total) >= r.MaxRate
+}
+
+// NoSuccessWithin is exceeded once no delivery succeeded for the given duration. A subscriber
+// which never received an event is measured from its oldest known delivery.
+type NoSuccessWithin time.Duration
+
+// Exceeded implements FailurePolicy
+func (d NoSuccessWithin) Exceeded(h Health, deliveries []Delivery, now time.Time) bool {
+	since := h.LastSuccess
+	if since == nil {
+		if len(deliveries) == 0 {
+			return false
+		}
+		since = &deliveries[0].Time
+	}
+	return now.Sub(*since) >= time.Duration(d)
+}
+
+type anyOf []FailurePolicy
+
+// AnyOf is exceeded as soon as one of the policies is exceeded
+func AnyOf(policies ...FailurePolicy) FailurePolicy {
+	return anyOf(policies)
+}
+
+// Exceeded implements FailurePolicy
+func (a anyOf) Exceeded(h Health, deliveries []Delivery, now time.Time) bool {
+	for _, p := range a {
+		if p.Exceeded(h, deliveries, now) {
+			return true
+		}
+	}
+	return false
+}
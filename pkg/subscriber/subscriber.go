@@ -17,6 +17,7 @@ package subscriber
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -38,6 +39,8 @@ const (
 	InActive Status = iota
 	// Active Client
 	Active
+	// Quarantined client, events are not delivered until its quarantine ends
+	Quarantined
 )
 
 // Subscriber object holds client connections
@@ -54,8 +57,32 @@ type Subscriber struct {
 	Status Status `json:"status" omit:"empty"`
 	// Action ...
 	Action channel.Status `json:"action" omit:"empty"`
-	// FailedCount ...
-	failedCount int
+	// LeaseTTL - optional lifetime of the subscriber, a subscriber without a lease never expires.
+	LeaseTTL time.Duration `json:"leaseTTL,omitempty"`
+	// ExpiresAt - time at which the lease expires unless it is renewed.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Revision - Incremented on every change of the subscriber, used for conditional updates.
+	Revision uint64 `json:"revision,omitempty"`
+	// Namespace - The tenant owning the subscriber and its subscriptions, empty for pubsub.DefaultNamespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Failures - Number of consecutive failed deliveries to the subscriber.
+	Failures int `json:"failures,omitempty"`
+	// LastSuccess - time of the last successful delivery.
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// LastFailure - time of the last failed delivery.
+	LastFailure *time.Time `json:"lastFailure,omitempty"`
+	// QuarantinedUntil - end of the quarantine of a Quarantined subscriber.
+	QuarantinedUntil *time.Time `json:"quarantinedUntil,omitempty"`
+}
+
+// Health is the delivery state of a subscriber
+type Health struct {
+	Status           Status         `json:"status"`
+	Action           channel.Status `json:"action,omitempty"`
+	Failures         int            `json:"failures,omitempty"`
+	LastSuccess      *time.Time     `json:"lastSuccess,omitempty"`
+	LastFailure      *time.Time     `json:"lastFailure,omitempty"`
+	QuarantinedUntil *time.Time     `json:"quarantinedUntil,omitempty"`
 }
 
 // Get ... get pubsub
@@ -65,20 +92,49 @@ func (s *Subscriber) Get(subID string) pubsub.PubSub {
 
 // IncFailCount ...
 func (s *Subscriber) IncFailCount() {
-	s.failedCount++
-	if s.failedCount >= SetConnectionToFailAfter {
-		s.Action = channel.DELETE
-		s.Status = InActive
+	s.Failures++
+	if s.Failures >= SetConnectionToFailAfter {
+		s.MarkForDelete()
 	}
 }
 
+// MarkForDelete deactivates the subscriber until it is deleted
+func (s *Subscriber) MarkForDelete() {
+	s.Action = channel.DELETE
+	s.Status = InActive
+	s.QuarantinedUntil = nil
+}
+
+// Quarantine stops the deliveries to the subscriber until the given time
+func (s *Subscriber) Quarantine(until time.Time) {
+	s.Status = Quarantined
+	s.QuarantinedUntil = &until
+}
+
 // ResetFailCount ...
 func (s *Subscriber) ResetFailCount() {
-	s.failedCount = 0
+	s.Failures = 0
 }
 
 func (s *Subscriber) FailedCount() int {
-	return s.failedCount
+	return s.Failures
+}
+
+// RecordFailure counts a failed delivery at now, what happens to the subscriber is decided by
+// a FailurePolicy
+func (s *Subscriber) RecordFailure(now time.Time) {
+	s.LastFailure = &now
+	s.Failures++
+}
+
+// RecordSuccess resets the fail count after a successful delivery at now and ends a quarantine
+func (s *Subscriber) RecordSuccess(now time.Time) {
+	s.LastSuccess = &now
+	s.ResetFailCount()
+	if s.Status == Quarantined {
+		s.Status = Active
+		s.QuarantinedUntil = nil
+	}
 }
 
 // String returns a pretty-printed representation of the Event.
@@ -1,6 +1,8 @@
 package subscriber
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
 	"github.com/redhat-cne/sdk-go/pkg/store"
@@ -32,6 +34,18 @@ type Reader interface {
 	GetSubStore() *store.PubSubStore
 	// GetEndPointURI EndPointURI return   endpoint
 	GetEndPointURI() string
+	// GetLeaseTTL returns the lease of the subscriber
+	GetLeaseTTL() time.Duration
+	// IsExpired returns true if the lease expired
+	IsExpired(now time.Time) bool
+	// GetRevision returns the revision
+	GetRevision() uint64
+	// GetNamespace returns the namespace
+	GetNamespace() string
+	// GetHealth returns the delivery state
+	GetHealth() Health
+	// IsQuarantined returns true if the subscriber is quarantined
+	IsQuarantined(now time.Time) bool
 }
 
 // Writer is the interface for writing through an event onto attributes.
@@ -48,4 +62,17 @@ type Writer interface {
 	SetEndPointURI(url string) error
 
 	AddSubscription(sub ...pubsub.PubSub)
+
+	// SetLeaseTTL set the lease of the subscriber
+	SetLeaseTTL(ttl time.Duration)
+
+	// RenewLease extends the lease from now
+	RenewLease(now time.Time)
+
+	// SetRevision set the revision
+	SetRevision(revision uint64)
+	// SetNamespace set the namespace
+	SetNamespace(namespace string)
+	// SetHealth restores the delivery state
+	SetHealth(h Health)
 }
@@ -1,9 +1,12 @@
 package subscriber
 
 import (
+	"time"
+
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/google/uuid"
 	"github.com/redhat-cne/sdk-go/pkg/channel"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
 	"github.com/redhat-cne/sdk-go/pkg/store"
 )
 
@@ -24,6 +27,37 @@ func (s *Subscriber) GetStatus() Status {
 	return s.Status
 }
 
+// GetLeaseTTL returns the lease of the subscriber, zero if it never expires
+func (s *Subscriber) GetLeaseTTL() time.Duration {
+	return s.LeaseTTL
+}
+
+// IsExpired returns true if the lease of the subscriber expired at now
+func (s *Subscriber) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// GetRevision returns the revision, zero if the subscriber was never stored
+func (s *Subscriber) GetRevision() uint64 {
+	return s.Revision
+}
+
+// GetNamespace returns the namespace, pubsub.DefaultNamespace if none was set
+func (s *Subscriber) GetNamespace() string {
+	return pubsub.Namespace(s.Namespace)
+}
+
+// GetHealth returns the delivery state of the subscriber
+func (s *Subscriber) GetHealth() Health {
+	return Health{Status: s.Status, Action: s.Action, Failures: s.Failures, LastSuccess: s.LastSuccess, LastFailure: s.LastFailure,
+		QuarantinedUntil: s.QuarantinedUntil}
+}
+
+// IsQuarantined returns true if the subscriber is quarantined at now
+func (s *Subscriber) IsQuarantined(now time.Time) bool {
+	return s.Status == Quarantined && s.QuarantinedUntil != nil && now.Before(*s.QuarantinedUntil)
+}
+
 // GetSubStore get subscription store
 func (s *Subscriber) GetSubStore() *store.PubSubStore {
 	return s.SubStore
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -39,6 +40,43 @@ func (s *Subscriber) SetStatus(status Status) {
 	s.Status = status
 }
 
+// SetLeaseTTL set the lease of the subscriber, a zero ttl removes the lease
+func (s *Subscriber) SetLeaseTTL(ttl time.Duration) {
+	s.LeaseTTL = ttl
+	if ttl <= 0 {
+		s.LeaseTTL = 0
+		s.ExpiresAt = nil
+	}
+}
+
+// RenewLease extends the lease to now + LeaseTTL, it does nothing for a subscriber without a lease
+func (s *Subscriber) RenewLease(now time.Time) {
+	if s.LeaseTTL <= 0 {
+		return
+	}
+	expiresAt := now.Add(s.LeaseTTL)
+	s.ExpiresAt = &expiresAt
+}
+
+// SetRevision set the revision of the subscriber
+func (s *Subscriber) SetRevision(revision uint64) {
+	s.Revision = revision
+}
+
+// SetNamespace set the namespace of the subscriber
+func (s *Subscriber) SetNamespace(namespace string) {
+	if namespace = pubsub.Namespace(namespace); namespace == pubsub.DefaultNamespace {
+		namespace = ""
+	}
+	s.Namespace = namespace
+}
+
+// SetHealth restores the delivery state of the subscriber
+func (s *Subscriber) SetHealth(h Health) {
+	s.Status, s.Action, s.Failures = h.Status, h.Action, h.Failures
+	s.LastSuccess, s.LastFailure, s.QuarantinedUntil = h.LastSuccess, h.LastFailure, h.QuarantinedUntil
+}
+
 // AddSubscription ...
 func (s *Subscriber) AddSubscription(subs ...pubsub.PubSub) {
 	for _, ss := range subs {
@@ -87,6 +87,16 @@ func (u URI) Validate() bool {
 	return u.IsAbs()
 }
 
+// Copy returns a copy of u that can be changed without changing u, nil if u is nil
+func (u *URI) Copy() *URI {
+	if u == nil {
+		return nil
+	}
+	// url.Userinfo is immutable and can be shared
+	c := *u
+	return &c
+}
+
 // String returns the full string representation of the URI-Reference.
 func (u *URI) String() string {
 	if u == nil {
@@ -23,6 +23,20 @@ import (
 	"github.com/redhat-cne/sdk-go/pkg/types"
 )
 
+func TestURI_Copy(t *testing.T) {
+	u := types.ParseURI("http://user@localhost:9090/event")
+	c := u.Copy()
+	c.Host = "changed"
+	c.Path = "/changed"
+	if got := u.String(); got != "http://user@localhost:9090/event" {
+		t.Errorf("unexpected uri %s", got)
+	}
+	var empty *types.URI
+	if empty.Copy() != nil {
+		t.Errorf("expected nil copy")
+	}
+}
+
 func TestParseURL(t *testing.T) {
 	testCases := map[string]struct {
 		t    string
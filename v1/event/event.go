@@ -15,13 +15,9 @@
 package event
 
 import (
-	"encoding/json"
-	"fmt"
-
 	log "github.com/sirupsen/logrus"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
-	"github.com/google/uuid"
 	"github.com/redhat-cne/sdk-go/pkg/channel"
 	"github.com/redhat-cne/sdk-go/pkg/event"
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
@@ -83,40 +79,14 @@ func SendCloudEventsToDataChannel(inChan chan<- *channel.DataChan, status channe
 	}
 }
 
-// CreateCloudEvents create new cloud event from cloud native events and pubsub
+// CreateCloudEvents create new cloud event from cloud native events and pubsub, see event.Event.NewCloudEvent
 func CreateCloudEvents(e event.Event, ps pubsub.PubSub) (*cloudevents.Event, error) {
-	ce := cloudevents.NewEvent(cloudevents.VersionV03)
-	ce.SetTime(e.GetTime())
-	ce.SetType(e.Type)
-	ce.SetDataContentType(cloudevents.ApplicationJSON)
-	ce.SetSubject(e.Source)   // subject is set to source of the event object
-	ce.SetSource(ps.Resource) // bus address
-	ce.SetSpecVersion(cloudevents.VersionV03)
-	ce.SetID(uuid.New().String())
-	if err := ce.SetData(cloudevents.ApplicationJSON, e.GetData()); err != nil {
-		return nil, err
-	}
-	return &ce, nil
+	return e.NewCloudEvent(&ps)
 }
 
-// GetCloudNativeEvents  get event data from cloud events object if its valid else return error
+// GetCloudNativeEvents  get event data from cloud events object if its valid else return error,
+// see event.Event.GetCloudNativeEvents
 func GetCloudNativeEvents(ce cloudevents.Event) (e event.Event, err error) {
-	if ce.Data() == nil {
-		return e, fmt.Errorf("event data is empty")
-	}
-	data := event.Data{}
-	if err = json.Unmarshal(ce.Data(), &data); err != nil {
-		return
-	}
-	e.SetDataContentType(event.ApplicationJSON)
-	e.SetTime(ce.Time())
-	e.SetType(ce.Type())
-	if ce.Subject() != "" {
-		e.SetSource(ce.Subject())
-	} else {
-		e.SetSource(ce.Source())
-	}
-	e.SetID(ce.ID())
-	e.SetData(data)
+	err = e.GetCloudNativeEvents(&ce)
 	return
 }
@@ -0,0 +1,192 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+)
+
+var _ archive.Part = (*API)(nil)
+
+// Export writes the publishers and subscriptions to w as a versioned archive
+func (p *API) Export(w io.Writer) error {
+	a := &archive.Archive{CreatedAt: p.clock.Now().UTC()}
+	if err := p.ExportTo(a); err != nil {
+		return err
+	}
+	return archive.Encode(w, a)
+}
+
+// Import reads an archive written by Export or archive.Export and applies its publishers and
+// subscriptions. Records that could not be imported are listed in the report conflicts.
+func (p *API) Import(r io.Reader, mode archive.Mode, opts ...CallOption) (archive.Report, error) {
+	if err := archive.CheckMode(mode); err != nil {
+		return archive.Report{}, err
+	}
+	a, err := archive.Decode(r)
+	if err != nil {
+		return archive.Report{}, err
+	}
+	return p.ImportFrom(a, mode, opts...)
+}
+
+// ExportTo implements archive.Part
+func (p *API) ExportTo(a *archive.Archive) error {
+	a.Publishers = listStore(p.pubStore)
+	a.Subscriptions = listStore(p.subStore)
+	return nil
+}
+
+// ImportFrom implements archive.Part
+func (p *API) ImportFrom(a *archive.Archive, mode archive.Mode, opts ...CallOption) (report archive.Report, err error) {
+	c := p.call(opts)
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	defer p.updateObjectCounts()
+	if a.Publishers != nil {
+		if err = p.importList(p.pubStore, p.pubFile, archive.SectionPublishers, a.Publishers, mode, &report, c); err != nil {
+			return
+		}
+	}
+	if a.Subscriptions != nil {
+		err = p.importList(p.subStore, p.subFile, archive.SectionSubscriptions, a.Subscriptions, mode, &report, c)
+	}
+	return
+}
+
+// importList combines list with the content of ps, persists the result under key and then
+// updates ps
+func (p *API) importList(ps *store.PubSubStore, key, section string, list []pubsub.PubSub, mode archive.Mode, report *archive.Report, c store.Call) error {
+	current := map[string]pubsub.PubSub{}
+	for _, s := range listStore(ps) {
+		current[s.ID] = s
+	}
+	next := map[string]pubsub.PubSub{}
+	resources := map[string]string{}
+	// namespaces counts the records of next by namespace to check the subscription quotas
+	namespaces := map[string]int{}
+	if mode == archive.Merge {
+		for id, s := range current {
+			next[id] = s
+			resources[resourceKey(s)] = id
+			namespaces[s.GetNamespace()]++
+		}
+	}
+	var changed []pubsub.PubSub
+	for _, s := range list {
+		if s.ID == "" {
+			report.Conflict(section, s.Resource, "record has no id")
+			continue
+		}
+		if prev, ok := next[s.ID]; ok {
+			switch {
+			case mode == archive.Replace:
+				report.Conflict(section, s.ID, "id is used more than once in the archive")
+			case archive.SameRecord(prev, s):
+				report.Unchanged++
+			default:
+				report.Conflict(section, s.ID, "differs from the stored record")
+			}
+			continue
+		}
+		if id, ok := resources[resourceKey(s)]; ok {
+			report.Conflict(section, s.ID, "resource %s is already used by %s", s.Resource, id)
+			continue
+		}
+		if s.GetCreatedAt().IsZero() {
+			s.SetCreatedAt(p.clock.Now())
+		}
+		existing, exists := current[s.ID]
+		if ps == p.subStore && !exists {
+			quota := p.quotas.Get(s.GetNamespace())
+			if e := store.CheckQuota(s.GetNamespace(), store.QuotaSubscriptions, quota.MaxSubscriptions, namespaces[s.GetNamespace()], 1); e != nil {
+				report.Conflict(section, s.ID, "%v", e)
+				continue
+			}
+		}
+		switch {
+		case !exists:
+			if s.GetRevision() == 0 {
+				s.SetRevision(1)
+			}
+			report.Added++
+			changed = append(changed, s)
+		case archive.SameRecord(existing, s):
+			s = existing
+			report.Unchanged++
+		default:
+			s.SetRevision(existing.GetRevision() + 1)
+			report.Updated++
+			changed = append(changed, s)
+		}
+		next[s.ID] = s
+		resources[resourceKey(s)] = s.ID
+		namespaces[s.GetNamespace()]++
+	}
+	var removed []pubsub.PubSub
+	for id, s := range current {
+		if _, ok := next[id]; !ok {
+			removed = append(removed, s)
+		}
+	}
+	report.Removed += len(removed)
+	if len(changed) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	out := make([]pubsub.PubSub, 0, len(next))
+	for _, s := range next {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	p.mu.Lock()
+	err := p.putPubSubs(key, out)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	reason := fmt.Sprintf("%s import", mode)
+	for _, s := range removed {
+		ps.Delete(s.ID)
+		p.auditPubSub(c.Actor, audit.ActionDelete, key, s, reason)
+	}
+	for _, s := range changed {
+		action := audit.ActionUpdate
+		if _, ok := current[s.ID]; !ok {
+			action = audit.ActionCreate
+		}
+		ps.Set(s.ID, s)
+		p.auditPubSub(c.Actor, action, key, s, reason)
+	}
+	p.logger.Infof("imported %s: %d added or updated, %d removed", section, len(changed), len(removed))
+	return nil
+}
+
+// listStore returns a copy of the content of ps sorted by id
+func listStore(ps *store.PubSubStore) []pubsub.PubSub {
+	return ps.Snapshot().List()
+}
+
+// resourceKey identifies the resource of a pub/sub within its namespace
+func resourceKey(s pubsub.PubSub) string {
+	return s.GetNamespace() + "\x00" + s.GetResource()
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redhat-cne/sdk-go/pkg/localmetrics"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/encryption"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
+	log "github.com/sirupsen/logrus"
+)
+
+// Option configures an API created by NewAPI
+type Option func(*API)
+
+// WithStorePath persists publishers and subscriptions as files in path.
+// It is ignored if WithBackend is also given.
+func WithStorePath(path string) Option {
+	return func(p *API) {
+		p.storeFilePath = path
+	}
+}
+
+// WithBackend persists publishers and subscriptions to b
+func WithBackend(b backend.Backend) Option {
+	return func(p *API) {
+		p.backend = b
+	}
+}
+
+// WithLogger sets the logger used by the API, defaults to the logrus standard logger
+func WithLogger(l log.FieldLogger) Option {
+	return func(p *API) {
+		p.logger = l
+	}
+}
+
+// WithClock sets the clock used by the API, defaults to the real clock
+func WithClock(c clock.Clock) Option {
+	return func(p *API) {
+		p.clock = c
+	}
+}
+
+// WithMetricsRegistry registers the store metrics with reg, by default no metrics are collected
+func WithMetricsRegistry(reg prometheus.Registerer) Option {
+	return func(p *API) {
+		p.metrics = localmetrics.NewStoreMetrics(reg)
+	}
+}
+
+// WithQuota limits the number of subscriptions of namespace, only MaxSubscriptions applies
+func WithQuota(namespace string, quota store.Quota) Option {
+	return func(p *API) {
+		p.quotas.Set(namespace, quota)
+	}
+}
+
+// WithDefaultQuota limits the number of subscriptions of the namespaces without their own quota
+func WithDefaultQuota(quota store.Quota) Option {
+	return func(p *API) {
+		p.quotas.SetDefault(quota)
+	}
+}
+
+// WithAuditLog records every change of the publishers and subscriptions in l
+func WithAuditLog(l *audit.Log) Option {
+	return func(p *API) {
+		p.audit = l
+	}
+}
+
+// WithAuditActor sets the actor recorded for the changes requested through the API,
+// defaults to audit.ActorAPI
+func WithAuditActor(actor string) Option {
+	return func(p *API) {
+		p.auditActor = actor
+	}
+}
+
+// CallOption configures a single call of the API
+type CallOption = store.CallOption
+
+// AsActor records actor as the author of the changes made by the call instead of the actor set
+// with WithAuditActor, a REST layer passes the identity of each request
+func AsActor(actor string) CallOption {
+	return store.AsActor(actor)
+}
+
+// call returns the options of a call with the defaults of the API
+func (p *API) call(opts []CallOption) store.Call {
+	return store.NewCall(p.auditActor, opts...)
+}
+
+// WithEncryption encrypts everything the API persists with the keys of keys, values stored
+// in plaintext before are read and encrypted on their next write unless opts include
+// encryption.RequireEncryption
+func WithEncryption(keys encryption.KeyProvider, opts ...encryption.Option) Option {
+	return func(p *API) {
+		p.keys = keys
+		p.encryptionOpts = opts
+	}
+}
+
+// WithReloadNotifier sends to ch the changes applied each time a store file changed by another
+// process is reloaded, see StartHotReload. Reports are dropped when ch is full.
+func WithReloadNotifier(ch chan<- archive.Report) Option {
+	return func(p *API) {
+		p.reloadNotifier = ch
+	}
+}
@@ -15,18 +15,26 @@
 package pubsub
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"os"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/localmetrics"
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
 	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/encryption"
+	"github.com/redhat-cne/sdk-go/pkg/store/query"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
 	"github.com/redhat-cne/sdk-go/pkg/types"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
 )
 
 // API ... api methods  for publisher subscriber
@@ -36,12 +44,26 @@ type API struct {
 	subFile          string
 	pubFile          string
 	storeFilePath    string
+	backend          backend.Backend
+	keys             encryption.KeyProvider
+	encryptionOpts   []encryption.Option
 	transportEnabled bool
+	logger           log.FieldLogger
+	clock            clock.Clock
+	metrics          *localmetrics.StoreMetrics
+	// mu serializes read-modify-write cycles on the backend
+	mu sync.Mutex
+	// updateMu makes revision checks and the following update atomic
+	updateMu   sync.Mutex
+	quotas     store.Quotas
+	audit      *audit.Log
+	auditActor string
+	// reloadNotifier receives the changes applied by hot reload
+	reloadNotifier chan<- archive.Report
 }
 
 var instance *API
 var once sync.Once
-var mu sync.Mutex
 
 // NewPubSub create new publisher or subscriber
 func NewPubSub(endPointURI *types.URI, resource string) pubsub.PubSub {
@@ -56,52 +78,100 @@ func New() pubsub.PubSub {
 	return pubsub.PubSub{}
 }
 
-// GetAPIInstance get event instance
+// NewAPI creates an independent publisher/subscription API and loads its store.
+// Without WithBackend or WithStorePath the API keeps its state in memory only.
+func NewAPI(opts ...Option) *API {
+	p := &API{
+		transportEnabled: true,
+		pubStore: &store.PubSubStore{
+			RWMutex: sync.RWMutex{},
+			Store:   map[string]*pubsub.PubSub{},
+		},
+		subStore: &store.PubSubStore{
+			RWMutex: sync.RWMutex{},
+			Store:   map[string]*pubsub.PubSub{},
+		},
+		subFile:    "sub.json",
+		pubFile:    "pub.json",
+		logger:     log.StandardLogger(),
+		clock:      clock.RealClock{},
+		auditActor: audit.ActorAPI,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.backend == nil {
+		if p.storeFilePath != "" {
+			p.backend = backend.NewFileBackend(p.storeFilePath, backend.WithBackupCheck(p.checkBackup))
+		} else {
+			p.backend = backend.NewMemoryBackend()
+		}
+	}
+	if p.keys != nil {
+		p.backend = encryption.NewBackend(p.backend, p.keys, append([]encryption.Option{encryption.WithLogger(p.logger)}, p.encryptionOpts...)...)
+	}
+	if err := p.ReloadStore(); err != nil {
+		p.logger.Errorf("error reloading store %s: %v", p.storeFilePath, err)
+	}
+	return p
+}
+
+// checkBackup accepts the content of a store file as backup if it decrypts and holds valid JSON
+func (p *API) checkBackup(key string, data []byte) (err error) {
+	if e, ok := p.backend.(*encryption.Backend); ok {
+		if data, err = e.Decrypt(key, data); err != nil {
+			return err
+		}
+	}
+	return backend.ValidJSON(key, data)
+}
+
+// GetAPIInstance get event instance.
+// The first call creates a shared instance, later calls return it regardless of storeFilePath;
+// use NewAPI for independent instances.
 func GetAPIInstance(storeFilePath string) *API {
 	once.Do(func() {
-		instance = &API{
-			transportEnabled: true,
-			pubStore: &store.PubSubStore{
-				RWMutex: sync.RWMutex{},
-				Store:   map[string]*pubsub.PubSub{},
-			},
-			subStore: &store.PubSubStore{
-				RWMutex: sync.RWMutex{},
-				Store:   map[string]*pubsub.PubSub{},
-			},
-			subFile:       "sub.json",
-			pubFile:       "pub.json",
-			storeFilePath: storeFilePath,
-		}
-		instance.ReloadStore()
+		instance = NewAPI(WithStorePath(storeFilePath))
 	})
 	return instance
 }
 
-// ReloadStore reload store if there is any change or refresh is required
-func (p *API) ReloadStore() {
-	// load for file
-	if b, err := loadFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, p.subFile)); err == nil {
-		if len(b) > 0 {
-			var subs []pubsub.PubSub
-			if err := json.Unmarshal(b, &subs); err == nil {
-				for _, sub := range subs {
-					p.subStore.Set(sub.ID, sub)
-				}
-			}
-		}
+// GetAPIInstanceWithBackend get event instance persisting to the given backend
+func GetAPIInstanceWithBackend(b backend.Backend) *API {
+	once.Do(func() {
+		instance = NewAPI(WithBackend(b))
+	})
+	return instance
+}
+
+// ReloadStore reload store if there is any change or refresh is required.
+// A corrupted store file is replaced by its last good copy when one exists; corruption is
+// reported as a *backend.CorruptionError in the returned error. Files written in an older
+// layout are upgraded to the current schema version.
+func (p *API) ReloadStore() error {
+	var errs []error
+	subs, err := p.loadPubSubs(p.subFile)
+	if err != nil {
+		errs = append(errs, err)
 	}
-	// load for file
-	if b, err := loadFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, p.pubFile)); err == nil {
-		if len(b) > 0 {
-			var pubs []pubsub.PubSub
-			if err := json.Unmarshal(b, &pubs); err == nil {
-				for _, pub := range pubs {
-					p.pubStore.Set(pub.ID, pub)
-				}
-			}
-		}
+	for _, sub := range subs {
+		p.subStore.Set(sub.ID, sub)
+	}
+	pubs, err := p.loadPubSubs(p.pubFile)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, pub := range pubs {
+		p.pubStore.Set(pub.ID, pub)
 	}
+	p.updateObjectCounts()
+	err = errors.Join(errs...)
+	reason := fmt.Sprintf("loaded %d publishers and %d subscriptions", len(pubs), len(subs))
+	if err != nil {
+		reason = fmt.Sprintf("%s: %v", reason, err)
+	}
+	p.audit.Record(audit.Record{Action: audit.ActionReload, Kind: audit.KindStore, Actor: audit.ActorSystem, Reason: reason})
+	return err
 }
 
 // HasTransportEnabled ...
@@ -121,35 +191,29 @@ func (p *API) EnableTransport() {
 
 // GetFromPubStore get data from publisher store
 func (p *API) GetFromPubStore(address string) (pubsub.PubSub, error) {
-	for _, pub := range p.pubStore.Store {
-		if pub.GetResource() == address {
-			return pubsub.PubSub{
-				ID:          pub.ID,
-				EndPointURI: pub.EndPointURI,
-				URILocation: pub.URILocation,
-				Resource:    pub.Resource,
-			}, nil
-		}
+	var found pubsub.PubSub
+	ok := false
+	p.pubStore.Snapshot().Range(func(_ string, pub pubsub.PubSub) bool {
+		found, ok = pub, pub.GetResource() == address
+		return !ok
+	})
+	if ok {
+		return found, nil
 	}
 	return pubsub.PubSub{}, fmt.Errorf("publisher not found for address %s", address)
 }
 
 // GetFromSubStore get data from subscription store
 func (p *API) GetFromSubStore(address string) (pubsub.PubSub, error) {
-	for _, sub := range p.subStore.Store {
-		if sub.GetResource() == address {
-			return pubsub.PubSub{
-				ID:          sub.ID,
-				EndPointURI: sub.EndPointURI,
-				URILocation: sub.URILocation,
-				Resource:    sub.Resource,
-			}, nil
-		}
+	if sub, ok := p.subStore.Snapshot().GetByResource(address); ok {
+		return sub, nil
 	}
 	return pubsub.PubSub{}, fmt.Errorf("subscription not found for address %s ", address)
 }
 
-// HasSubscription check if the subscription is already exists in the store/cache
+// HasSubscription check if the subscription is already exists in the store/cache.
+// Subscriptions with wildcard resources such as /cluster/node/*/sync or /sync/ptp-status/**
+// match concrete addresses, an exact match is preferred.
 func (p *API) HasSubscription(address string) (pubsub.PubSub, bool) {
 	if sub, err := p.GetFromSubStore(address); err == nil {
 		return sub, true
@@ -166,156 +230,379 @@ func (p *API) HasPublisher(address string) (pubsub.PubSub, bool) {
 }
 
 // CreateSubscription create a subscription and store it in a file and cache
-func (p *API) CreateSubscription(sub pubsub.PubSub) (pubsub.PubSub, error) {
+func (p *API) CreateSubscription(sub pubsub.PubSub, opts ...CallOption) (pubsub.PubSub, error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
 	//TODO-V2: remove this from v2 since already checked this before calling
-	if subExists, ok := p.HasSubscription(sub.GetResource()); ok {
-		log.Warnf("there was already a subscription in the store,skipping creation %v", subExists)
-		p.subStore.Set(sub.ID, subExists)
+	// a subscription to a matching wildcard pattern is not a duplicate, only the same resource
+	// in the same namespace is
+	if subExists, ok := findResource(p.subStore, sub.GetNamespace(), sub.GetResource()); ok {
+		p.logger.Warnf("there was already a subscription in the store,skipping creation %v", subExists)
+		if sub.ID != "" {
+			p.subStore.Set(sub.ID, subExists)
+		}
 		return subExists, nil
 	}
+	quota := p.quotas.Get(sub.GetNamespace())
+	if err := store.CheckQuota(sub.GetNamespace(), store.QuotaSubscriptions, quota.MaxSubscriptions,
+		p.subStore.CountNamespace(sub.GetNamespace()), 1); err != nil {
+		return pubsub.PubSub{}, err
+	}
 	if sub.ID == "" { //this will be always set by rest api
 		sub.SetID(uuid.New().String())
 	}
+	if sub.GetCreatedAt().IsZero() {
+		sub.SetCreatedAt(p.clock.Now())
+	}
+	sub.SetRevision(1)
 	// persist the subscription -
-	err := writeToFile(sub, fmt.Sprintf("%s/%s", p.storeFilePath, p.subFile))
+	err := p.writeToBackend(sub, p.subFile)
 	if err != nil {
-		log.Errorf("error writing to a store %v\n", err)
+		p.logger.Errorf("error writing to a store %v\n", err)
 		return pubsub.PubSub{}, err
 	}
-	log.Infof("subscription persisted into a file %s", fmt.Sprintf("%s/%s  - content %s", p.storeFilePath, p.subFile, sub.String()))
+	p.logger.Infof("subscription persisted into a file %s", fmt.Sprintf("%s/%s  - content %s", p.storeFilePath, p.subFile, sub.String()))
 	// store the publisher
 	p.subStore.Set(sub.ID, sub)
+	p.updateObjectCounts()
+	p.auditPubSub(p.call(opts).Actor, audit.ActionCreate, p.subFile, sub, "")
 	return sub, nil
 }
 
 // CreatePublisher create a publisher data and store it a file and cache
-func (p *API) CreatePublisher(pub pubsub.PubSub) (pubsub.PubSub, error) {
-	if pubExists, ok := p.HasPublisher(pub.GetResource()); ok {
-		log.Warnf("There was already a publisher, skipping creation %v", pubExists)
-		p.pubStore.Set(pub.ID, pubExists)
+func (p *API) CreatePublisher(pub pubsub.PubSub, opts ...CallOption) (pubsub.PubSub, error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	if pubExists, ok := findResource(p.pubStore, pub.GetNamespace(), pub.GetResource()); ok {
+		p.logger.Warnf("There was already a publisher, skipping creation %v", pubExists)
+		if pub.ID != "" {
+			p.pubStore.Set(pub.ID, pubExists)
+		}
 		return pubExists, nil
 	}
 	if pub.ID == "" { //this will be always set by rest api
 		pub.SetID(uuid.New().String())
 	}
+	if pub.GetCreatedAt().IsZero() {
+		pub.SetCreatedAt(p.clock.Now())
+	}
+	pub.SetRevision(1)
 	// persist the subscription -
-	err := writeToFile(pub, fmt.Sprintf("%s/%s", p.storeFilePath, p.pubFile))
+	err := p.writeToBackend(pub, p.pubFile)
 	if err != nil {
-		log.Errorf("error writing to a store %v\n", err)
+		p.logger.Errorf("error writing to a store %v\n", err)
 		return pubsub.PubSub{}, err
 	}
-	log.Infof("publisher persisted into a file %s", fmt.Sprintf("%s/%s  - content %s", p.storeFilePath, p.pubFile, pub.String()))
+	p.logger.Infof("publisher persisted into a file %s", fmt.Sprintf("%s/%s  - content %s", p.storeFilePath, p.pubFile, pub.String()))
 	// store the publisher
 	p.pubStore.Set(pub.ID, pub)
+	p.updateObjectCounts()
+	p.auditPubSub(p.call(opts).Actor, audit.ActionCreate, p.pubFile, pub, "")
 	return pub, nil
 }
 
 // GetSubscription  get a subscription by it's id
 func (p *API) GetSubscription(subscriptionID string) (pubsub.PubSub, error) {
-	if sub, ok := p.subStore.Store[subscriptionID]; ok {
-		return *sub, nil
+	if sub, ok := p.subStore.Snapshot().Get(subscriptionID); ok {
+		return sub, nil
 	}
 	return pubsub.PubSub{}, fmt.Errorf("subscription data was not found for id %s", subscriptionID)
 }
 
 // GetPublisher get a publisher by it's id
 func (p *API) GetPublisher(publisherID string) (pubsub.PubSub, error) {
-	if sub, ok := p.pubStore.Store[publisherID]; ok {
-		return *sub, nil
+	if pub, ok := p.pubStore.Snapshot().Get(publisherID); ok {
+		return pub, nil
 	}
 	return pubsub.PubSub{}, fmt.Errorf("publisher data was not found for id %s", publisherID)
 }
 
-// GetSubscriptions  get all subscription inforamtions
+// GetSubscriptions returns a copy of all the subscriptions taken from a consistent snapshot
 func (p *API) GetSubscriptions() map[string]*pubsub.PubSub {
-	return p.subStore.Store
+	return p.subStore.Snapshot().Map()
 }
 
-// GetPublishers  get all publishers information
+// GetPublishers returns a copy of all the publishers taken from a consistent snapshot
 func (p *API) GetPublishers() map[string]*pubsub.PubSub {
-	return p.pubStore.Store
+	return p.pubStore.Snapshot().Map()
+}
+
+// SubscriptionsSnapshot returns an immutable view of the subscriptions with its revision, it
+// stays valid while the subscriptions change
+func (p *API) SubscriptionsSnapshot() *store.Snapshot {
+	return p.subStore.Snapshot()
+}
+
+// PublishersSnapshot returns an immutable view of the publishers with its revision, it stays
+// valid while the publishers change
+func (p *API) PublishersSnapshot() *store.Snapshot {
+	return p.pubStore.Snapshot()
+}
+
+// GetSubscriptionsInNamespace returns a copy of the subscriptions of namespace
+func (p *API) GetSubscriptionsInNamespace(namespace string) map[string]*pubsub.PubSub {
+	return p.subStore.InNamespace(namespace)
+}
+
+// GetPublishersInNamespace returns a copy of the publishers of namespace
+func (p *API) GetPublishersInNamespace(namespace string) map[string]*pubsub.PubSub {
+	return p.pubStore.InNamespace(namespace)
+}
+
+// SetQuota sets the quota of namespace, existing subscriptions above the quota are kept
+func (p *API) SetQuota(namespace string, quota store.Quota) {
+	p.quotas.Set(namespace, quota)
+}
+
+// QuerySubscriptions returns a page of copies of the subscriptions selected by req.
+// The ClientID and Status filters are not supported since the store holds no clients.
+func (p *API) QuerySubscriptions(req query.Request) (query.Page, error) {
+	return queryStore(p.subStore, req)
+}
+
+// QueryPublishers returns a page of copies of the publishers selected by req.
+// The ClientID and Status filters are not supported since the store holds no clients.
+func (p *API) QueryPublishers(req query.Request) (query.Page, error) {
+	return queryStore(p.pubStore, req)
+}
+
+func queryStore(ps *store.PubSubStore, req query.Request) (query.Page, error) {
+	if req.ClientID != uuid.Nil || req.Status != nil {
+		return query.Page{}, fmt.Errorf("client id and status filters are not supported by the pub/sub store")
+	}
+	snapshot := ps.Snapshot()
+	items := make([]query.Item, 0, snapshot.Len())
+	snapshot.Range(func(_ string, s pubsub.PubSub) bool {
+		items = append(items, query.Item{Subscription: s.Copy()})
+		return true
+	})
+	return query.Run(items, req)
+}
+
+// WatchSubscriptions streams subscription changes, including those made by ReloadStore, until ctx is cancelled
+func (p *API) WatchSubscriptions(ctx context.Context) <-chan store.PubSubEvent {
+	return p.subStore.Watch(ctx)
+}
+
+// WatchPublishers streams publisher changes, including those made by ReloadStore, until ctx is cancelled
+func (p *API) WatchPublishers(ctx context.Context) <-chan store.PubSubEvent {
+	return p.pubStore.Watch(ctx)
+}
+
+// UpdateSubscription replaces the subscription with the id of sub if its revision is ifMatch,
+// store.AnyRevision skips the check. A revision mismatch returns a *store.ConflictError.
+func (p *API) UpdateSubscription(sub pubsub.PubSub, ifMatch uint64, opts ...CallOption) (pubsub.PubSub, error) {
+	return p.update(p.subStore, p.subFile, sub, ifMatch, p.call(opts))
+}
+
+// UpdatePublisher replaces the publisher with the id of pub if its revision is ifMatch,
+// store.AnyRevision skips the check. A revision mismatch returns a *store.ConflictError.
+func (p *API) UpdatePublisher(pub pubsub.PubSub, ifMatch uint64, opts ...CallOption) (pubsub.PubSub, error) {
+	return p.update(p.pubStore, p.pubFile, pub, ifMatch, p.call(opts))
+}
+
+func (p *API) update(ps *store.PubSubStore, key string, sub pubsub.PubSub, ifMatch uint64, c store.Call) (pubsub.PubSub, error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	current, ok := getFromStore(ps, sub.ID)
+	if err := store.CheckRevision(sub.ID, ifMatch, current.GetRevision()); err != nil {
+		return pubsub.PubSub{}, err
+	}
+	if !ok {
+		return pubsub.PubSub{}, fmt.Errorf("data was not found for id %s", sub.ID)
+	}
+	// the namespace can't be changed, quotas are only checked on creation
+	sub.SetCreatedAt(current.GetCreatedAt())
+	sub.SetNamespace(current.GetNamespace())
+	sub.SetRevision(current.GetRevision() + 1)
+	if err := p.writeToBackend(sub, key); err != nil {
+		return pubsub.PubSub{}, err
+	}
+	ps.Set(sub.ID, sub)
+	p.auditPubSub(c.Actor, audit.ActionUpdate, key, sub, "")
+	return sub, nil
 }
 
 // DeletePublisher delete a publisher by id
-func (p *API) DeletePublisher(publisherID string) error {
-	log.Info("deleting publisher")
-	if pub, ok := p.pubStore.Store[publisherID]; ok {
-		err := deleteFromFile(*pub, fmt.Sprintf("%s/%s", p.storeFilePath, p.pubFile))
-		p.pubStore.Delete(publisherID)
-		return err
+func (p *API) DeletePublisher(publisherID string, opts ...CallOption) error {
+	p.logger.Info("deleting publisher")
+	err := p.delete(p.pubStore, p.pubFile, publisherID, store.AnyRevision, p.call(opts))
+	if errors.Is(err, errNotFound) {
+		return nil
 	}
-	return nil
+	return err
+}
+
+// DeletePublisherIfMatch deletes a publisher if its revision is ifMatch, a revision mismatch
+// returns a *store.ConflictError
+func (p *API) DeletePublisherIfMatch(publisherID string, ifMatch uint64, opts ...CallOption) error {
+	return p.delete(p.pubStore, p.pubFile, publisherID, ifMatch, p.call(opts))
 }
 
 // DeleteSubscription delete a subscription by id
-func (p *API) DeleteSubscription(subscriptionID string) error {
-	log.Info("deleting subscription")
-	if pub, ok := p.subStore.Store[subscriptionID]; ok {
-		if err := deleteFromFile(*pub, fmt.Sprintf("%s/%s", p.storeFilePath, p.subFile)); err == nil {
-			p.subStore.Delete(subscriptionID)
-		} else {
-			return err
-		}
-	} else {
+func (p *API) DeleteSubscription(subscriptionID string, opts ...CallOption) error {
+	p.logger.Info("deleting subscription")
+	if err := p.delete(p.subStore, p.subFile, subscriptionID, store.AnyRevision, p.call(opts)); errors.Is(err, errNotFound) {
 		return fmt.Errorf("subscription not found")
+	} else if err != nil {
+		return err
 	}
 	return nil
 }
 
+// DeleteSubscriptionIfMatch deletes a subscription if its revision is ifMatch, a revision mismatch
+// returns a *store.ConflictError
+func (p *API) DeleteSubscriptionIfMatch(subscriptionID string, ifMatch uint64, opts ...CallOption) error {
+	return p.delete(p.subStore, p.subFile, subscriptionID, ifMatch, p.call(opts))
+}
+
+var errNotFound = errors.New("not found")
+
+func (p *API) delete(ps *store.PubSubStore, key, id string, ifMatch uint64, c store.Call) error {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	current, ok := getFromStore(ps, id)
+	if err := store.CheckRevision(id, ifMatch, current.GetRevision()); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("data was not found for id %s: %w", id, errNotFound)
+	}
+	if err := p.deleteFromBackend(current, key); err != nil {
+		return err
+	}
+	ps.Delete(id)
+	p.updateObjectCounts()
+	p.auditPubSub(c.Actor, audit.ActionDelete, key, current, "")
+	return nil
+}
+
+// getFromStore returns a copy of the pub/sub stored under id
+func getFromStore(ps *store.PubSubStore, id string) (pubsub.PubSub, bool) {
+	ps.RLock()
+	defer ps.RUnlock()
+	if s, ok := ps.Store[id]; ok {
+		return *s, true
+	}
+	return pubsub.PubSub{}, false
+}
+
 // DeleteAllSubscriptions  delete all subscription information
-func (p *API) DeleteAllSubscriptions() error {
-	log.Info("deleting all subscription")
-	if err := deleteAllFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, p.subFile)); err != nil {
+func (p *API) DeleteAllSubscriptions(opts ...CallOption) error {
+	p.logger.Info("deleting all subscription")
+	deleted := listStore(p.subStore)
+	if err := p.deleteAllFromBackend(p.subFile); err != nil {
 		return err
 	}
 	// empty the store
-	p.subStore.Store = make(map[string]*pubsub.PubSub)
+	p.subStore.DeleteAll()
+	p.updateObjectCounts()
+	for _, s := range deleted {
+		p.auditPubSub(p.call(opts).Actor, audit.ActionDelete, p.subFile, s, "all subscriptions deleted")
+	}
 	return nil
 }
 
+// DeleteAllSubscriptionsInNamespace deletes the subscriptions of namespace
+func (p *API) DeleteAllSubscriptionsInNamespace(namespace string, opts ...CallOption) error {
+	p.logger.Infof("deleting all subscriptions of namespace %s", pubsub.Namespace(namespace))
+	return p.deleteNamespace(p.subStore, p.subFile, namespace, p.call(opts))
+}
+
+// DeleteAllPublishersInNamespace deletes the publishers of namespace
+func (p *API) DeleteAllPublishersInNamespace(namespace string, opts ...CallOption) error {
+	p.logger.Infof("deleting all publishers of namespace %s", pubsub.Namespace(namespace))
+	return p.deleteNamespace(p.pubStore, p.pubFile, namespace, p.call(opts))
+}
+
+// deleteNamespace removes the pub/subs of namespace from the list stored under key and then from ps
+func (p *API) deleteNamespace(ps *store.PubSubStore, key, namespace string, c store.Call) error {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	namespace = pubsub.Namespace(namespace)
+	var kept, removed []pubsub.PubSub
+	for _, s := range listStore(ps) {
+		if s.GetNamespace() == namespace {
+			removed = append(removed, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	err := p.putPubSubs(key, kept)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, s := range removed {
+		ps.Delete(s.ID)
+		p.auditPubSub(c.Actor, audit.ActionDelete, key, s, fmt.Sprintf("all records of namespace %s deleted", namespace))
+	}
+	p.updateObjectCounts()
+	return nil
+}
+
+// findResource returns the pub/sub of namespace with the given resource
+func findResource(ps *store.PubSubStore, namespace, resource string) (pubsub.PubSub, bool) {
+	ps.RLock()
+	defer ps.RUnlock()
+	for _, s := range ps.Store {
+		if s.GetResource() == resource && s.GetNamespace() == namespace {
+			return *s, true
+		}
+	}
+	return pubsub.PubSub{}, false
+}
+
 // DeleteAllPublishers delete all the publisher information the store and cache.
-func (p *API) DeleteAllPublishers() error {
-	log.Info("deleting all publishers")
-	if err := deleteAllFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, p.pubFile)); err != nil {
+func (p *API) DeleteAllPublishers(opts ...CallOption) error {
+	p.logger.Info("deleting all publishers")
+	deleted := listStore(p.pubStore)
+	if err := p.deleteAllFromBackend(p.pubFile); err != nil {
 		return err
 	}
 	//empty the store
-	p.pubStore.Store = make(map[string]*pubsub.PubSub)
+	p.pubStore.DeleteAll()
+	p.updateObjectCounts()
+	for _, s := range deleted {
+		p.auditPubSub(p.call(opts).Actor, audit.ActionDelete, p.pubFile, s, "all publishers deleted")
+	}
 	return nil
 }
 
 // GetPublishersFromFile  get publisher data from the file store
 func (p *API) GetPublishersFromFile() ([]byte, error) {
-	b, err := loadFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, p.pubFile))
+	b, err := loadFromBackend(p.backend, p.pubFile)
 	return b, err
 }
 
 // GetSubscriptionsFromFile  get subscriptions data from the file store
 func (p *API) GetSubscriptionsFromFile() ([]byte, error) {
-	b, err := loadFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, p.subFile))
+	b, err := loadFromBackend(p.backend, p.subFile)
 	return b, err
 }
 
-// deleteAllFromFile deletes  publisher and subscription information from the file system
-func deleteAllFromFile(filePath string) error {
-	return os.WriteFile(filePath, []byte{}, 0666)
+// deleteAllFromBackend deletes  publisher and subscription information from the backend
+func (p *API) deleteAllFromBackend(key string) error {
+	err := p.backend.Put(key, []byte{})
+	p.recordWrite(key, err)
+	return err
 }
 
-// DeleteFromFile is used to delete subscription from the file system
-func deleteFromFile(sub pubsub.PubSub, filePath string) error {
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	//read file and unmarshall json file to slice of users
-	b, err := io.ReadAll(file)
+// deleteFromBackend is used to delete subscription from the backend
+func (p *API) deleteFromBackend(sub pubsub.PubSub, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := loadFromBackend(p.backend, key)
 	if err != nil {
 		return err
 	}
 	var allSubs []pubsub.PubSub
-	if len(b) > 0 {
-		err = json.Unmarshal(b, &allSubs)
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &allSubs)
 		if err != nil {
 			return err
 		}
@@ -329,57 +616,131 @@ func deleteFromFile(sub pubsub.PubSub, filePath string) error {
 			break
 		}
 	}
-	newBytes, err := json.MarshalIndent(&allSubs, "", " ")
-	if err != nil {
-		log.Errorf("error deleting sub %v", err)
-		return err
+	if err = p.putPubSubs(key, allSubs); err != nil {
+		p.logger.Errorf("error deleting sub %v", err)
 	}
-	return os.WriteFile(filePath, newBytes, 0666)
+	return err
 }
 
-// loadFromFile is used to read subscription/publisher from the file system
-func loadFromFile(filePath string) (b []byte, err error) {
-	//open file
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		return nil, err
+// loadPubSubs reads a publisher/subscription list, falling back to the backup copy if the key is corrupted.
+// A list written with an older schema version is rewritten in the current one.
+func (p *API) loadPubSubs(key string) (list []pubsub.PubSub, err error) {
+	version := schema.CurrentVersion
+	err = backend.LoadWithFallback(p.backend, key, func(data []byte) error {
+		payload, v, e := schema.Unwrap(schema.KindPubSubList, data)
+		if e != nil {
+			return e
+		}
+		var decoded []pubsub.PubSub
+		if e = json.Unmarshal(payload, &decoded); e != nil {
+			return e
+		}
+		list, version = decoded, v
+		return nil
+	})
+	if err != nil || version >= schema.CurrentVersion {
+		return
 	}
-	defer file.Close()
-	//read file and unmarshall json file to slice of users
-	b, err = io.ReadAll(file)
-	if err != nil {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e := p.putPubSubs(key, list); e != nil {
+		p.logger.Errorf("failed to upgrade %s from schema version %d: %v", key, version, e)
+		return
+	}
+	p.logger.Infof("upgraded %s from schema version %d to %d", key, version, schema.CurrentVersion)
+	return
+}
+
+// loadFromBackend is used to read subscription/publisher from the backend, a missing key reads as empty.
+// The returned list is upgraded to the current schema version and unwrapped from its envelope.
+func loadFromBackend(b backend.Backend, key string) ([]byte, error) {
+	data, err := b.Get(key)
+	if errors.Is(err, backend.ErrNotFound) {
+		return []byte{}, nil
+	} else if err != nil {
 		return nil, err
 	}
-	return b, nil
+	payload, _, err := schema.Unwrap(schema.KindPubSubList, data)
+	return payload, err
 }
 
-// writeToFile writes subscription data to a file
-func writeToFile(sub pubsub.PubSub, filePath string) error {
-	//open file
-	mu.Lock()
-	defer mu.Unlock()
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0644)
+// putPubSubs writes a publisher/subscription list in the current schema version
+func (p *API) putPubSubs(key string, list []pubsub.PubSub) error {
+	payload, err := json.Marshal(&list)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	//read file and unmarshall json file to slice of users
-	b, err := io.ReadAll(file)
+	newBytes, err := schema.Wrap(schema.KindPubSubList, payload)
+	if err != nil {
+		return err
+	}
+	p.logger.Infof("persisting following contents %s to %s\n", string(newBytes), key)
+	err = p.backend.Put(key, newBytes)
+	p.recordWrite(key, err)
+	return err
+}
+
+// writeToBackend writes subscription data to the backend, replacing an entry with the same id
+func (p *API) writeToBackend(sub pubsub.PubSub, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := loadFromBackend(p.backend, key)
 	if err != nil {
 		return err
 	}
 	var allSubs []pubsub.PubSub
-	if len(b) > 0 {
-		err = json.Unmarshal(b, &allSubs)
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &allSubs)
 		if err != nil {
 			return err
 		}
 	}
-	allSubs = append(allSubs, sub)
-	newBytes, err := json.MarshalIndent(&allSubs, "", " ")
+	replaced := false
+	for i := range allSubs {
+		if allSubs[i].ID == sub.ID {
+			allSubs[i], replaced = sub, true
+			break
+		}
+	}
+	if !replaced {
+		allSubs = append(allSubs, sub)
+	}
+	return p.putPubSubs(key, allSubs)
+}
+
+// QueryAudit returns the audit records selected by q, none if no audit log is set
+func (p *API) QueryAudit(q audit.Query) ([]audit.Record, error) {
+	return p.audit.Query(q)
+}
+
+// auditPubSub records a change by actor of the publisher or subscription s stored under key
+func (p *API) auditPubSub(actor string, action audit.Action, key string, s pubsub.PubSub, reason string) {
+	kind := audit.KindSubscription
+	if key == p.pubFile {
+		kind = audit.KindPublisher
+	}
+	p.audit.Record(audit.Record{Action: action, Kind: kind, ID: s.ID, Namespace: s.GetNamespace(), Resource: s.GetResource(),
+		Actor: actor, Reason: reason})
+}
+
+// recordWrite counts a write to the backend and refreshes the object counts
+func (p *API) recordWrite(key string, err error) {
+	kind := "subscription"
+	if key == p.pubFile {
+		kind = "publisher"
+	}
 	if err != nil {
-		return err
+		p.metrics.IncWriteCount(kind, localmetrics.FAILED)
+		return
+	}
+	p.metrics.IncWriteCount(kind, localmetrics.SUCCESS)
+}
+
+// updateObjectCounts publishes the number of publishers and subscriptions held in memory
+func (p *API) updateObjectCounts() {
+	if p.metrics == nil {
+		return
 	}
-	log.Infof("persisting following contents %s to a file %s\n", string(newBytes), filePath)
-	return os.WriteFile(filePath, newBytes, 0666)
+	p.metrics.SetObjectCount("publisher", p.pubStore.Snapshot().Len())
+	p.metrics.SetObjectCount("subscription", p.subStore.Snapshot().Len())
 }
@@ -15,12 +15,25 @@
 package pubsub_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/query"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
 	"github.com/redhat-cne/sdk-go/pkg/types"
 	api "github.com/redhat-cne/sdk-go/v1/pubsub"
 	"github.com/stretchr/testify/assert"
@@ -45,6 +58,330 @@ func TestAPI_GetAPIInstance(t *testing.T) {
 	assert.Equal(t, &globalInstance, &localInstance)
 }
 
+func TestAPI_NewAPI(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	one := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()), api.WithMetricsRegistry(reg))
+	two := api.NewAPI(api.WithStorePath(t.TempDir()), api.WithMetricsRegistry(reg))
+	assert.NotSame(t, one, two)
+
+	s, e := one.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	_, found := two.HasSubscription(s.Resource)
+	assert.False(t, found)
+	assert.Len(t, one.GetSubscriptions(), 1)
+	assert.Len(t, two.GetSubscriptions(), 0)
+
+	families, e := reg.Gather()
+	assert.Nil(t, e)
+	var names []string
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.Contains(t, names, "cne_store_objects")
+	assert.Contains(t, names, "cne_store_writes_total")
+}
+
+func TestAPI_NewAPIReloadsBackend(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	s, e := api.NewAPI(api.WithBackend(b)).CreateSubscription(subscription)
+	assert.Nil(t, e)
+	reloaded, e := api.NewAPI(api.WithBackend(b)).GetSubscription(s.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, s, reloaded)
+}
+
+func TestAPI_HasSubscriptionWildcard(t *testing.T) {
+	p := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()))
+	for _, resource := range []string{"/cluster/node/*/sync/sync-status/sync-state", "/sync/ptp-status/**"} {
+		_, e := p.CreateSubscription(api.NewPubSub(subscription.EndPointURI, resource))
+		assert.Nil(t, e)
+	}
+	s, ok := p.HasSubscription("/cluster/node/worker-1/sync/sync-status/sync-state")
+	assert.True(t, ok)
+	assert.Equal(t, "/cluster/node/*/sync/sync-status/sync-state", s.Resource)
+	s, ok = p.HasSubscription("/sync/ptp-status/lock-state")
+	assert.True(t, ok)
+	assert.Equal(t, "/sync/ptp-status/**", s.Resource)
+	_, ok = p.HasSubscription("/cluster/node/worker-1/sync/gnss-status/gnss-sync-status")
+	assert.False(t, ok)
+
+	// a concrete subscription covered by a pattern is still created
+	s, e := p.CreateSubscription(api.NewPubSub(subscription.EndPointURI, "/sync/ptp-status/lock-state"))
+	assert.Nil(t, e)
+	assert.Equal(t, "/sync/ptp-status/lock-state", s.Resource)
+	assert.Len(t, p.GetSubscriptions(), 3)
+}
+
+func TestAPI_QuerySubscriptions(t *testing.T) {
+	p := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()))
+	for _, resource := range []string{"/cluster/node/a/sync", "/cluster/node/b/sync", "/other"} {
+		_, e := p.CreateSubscription(api.NewPubSub(subscription.EndPointURI, resource))
+		assert.Nil(t, e)
+	}
+	page, e := p.QuerySubscriptions(query.Request{Filter: query.Filter{ResourcePrefix: "/cluster/"}, Limit: 1})
+	assert.Nil(t, e)
+	assert.Len(t, page.Items, 1)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.False(t, page.Items[0].Subscription.CreatedAt.IsZero())
+
+	// items are copies of the store content
+	page.Items[0].Subscription.EndPointURI.Host = "changed"
+	s, e := p.GetSubscription(page.Items[0].Subscription.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, subscription.EndPointURI.Host, s.EndPointURI.Host)
+
+	page, e = p.QuerySubscriptions(query.Request{Filter: query.Filter{ResourcePrefix: "/cluster/"}, Cursor: page.NextCursor})
+	assert.Nil(t, e)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+
+	_, e = p.QuerySubscriptions(query.Request{Filter: query.Filter{ClientID: uuid.New()}})
+	assert.NotNil(t, e)
+}
+
+func TestAPI_UpdateSubscriptionIfMatch(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	p := api.NewAPI(api.WithBackend(b))
+	s, e := p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	assert.Equal(t, uint64(1), s.GetRevision())
+
+	s.Resource = "test/updated"
+	updated, e := p.UpdateSubscription(s, 1)
+	assert.Nil(t, e)
+	assert.Equal(t, uint64(2), updated.GetRevision())
+	assert.Equal(t, s.CreatedAt, updated.CreatedAt)
+
+	// a stale revision is rejected and the store is unchanged
+	s.Resource = "test/stale"
+	_, e = p.UpdateSubscription(s, 1)
+	var conflict *store.ConflictError
+	assert.True(t, errors.As(e, &conflict))
+	assert.Equal(t, uint64(2), conflict.Current)
+	current, e := p.GetSubscription(s.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, "test/updated", current.Resource)
+
+	// the revision survives a reload
+	reloaded := api.NewAPI(api.WithBackend(b))
+	current, e = reloaded.GetSubscription(s.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, uint64(2), current.GetRevision())
+	assert.Len(t, reloaded.GetSubscriptions(), 1)
+
+	assert.True(t, errors.Is(p.DeleteSubscriptionIfMatch(s.ID, 1), store.ErrConflict))
+	assert.Nil(t, p.DeleteSubscriptionIfMatch(s.ID, 2))
+	assert.True(t, errors.Is(p.DeleteSubscriptionIfMatch(s.ID, 2), store.ErrConflict))
+	_, e = p.UpdateSubscription(s, store.AnyRevision)
+	assert.NotNil(t, e)
+}
+
+func TestAPI_ExportImport(t *testing.T) {
+	source := api.NewAPI()
+	s, e := source.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	_, e = source.CreatePublisher(publisher)
+	assert.Nil(t, e)
+	var b bytes.Buffer
+	assert.Nil(t, source.Export(&b))
+	data := b.Bytes()
+
+	be := backend.NewMemoryBackend()
+	target := api.NewAPI(api.WithBackend(be))
+	report, e := target.Import(bytes.NewReader(data), archive.Merge)
+	assert.Nil(t, e)
+	assert.Equal(t, archive.Report{Added: 2}, report)
+	extra, e := target.CreateSubscription(api.NewPubSub(subscription.EndPointURI, "test/extra"))
+	assert.Nil(t, e)
+
+	report, e = target.Import(bytes.NewReader(data), archive.Merge)
+	assert.Nil(t, e)
+	assert.Equal(t, 2, report.Unchanged)
+	assert.Empty(t, report.Conflicts)
+
+	changed := s
+	changed.Resource = "test/changed"
+	var conflicting bytes.Buffer
+	assert.Nil(t, archive.Encode(&conflicting, &archive.Archive{Subscriptions: []pubsub.PubSub{changed}}))
+	report, e = target.Import(bytes.NewReader(conflicting.Bytes()), archive.Merge)
+	assert.Nil(t, e)
+	assert.Len(t, report.Conflicts, 1)
+	assert.Equal(t, s.ID, report.Conflicts[0].ID)
+
+	report, e = target.Import(bytes.NewReader(conflicting.Bytes()), archive.Replace)
+	assert.Nil(t, e)
+	assert.Equal(t, archive.Report{Updated: 1, Removed: 1}, report)
+	_, e = target.GetSubscription(extra.ID)
+	assert.NotNil(t, e)
+	// publishers are not part of the conflicting archive and are kept
+	assert.Len(t, target.GetPublishers(), 1)
+
+	reloaded := api.NewAPI(api.WithBackend(be))
+	current, e := reloaded.GetSubscription(s.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, "test/changed", current.Resource)
+	assert.Equal(t, uint64(2), current.GetRevision())
+	assert.Len(t, reloaded.GetSubscriptions(), 1)
+}
+
+func TestAPI_Namespaces(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	p := api.NewAPI(api.WithBackend(b), api.WithQuota("tenant", store.Quota{MaxSubscriptions: 2}))
+	inDefault, e := p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	assert.Equal(t, pubsub.DefaultNamespace, inDefault.GetNamespace())
+
+	// the same resource in another namespace is not a duplicate
+	tenantSub := api.NewPubSub(subscription.EndPointURI, subscription.Resource)
+	tenantSub.SetNamespace("tenant")
+	inTenant, e := p.CreateSubscription(tenantSub)
+	assert.Nil(t, e)
+	assert.NotEqual(t, inDefault.ID, inTenant.ID)
+	again, e := p.CreateSubscription(tenantSub)
+	assert.Nil(t, e)
+	assert.Equal(t, inTenant.ID, again.ID)
+
+	tenantSub.Resource = "test/second"
+	_, e = p.CreateSubscription(tenantSub)
+	assert.Nil(t, e)
+	tenantSub.Resource = "test/third"
+	_, e = p.CreateSubscription(tenantSub)
+	assert.True(t, errors.Is(e, store.ErrQuotaExceeded))
+	assert.Len(t, p.GetSubscriptionsInNamespace("tenant"), 2)
+	page, e := p.QuerySubscriptions(query.Request{Filter: query.Filter{Namespace: "tenant"}})
+	assert.Nil(t, e)
+	assert.Len(t, page.Items, 2)
+
+	// the namespace survives a reload
+	reloaded := api.NewAPI(api.WithBackend(b))
+	s, e := reloaded.GetSubscription(inTenant.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, "tenant", s.GetNamespace())
+
+	assert.Nil(t, p.DeleteAllSubscriptionsInNamespace("tenant"))
+	assert.Len(t, p.GetSubscriptions(), 1)
+	_, e = p.GetSubscription(inDefault.ID)
+	assert.Nil(t, e)
+	reloaded = api.NewAPI(api.WithBackend(b))
+	assert.Len(t, reloaded.GetSubscriptions(), 1)
+	assert.Empty(t, reloaded.GetSubscriptionsInNamespace("tenant"))
+}
+
+func TestAPI_ImportQuota(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	p := api.NewAPI(api.WithBackend(b), api.WithQuota("tenant", store.Quota{MaxSubscriptions: 2}))
+	tenantSub := func(resource string) pubsub.PubSub {
+		s := api.NewPubSub(subscription.EndPointURI, resource)
+		s.SetID(uuid.New().String())
+		s.SetNamespace("tenant")
+		return s
+	}
+	stored, e := p.CreateSubscription(tenantSub("test/1"))
+	assert.Nil(t, e)
+
+	// the subscription past the quota of the namespace is rejected, the other namespaces are not limited
+	second, third, other := tenantSub("test/2"), tenantSub("test/3"), api.NewPubSub(subscription.EndPointURI, "test/3")
+	other.SetID(uuid.New().String())
+	report, e := p.ImportFrom(&archive.Archive{Subscriptions: []pubsub.PubSub{second, third, other}}, archive.Merge)
+	assert.Nil(t, e)
+	assert.Equal(t, 2, report.Added)
+	if assert.Len(t, report.Conflicts, 1) {
+		assert.Equal(t, third.ID, report.Conflicts[0].ID)
+	}
+	assert.Len(t, p.GetSubscriptionsInNamespace("tenant"), 2)
+
+	// replace counts the archive content only
+	report, e = p.ImportFrom(&archive.Archive{Subscriptions: []pubsub.PubSub{stored, third}}, archive.Replace)
+	assert.Nil(t, e)
+	assert.Empty(t, report.Conflicts)
+	reloaded := api.NewAPI(api.WithBackend(b))
+	assert.Len(t, reloaded.GetSubscriptionsInNamespace("tenant"), 2)
+	_, e = reloaded.GetSubscription(third.ID)
+	assert.Nil(t, e)
+}
+
+func TestAPI_AuditLog(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	log := audit.New(b)
+	p := api.NewAPI(api.WithBackend(b), api.WithAuditLog(log), api.WithAuditActor("operator"))
+	sub, e := p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	// an existing subscription is not created again
+	_, e = p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	pub, e := p.CreatePublisher(publisher)
+	assert.Nil(t, e)
+	assert.Nil(t, p.DeleteSubscription(sub.ID))
+	assert.Nil(t, p.DeleteAllPublishers())
+	api.NewAPI(api.WithBackend(b), api.WithAuditLog(log))
+
+	records, e := p.QueryAudit(audit.Query{})
+	assert.Nil(t, e)
+	assert.Len(t, records, 6)
+	assert.Equal(t, audit.ActionReload, records[0].Action)
+	assert.Equal(t, audit.Record{Time: records[1].Time, Action: audit.ActionCreate, Kind: audit.KindSubscription, ID: sub.ID,
+		Namespace: pubsub.DefaultNamespace, Resource: sub.Resource, Actor: "operator"}, records[1])
+	assert.Equal(t, audit.KindPublisher, records[2].Kind)
+	assert.Equal(t, audit.ActionDelete, records[3].Action)
+	assert.Equal(t, pub.ID, records[4].ID)
+	assert.Equal(t, "all publishers deleted", records[4].Reason)
+	assert.Equal(t, audit.ActorSystem, records[5].Actor)
+
+	records, e = p.QueryAudit(audit.Query{ID: sub.ID})
+	assert.Nil(t, e)
+	assert.Len(t, records, 2)
+}
+
+func TestAPI_AuditActorPerCall(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	log := audit.New(b)
+	p := api.NewAPI(api.WithBackend(b), api.WithAuditLog(log), api.WithAuditActor("operator"))
+	sub, e := p.CreateSubscription(subscription, api.AsActor("alice"))
+	assert.Nil(t, e)
+	_, e = p.UpdateSubscription(sub, store.AnyRevision)
+	assert.Nil(t, e)
+	assert.Nil(t, p.DeleteSubscription(sub.ID, api.AsActor("bob")))
+
+	source := api.NewAPI()
+	_, e = source.CreatePublisher(publisher)
+	assert.Nil(t, e)
+	var archived bytes.Buffer
+	assert.Nil(t, source.Export(&archived))
+	_, e = p.Import(bytes.NewReader(archived.Bytes()), archive.Merge, api.AsActor("carol"))
+	assert.Nil(t, e)
+
+	records, e := p.QueryAudit(audit.Query{ID: sub.ID})
+	assert.Nil(t, e)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "alice", records[0].Actor)
+		assert.Equal(t, "operator", records[1].Actor)
+		assert.Equal(t, audit.ActionDelete, records[2].Action)
+		assert.Equal(t, "bob", records[2].Actor)
+	}
+	records, e = p.QueryAudit(audit.Query{Kind: audit.KindPublisher})
+	assert.Nil(t, e)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "carol", records[0].Actor)
+	}
+}
+
+func TestAPI_ReloadStoreUpgradesLegacyFile(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	legacy := `[{"id":"1","endpointUri":"http://localhost:9090/ack/event","resource":"/test/test/1"}]`
+	assert.Nil(t, b.Put("sub.json", []byte(legacy)))
+	p := api.NewAPI(api.WithBackend(b))
+	s, ok := p.HasSubscription("/test/test/1")
+	assert.True(t, ok)
+	assert.Equal(t, "1", s.ID)
+
+	data, e := b.Get("sub.json")
+	assert.Nil(t, e)
+	var env schema.Envelope
+	assert.Nil(t, json.Unmarshal(data, &env))
+	assert.Equal(t, schema.CurrentVersion, env.Version)
+	assert.Equal(t, schema.KindPubSubList, env.Kind)
+}
+
 func TestAPI_CreatePublisher(t *testing.T) {
 	defer clean()
 	p, e := globalInstance.CreatePublisher(publisher)
@@ -191,6 +528,26 @@ func TestAPI_HasSubscription(t *testing.T) {
 	assert.True(t, found)
 	assert.Equal(t, s, fs)
 }
+func TestAPI_ReloadStoreRecoversCorruptedFile(t *testing.T) {
+	defer clean()
+	s, e := globalInstance.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	_, e = globalInstance.CreatePublisher(publisher)
+	assert.Nil(t, e)
+	// a torn write leaves a truncated file behind
+	assert.Nil(t, os.WriteFile("./sub.json", []byte(`[{"SubscriptionId":`), 0600))
+	e = globalInstance.ReloadStore()
+	var corrupted *backend.CorruptionError
+	assert.True(t, errors.As(e, &corrupted))
+	assert.Equal(t, "sub.json", corrupted.Key)
+	assert.True(t, corrupted.Recovered)
+	b, e := globalInstance.GetSubscriptionsFromFile()
+	assert.Nil(t, e)
+	var subs []pubsub.PubSub
+	assert.Nil(t, json.Unmarshal(b, &subs))
+	assert.NotContains(t, subs, s)
+}
+
 func clean() {
 	_ = globalInstance.DeleteAllSubscriptions()
 	_ = globalInstance.DeleteAllPublishers()
@@ -198,4 +555,93 @@ func clean() {
 func TestTeardown(*testing.T) {
 	_ = os.Remove("./pub.json")
 	_ = os.Remove("./sub.json")
+	_ = os.Remove("./pub.json.bak")
+	_ = os.Remove("./sub.json.bak")
+}
+
+func TestAPI_Reconcile(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	p := api.NewAPI(api.WithBackend(b))
+	sub, e := p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	report, e := p.Reconcile()
+	assert.Nil(t, e)
+	assert.Equal(t, archive.Report{Unchanged: 1}, report)
+
+	// another process changes the store
+	other := api.NewAPI(api.WithBackend(b))
+	_, e = other.CreatePublisher(publisher)
+	assert.Nil(t, e)
+	assert.Nil(t, other.DeleteSubscription(sub.ID))
+	report, e = p.Reconcile()
+	assert.Nil(t, e)
+	assert.Equal(t, archive.Report{Added: 1, Removed: 1}, report)
+	assert.Len(t, p.GetPublishers(), 1)
+	assert.Len(t, p.GetSubscriptions(), 0)
+
+	// an unreadable file leaves the store unchanged
+	assert.Nil(t, b.Put("pub.json", []byte("{")))
+	_, e = p.Reconcile()
+	var corruption *backend.CorruptionError
+	assert.True(t, errors.As(e, &corruption))
+	assert.Len(t, p.GetPublishers(), 1)
+
+	assert.ErrorIs(t, p.StartHotReload(make(chan struct{})), backend.ErrExternalWatchUnsupported)
+}
+
+func TestAPI_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	reports := make(chan archive.Report, 10)
+	p := api.NewAPI(api.WithStorePath(dir), api.WithReloadNotifier(reports))
+	sub, e := p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := p.WatchSubscriptions(ctx)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	assert.Nil(t, p.StartHotReload(stopCh))
+
+	other := api.NewAPI(api.WithStorePath(dir))
+	assert.Nil(t, other.DeleteSubscription(sub.ID))
+	select {
+	case report := <-reports:
+		assert.Equal(t, archive.Report{Removed: 1}, report)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the store to be reloaded")
+	}
+	_, ok := p.HasSubscription(sub.Resource)
+	assert.False(t, ok)
+	event := <-events
+	assert.Equal(t, store.Deleted, event.Type)
+	assert.Equal(t, sub.ID, event.Key)
+}
+
+func TestAPI_Snapshot(t *testing.T) {
+	p := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()))
+	sub, e := p.CreateSubscription(subscription)
+	assert.Nil(t, e)
+	snapshot := p.SubscriptionsSnapshot()
+	subs := p.GetSubscriptions()
+	delete(subs, sub.ID)
+	assert.Len(t, p.GetSubscriptions(), 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			s := pubsub.PubSub{EndPointURI: subscription.EndPointURI, Resource: fmt.Sprintf("test/test/%d", i)}
+			_, _ = p.CreateSubscription(s)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		for id, s := range p.GetSubscriptions() {
+			assert.Equal(t, id, s.ID)
+		}
+		_, _ = p.GetFromSubStore("test/test/50")
+	}
+	<-done
+	assert.Equal(t, 1, snapshot.Len())
+	assert.Equal(t, snapshot.Revision()+50, p.SubscriptionsSnapshot().Revision())
+	assert.Equal(t, 51, p.SubscriptionsSnapshot().Len())
 }
@@ -0,0 +1,109 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+)
+
+// StartHotReload reconciles the stores each time the publisher or subscription file is changed
+// by another process, for example an operator editing or restoring sub.json, until stopCh is
+// closed. It fails with backend.ErrExternalWatchUnsupported if the backend can't detect such changes.
+func (p *API) StartHotReload(stopCh <-chan struct{}) error {
+	return backend.OnExternalChange(p.backend, func(key string) bool {
+		return key == p.subFile || key == p.pubFile
+	}, func() {
+		if _, err := p.Reconcile(); err != nil {
+			p.logger.Errorf("failed to reload changed store files: %v", err)
+		}
+	}, stopCh)
+}
+
+// Reconcile re-reads the publisher and subscription files and applies their differences with
+// the stores in memory: records added or changed in a file are set and records missing from it
+// are deleted, watchers see the usual events. A file that can't be read is left out and its
+// error is returned, a corrupted file is replaced by its last good copy as in ReloadStore.
+func (p *API) Reconcile() (report archive.Report, err error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	var errs []error
+	if e := p.reconcileList(p.pubStore, p.pubFile, &report); e != nil {
+		errs = append(errs, e)
+	}
+	if e := p.reconcileList(p.subStore, p.subFile, &report); e != nil {
+		errs = append(errs, e)
+	}
+	err = errors.Join(errs...)
+	if report.Added+report.Updated+report.Removed == 0 {
+		return
+	}
+	p.updateObjectCounts()
+	p.audit.Record(audit.Record{Action: audit.ActionReload, Kind: audit.KindStore, Actor: audit.ActorSystem,
+		Reason: fmt.Sprintf("external change: %d added, %d updated, %d removed", report.Added, report.Updated, report.Removed)})
+	p.notifyReload(report)
+	return
+}
+
+// reconcileList applies the content of the file key to ps, or of its last good copy if the file
+// is corrupted. Callers must hold p.updateMu.
+func (p *API) reconcileList(ps *store.PubSubStore, key string, report *archive.Report) error {
+	list, err := p.loadPubSubs(key)
+	var corruption *backend.CorruptionError
+	if err != nil && !(errors.As(err, &corruption) && corruption.Recovered) {
+		return err
+	}
+	current := map[string]pubsub.PubSub{}
+	for _, s := range listStore(ps) {
+		current[s.ID] = s
+	}
+	for _, s := range list {
+		prev, ok := current[s.ID]
+		delete(current, s.ID)
+		switch {
+		case !ok:
+			report.Added++
+		case archive.SameRecord(prev, s) && prev.GetRevision() == s.GetRevision():
+			report.Unchanged++
+			continue
+		default:
+			report.Updated++
+		}
+		ps.Set(s.ID, s)
+	}
+	for id := range current {
+		ps.Delete(id)
+		report.Removed++
+	}
+	return err
+}
+
+// notifyReload sends the report of an external change to the reload notifier
+func (p *API) notifyReload(report archive.Report) {
+	if p.reloadNotifier == nil {
+		return
+	}
+	select {
+	case p.reloadNotifier <- report:
+	default:
+		p.logger.Warnf("reload notifier is full, dropping report of an external change")
+	}
+}
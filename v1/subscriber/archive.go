@@ -0,0 +1,350 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+var _ archive.Part = (*API)(nil)
+
+// Export writes the subscriber clients and their subscriptions to w as a versioned archive
+func (p *API) Export(w io.Writer) error {
+	a := &archive.Archive{CreatedAt: p.clock.Now().UTC()}
+	if err := p.ExportTo(a); err != nil {
+		return err
+	}
+	return archive.Encode(w, a)
+}
+
+// Import reads an archive written by Export or archive.Export and applies its subscriber
+// clients. Clients and subscriptions that could not be imported are listed in the report
+// conflicts. Imported clients are active.
+func (p *API) Import(r io.Reader, mode archive.Mode, opts ...CallOption) (archive.Report, error) {
+	if err := archive.CheckMode(mode); err != nil {
+		return archive.Report{}, err
+	}
+	a, err := archive.Decode(r)
+	if err != nil {
+		return archive.Report{}, err
+	}
+	return p.ImportFrom(a, mode, opts...)
+}
+
+// ExportTo implements archive.Part
+func (p *API) ExportTo(a *archive.Archive) error {
+	p.SubscriberStore.RLock()
+	clients := make([]subscriber.Subscriber, 0, len(p.SubscriberStore.Store))
+	for _, s := range p.SubscriberStore.Store {
+		c := *subscriber.New(s.ClientID)
+		c.EndPointURI = s.EndPointURI
+		c.Status = s.Status
+		c.LeaseTTL, c.ExpiresAt = s.LeaseTTL, s.ExpiresAt
+		c.Revision = s.Revision
+		c.Namespace = s.Namespace
+		for _, sub := range subscriptions(s) {
+			c.SubStore.Store[sub.ID] = &sub
+		}
+		clients = append(clients, c)
+	}
+	p.SubscriberStore.RUnlock()
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ClientID.String() < clients[j].ClientID.String() })
+	a.Clients = clients
+	return nil
+}
+
+// ImportFrom implements archive.Part. In merge mode the subscriptions of a client that is
+// already stored are added to it unless its endpoint differs.
+func (p *API) ImportFrom(a *archive.Archive, mode archive.Mode, opts ...CallOption) (report archive.Report, err error) {
+	if a.Clients == nil {
+		return
+	}
+	c := p.call(opts)
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+
+	seen := map[uuid.UUID]bool{}
+	usage := map[string]*quotaUsage{}
+	// usageOf returns the clients and subscriptions the namespace will hold once the import is applied
+	usageOf := func(namespace string) *quotaUsage {
+		namespace = pubsub.Namespace(namespace)
+		u, ok := usage[namespace]
+		if !ok {
+			u = &quotaUsage{}
+			if mode == archive.Merge {
+				u.clients, u.subscriptions = p.SubscriberStore.CountNamespace(namespace)
+			}
+			usage[namespace] = u
+		}
+		return u
+	}
+	var changed []subscriber.Subscriber
+	for i := range a.Clients {
+		c := &a.Clients[i]
+		if c.ClientID == uuid.Nil {
+			report.Conflict(archive.SectionClients, c.GetEndPointURI(), "client has no id")
+			continue
+		}
+		if seen[c.ClientID] {
+			report.Conflict(archive.SectionClients, c.ClientID.String(), "id is used more than once in the archive")
+			continue
+		}
+		seen[c.ClientID] = true
+		imported := subscriber.New(c.ClientID)
+		if e := imported.SetEndPointURI(c.GetEndPointURI()); e != nil {
+			report.Conflict(archive.SectionClients, c.ClientID.String(), "invalid endpoint: %v", e)
+			continue
+		}
+		imported.SetStatus(subscriber.Active)
+		imported.LeaseTTL, imported.ExpiresAt = c.LeaseTTL, c.ExpiresAt
+		imported.SetNamespace(c.GetNamespace())
+
+		existing, exists := p.SubscriberStore.Get(c.ClientID)
+		switch {
+		case !exists:
+			p.addSubscriptions(imported, subscriptions(c), &report)
+			if e := p.useQuota(usageOf(imported.GetNamespace()), imported.GetNamespace(), 1, len(imported.SubStore.Store)); e != nil {
+				report.Conflict(archive.SectionClients, c.ClientID.String(), "%v", e)
+				continue
+			}
+			imported.SetRevision(max(c.GetRevision(), 1))
+			report.Added++
+			changed = append(changed, *imported)
+		case mode == archive.Replace:
+			p.addSubscriptions(imported, subscriptions(c), &report)
+			for _, sub := range subscriptions(imported) {
+				// keep revisions increasing for subscriptions that already exist
+				if prev := existing.Get(sub.ID); prev.GetID() != "" {
+					sub.SetCreatedAt(prev.GetCreatedAt())
+					if archive.SameRecord(prev, sub) {
+						sub.SetRevision(prev.GetRevision())
+					} else {
+						sub.SetRevision(prev.GetRevision() + 1)
+					}
+					imported.SubStore.Set(sub.ID, sub)
+				}
+			}
+			if e := p.useQuota(usageOf(imported.GetNamespace()), imported.GetNamespace(), 1, len(imported.SubStore.Store)); e != nil {
+				// the stored client is kept
+				u := usageOf(existing.GetNamespace())
+				u.clients++
+				u.subscriptions += len(subscriptions(&existing))
+				report.Conflict(archive.SectionClients, c.ClientID.String(), "%v", e)
+				continue
+			}
+			if sameClient(&existing, imported) {
+				report.Unchanged++
+				continue
+			}
+			imported.SetRevision(existing.GetRevision() + 1)
+			report.Updated++
+			changed = append(changed, *imported)
+		case existing.GetNamespace() != imported.GetNamespace():
+			report.Conflict(archive.SectionClients, c.ClientID.String(), "namespace %s differs from the stored namespace %s",
+				imported.GetNamespace(), existing.GetNamespace())
+		case existing.GetEndPointURI() != imported.GetEndPointURI():
+			report.Conflict(archive.SectionClients, c.ClientID.String(), "endpoint %s differs from the stored endpoint %s",
+				imported.GetEndPointURI(), existing.GetEndPointURI())
+		default:
+			merged := subscriber.New(c.ClientID)
+			merged.EndPointURI = existing.EndPointURI
+			merged.Status = existing.Status
+			merged.LeaseTTL, merged.ExpiresAt = existing.LeaseTTL, existing.ExpiresAt
+			merged.Namespace = existing.Namespace
+			for _, sub := range subscriptions(&existing) {
+				merged.SubStore.Set(sub.ID, sub)
+			}
+			added := p.addSubscriptions(merged, subscriptions(c), &report)
+			if added == 0 {
+				report.Unchanged++
+				continue
+			}
+			if e := p.useQuota(usageOf(merged.GetNamespace()), merged.GetNamespace(), 0, added); e != nil {
+				report.Conflict(archive.SectionClients, c.ClientID.String(), "%v", e)
+				continue
+			}
+			merged.SetRevision(existing.GetRevision() + 1)
+			report.Updated++
+			changed = append(changed, *merged)
+		}
+	}
+	var removed []uuid.UUID
+	if mode == archive.Replace {
+		p.SubscriberStore.RLock()
+		for clientID := range p.SubscriberStore.Store {
+			if !seen[clientID] {
+				removed = append(removed, clientID)
+			}
+		}
+		p.SubscriberStore.RUnlock()
+	}
+	report.Removed = len(removed)
+	if len(changed) == 0 && len(removed) == 0 {
+		return
+	}
+	p.mu.Lock()
+	if p.journal != nil {
+		// the journal can't be replayed over the imported clients, it is compacted with them
+		err = p.compactWith(changed, removed)
+		p.mu.Unlock()
+		if err == nil {
+			p.applyImport(changed, removed, mode, c)
+		}
+		return
+	}
+	err = backend.Batch(p.backend, func(tx backend.Tx) error {
+		for i := range changed {
+			b, e := encodeSubscriber(&changed[i])
+			if e != nil {
+				return e
+			}
+			if e = tx.Put(fmt.Sprintf("%s.json", changed[i].ClientID), b); e != nil {
+				return e
+			}
+		}
+		for _, clientID := range removed {
+			if e := tx.Delete(fmt.Sprintf("%s.json", clientID)); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+	p.recordWrite(err)
+	p.mu.Unlock()
+	if err != nil {
+		return
+	}
+	p.applyImport(changed, removed, mode, c)
+	return
+}
+
+// addSubscriptions adds the subscriptions missing from client and returns how many were added,
+// subscriptions conflicting with the stored ones are reported
+func (p *API) addSubscriptions(client *subscriber.Subscriber, subs []pubsub.PubSub, report *archive.Report) (added int) {
+	resources := map[string]string{}
+	for _, s := range client.SubStore.Store {
+		resources[s.Resource] = s.ID
+	}
+	for _, sub := range subs {
+		if sub.ID == "" {
+			report.Conflict(archive.SectionSubscriptions, sub.Resource, "subscription of client %s has no id", client.ClientID)
+			continue
+		}
+		if prev, ok := client.SubStore.Store[sub.ID]; ok {
+			if !archive.SameRecord(*prev, sub) {
+				report.Conflict(archive.SectionSubscriptions, sub.ID, "differs from the stored subscription of client %s", client.ClientID)
+			}
+			continue
+		}
+		if id, ok := resources[sub.Resource]; ok {
+			report.Conflict(archive.SectionSubscriptions, sub.ID, "resource %s is already used by %s of client %s", sub.Resource, id, client.ClientID)
+			continue
+		}
+		if sub.GetCreatedAt().IsZero() {
+			sub.SetCreatedAt(p.clock.Now())
+		}
+		if sub.GetRevision() == 0 {
+			sub.SetRevision(1)
+		}
+		sub.SetNamespace(client.GetNamespace())
+		client.SubStore.Set(sub.ID, sub)
+		resources[sub.Resource] = sub.ID
+		added++
+	}
+	return
+}
+
+// quotaUsage counts the clients and subscriptions of a namespace
+type quotaUsage struct {
+	clients, subscriptions int
+}
+
+// useQuota adds clients and subs to u unless they exceed the quota of namespace
+func (p *API) useQuota(u *quotaUsage, namespace string, clients, subs int) error {
+	quota := p.quotas.Get(namespace)
+	if clients > 0 {
+		if err := store.CheckQuota(namespace, store.QuotaClients, quota.MaxClients, u.clients, clients); err != nil {
+			return err
+		}
+	}
+	if subs > 0 {
+		if err := store.CheckQuota(namespace, store.QuotaSubscriptions, quota.MaxSubscriptions, u.subscriptions, subs); err != nil {
+			return err
+		}
+	}
+	u.clients += clients
+	u.subscriptions += subs
+	return nil
+}
+
+// applyImport updates the store with the clients changed by an import
+func (p *API) applyImport(changed []subscriber.Subscriber, removed []uuid.UUID, mode archive.Mode, c store.Call) {
+	reason := fmt.Sprintf("%s import", mode)
+	for _, clientID := range removed {
+		if client, ok := p.SubscriberStore.Get(clientID); ok {
+			p.auditClient(c.Actor, audit.ActionDelete, &client, reason)
+		}
+		p.SubscriberStore.Delete(clientID)
+	}
+	for i, client := range changed {
+		action := audit.ActionUpdate
+		if _, ok := p.SubscriberStore.Get(client.ClientID); !ok {
+			action = audit.ActionCreate
+		}
+		p.SubscriberStore.Set(client.ClientID, client)
+		p.auditClient(c.Actor, action, &changed[i], reason)
+	}
+	p.updateObjectCounts()
+	p.logger.Infof("imported %d clients, removed %d clients", len(changed), len(removed))
+}
+
+// subscriptions returns a copy of the subscriptions of s sorted by id
+func subscriptions(s *subscriber.Subscriber) []pubsub.PubSub {
+	if s.SubStore == nil {
+		return nil
+	}
+	s.SubStore.RLock()
+	defer s.SubStore.RUnlock()
+	subs := make([]pubsub.PubSub, 0, len(s.SubStore.Store))
+	for _, sub := range s.SubStore.Store {
+		subs = append(subs, *sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs
+}
+
+// sameClient reports whether two clients have the same endpoint, namespace and subscriptions
+func sameClient(a, b *subscriber.Subscriber) bool {
+	subsA, subsB := subscriptions(a), subscriptions(b)
+	if a.GetEndPointURI() != b.GetEndPointURI() || a.GetNamespace() != b.GetNamespace() || len(subsA) != len(subsB) {
+		return false
+	}
+	for i := range subsA {
+		if !archive.SameRecord(subsA[i], subsB[i]) {
+			return false
+		}
+	}
+	return true
+}
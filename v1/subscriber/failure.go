@@ -0,0 +1,151 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/channel"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
+	"github.com/redhat-cne/sdk-go/pkg/util/wait"
+)
+
+// backoffConfig is the exponential backoff applied between deliveries after a failure
+type backoffConfig struct {
+	initial, max   time.Duration
+	factor, jitter float64
+}
+
+// clientBackoff delays the deliveries to a client, timer is nil once the backoff elapsed
+type clientBackoff struct {
+	manager wait.BackoffManager
+	timer   clock.Timer
+}
+
+// DeliveryAllowed reports whether an event can be delivered to the client now. Deliveries are
+// not allowed to unknown clients, clients marked for delete or quarantined and while the client
+// backs off after a failed delivery.
+func (p *API) DeliveryAllowed(clientID uuid.UUID) bool {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	client, ok := p.SubscriberStore.Get(clientID)
+	if !ok || client.Action == channel.DELETE || client.IsQuarantined(p.clock.Now()) {
+		return false
+	}
+	b, ok := p.backoffs[clientID]
+	if !ok || b.timer == nil {
+		return true
+	}
+	select {
+	case <-b.timer.C():
+		b.timer = nil
+		return true
+	default:
+		return false
+	}
+}
+
+// onFailure applies the failure policy to a client whose delivery failed at now and returns the
+// audit action of the resulting change, if any. Callers must hold p.updateMu.
+func (p *API) onFailure(client *subscriber.Subscriber, now time.Time) (audit.Action, string) {
+	deliveries := p.recordDelivery(client.ClientID, now, true)
+	switch {
+	case client.Action == channel.DELETE:
+		return "", ""
+	case client.GetStatus() == subscriber.Quarantined:
+		if client.IsQuarantined(now) {
+			return "", ""
+		}
+		client.MarkForDelete()
+		return audit.ActionFail, fmt.Sprintf("delivery failed %d times, first delivery after the quarantine failed", client.FailedCount())
+	case p.failurePolicy.Exceeded(client.GetHealth(), deliveries, now):
+		p.forgetBackoff(client.ClientID)
+		if p.quarantine > 0 {
+			until := now.Add(p.quarantine)
+			client.Quarantine(until)
+			return audit.ActionQuarantine, fmt.Sprintf("delivery failed %d times, quarantined until %s", client.FailedCount(), until.UTC().Format(time.RFC3339))
+		}
+		client.MarkForDelete()
+		return audit.ActionFail, fmt.Sprintf("delivery failed %d times", client.FailedCount())
+	}
+	p.backOff(client.ClientID)
+	return "", ""
+}
+
+// onSuccess records a successful delivery at now and ends the backoff of the client.
+// Callers must hold p.updateMu.
+func (p *API) onSuccess(client *subscriber.Subscriber, now time.Time) {
+	client.RecordSuccess(now)
+	p.recordDelivery(client.ClientID, now, false)
+	p.forgetBackoff(client.ClientID)
+}
+
+// recordDelivery appends a delivery to the history of the client and returns the history
+func (p *API) recordDelivery(clientID uuid.UUID, now time.Time, failed bool) []subscriber.Delivery {
+	if p.deliveries == nil {
+		p.deliveries = map[uuid.UUID][]subscriber.Delivery{}
+	}
+	deliveries := append(p.deliveries[clientID], subscriber.Delivery{Time: now, Failed: failed})
+	if len(deliveries) > subscriber.MaxDeliveryHistory {
+		deliveries = append([]subscriber.Delivery(nil), deliveries[len(deliveries)-subscriber.MaxDeliveryHistory:]...)
+	}
+	p.deliveries[clientID] = deliveries
+	return deliveries
+}
+
+// backOff starts the next backoff of the client if a backoff is configured
+func (p *API) backOff(clientID uuid.UUID) {
+	if p.backoff == nil {
+		return
+	}
+	b, ok := p.backoffs[clientID]
+	if !ok {
+		if p.backoffs == nil {
+			p.backoffs = map[uuid.UUID]*clientBackoff{}
+		}
+		// the backoff is reset by a successful delivery, the reset duration only covers
+		// clients which were not sent any event for a while
+		b = &clientBackoff{manager: wait.NewExponentialBackoffManager(p.backoff.initial, p.backoff.max, 2*p.backoff.max,
+			p.backoff.factor, p.backoff.jitter, p.clock)}
+		p.backoffs[clientID] = b
+	} else if b.timer != nil {
+		// the timer must be drained before the manager reuses it
+		select {
+		case <-b.timer.C():
+		default:
+		}
+	}
+	b.timer = b.manager.Backoff()
+}
+
+func (p *API) forgetBackoff(clientID uuid.UUID) {
+	if b, ok := p.backoffs[clientID]; ok {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		delete(p.backoffs, clientID)
+	}
+}
+
+// forgetClient drops the delivery state kept in memory for a deleted client
+func (p *API) forgetClient(clientID uuid.UUID) {
+	delete(p.healthWritten, clientID)
+	delete(p.deliveries, clientID)
+	p.forgetBackoff(clientID)
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+// HealthPolicy controls whether the delivery health of the clients survives a restart
+type HealthPolicy struct {
+	// Persist writes the status, fail count and last delivery times of the clients to the store
+	// and restores them on ReloadStore. Without it every client is active after a restart.
+	Persist bool
+	// MinWriteInterval limits how often the health of a client is written while its status
+	// does not change, every change is written when zero
+	MinWriteInterval time.Duration
+}
+
+// GetHealth returns the delivery health of the client
+func (p *API) GetHealth(clientID uuid.UUID) (subscriber.Health, error) {
+	if client, ok := p.SubscriberStore.Get(clientID); ok {
+		return client.GetHealth(), nil
+	}
+	return subscriber.Health{}, fmt.Errorf("subscriber data was not found for id %s", clientID)
+}
+
+// persistedHealth returns the health written to the store for client
+func (p *API) persistedHealth(client *subscriber.Subscriber) subscriber.Health {
+	if p.health.Persist {
+		return client.GetHealth()
+	}
+	return subscriber.Health{Status: subscriber.Active}
+}
+
+// restoreHealth sets the health of a client loaded from the store according to the policy
+func (p *API) restoreHealth(client *subscriber.Subscriber) {
+	client.SetHealth(p.persistedHealth(client))
+}
+
+// writeHealth persists the health of client if the policy asks for it. Writes are skipped while
+// the last one is more recent than the policy interval unless force is set.
+// Callers must hold p.updateMu.
+func (p *API) writeHealth(client *subscriber.Subscriber, force bool) error {
+	if !p.health.Persist {
+		return nil
+	}
+	now := p.clock.Now()
+	if last, ok := p.healthWritten[client.ClientID]; ok && !force && now.Sub(last) < p.health.MinWriteInterval {
+		return nil
+	}
+	if p.healthWritten == nil {
+		p.healthWritten = map[uuid.UUID]time.Time{}
+	}
+	p.healthWritten[client.ClientID] = now
+	if p.journal != nil {
+		h := client.GetHealth()
+		return p.writeJournal(JournalRecord{Op: JournalHealth, ClientID: client.ClientID, Health: &h})
+	}
+	return p.writeToBackend(*client, fmt.Sprintf("%s.json", client.ClientID))
+}
@@ -0,0 +1,371 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/pubsub"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+// JournalKey is the backend key holding the subscriber store journal
+const JournalKey = "journal.log"
+
+// CorruptJournalKey is the backend key a journal that can't be replayed is moved to
+const CorruptJournalKey = JournalKey + ".corrupt"
+
+// JournalOp is the kind of mutation recorded in the journal
+type JournalOp string
+
+const (
+	// JournalCreate subscriptions were added to a client, creating it if needed
+	JournalCreate JournalOp = "create"
+	// JournalDelete a single subscription was removed from a client
+	JournalDelete JournalOp = "delete"
+	// JournalDeleteClient a client and all its subscriptions were removed
+	JournalDeleteClient JournalOp = "delete-client"
+	// JournalStatus the status of a client changed
+	JournalStatus JournalOp = "status"
+	// JournalEndpoint the endpoint of a client changed
+	JournalEndpoint JournalOp = "endpoint"
+	// JournalRenew the lease of a client was renewed
+	JournalRenew JournalOp = "renew"
+	// JournalHealth the delivery health of a client changed
+	JournalHealth JournalOp = "health"
+)
+
+// JournalRecord is a single mutation of the subscriber store
+type JournalRecord struct {
+	Seq            uint64             `json:"seq"`
+	Time           time.Time          `json:"time"`
+	Op             JournalOp          `json:"op"`
+	ClientID       uuid.UUID          `json:"clientID"`
+	EndPointURI    string             `json:"endpointUri,omitempty"`
+	Subscriptions  []pubsub.PubSub    `json:"subscriptions,omitempty"`
+	SubscriptionID string             `json:"subscriptionId,omitempty"`
+	Status         *subscriber.Status `json:"status,omitempty"`
+	LeaseTTL       time.Duration      `json:"leaseTTL,omitempty"`
+	ExpiresAt      *time.Time         `json:"expiresAt,omitempty"`
+	// Revision of the client after the mutation
+	Revision uint64 `json:"revision,omitempty"`
+	// Namespace of a client created by the record
+	Namespace string `json:"namespace,omitempty"`
+	// Health of the client, recorded when the health policy persists it
+	Health *subscriber.Health `json:"health,omitempty"`
+}
+
+// journal appends store mutations to the backend instead of rewriting client files.
+// Client files become snapshots which are only rewritten on compaction.
+type journal struct {
+	seq          uint64
+	records      int
+	compactAfter int
+	// torn is set when the journal may end with a partial record, which is cut before the
+	// next append so that the record does not end up in the middle of the journal
+	torn bool
+	// readOnly replays the journal without setting it aside when it is corrupted
+	readOnly bool
+}
+
+// writeJournal records a single mutation
+func (p *API) writeJournal(r JournalRecord) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.appendJournal(r)
+}
+
+// journalCreate records the subscriptions added to a client and any endpoint change
+func (p *API) journalCreate(existed bool, prevEndPointURI string, client *subscriber.Subscriber, added []pubsub.PubSub) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	endPointURI := client.GetEndPointURI()
+	if existed && prevEndPointURI != endPointURI {
+		if err := p.appendJournal(JournalRecord{Op: JournalEndpoint, ClientID: client.ClientID, EndPointURI: endPointURI, Revision: client.Revision}); err != nil {
+			return err
+		}
+	}
+	if existed && len(added) == 0 {
+		if client.ExpiresAt == nil {
+			return nil
+		}
+		return p.appendJournal(JournalRecord{Op: JournalRenew, ClientID: client.ClientID, LeaseTTL: client.LeaseTTL, ExpiresAt: client.ExpiresAt,
+			Revision: client.Revision})
+	}
+	return p.appendJournal(JournalRecord{Op: JournalCreate, ClientID: client.ClientID, EndPointURI: endPointURI, Subscriptions: added,
+		LeaseTTL: client.LeaseTTL, ExpiresAt: client.ExpiresAt, Revision: client.Revision, Namespace: client.Namespace})
+}
+
+// appendJournal records a mutation and compacts the journal once it grows past the threshold.
+// Callers must hold p.mu.
+func (p *API) appendJournal(r JournalRecord) error {
+	if p.journal.torn {
+		if err := p.repairJournal(); err != nil {
+			return err
+		}
+	}
+	r.Seq = p.journal.seq + 1
+	r.Time = p.clock.Now().UTC()
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	err = backend.Append(p.backend, JournalKey, append(b, '\n'))
+	p.recordWrite(err)
+	if err != nil {
+		// part of the record may have been written
+		p.journal.torn = true
+		return err
+	}
+	p.journal.seq = r.Seq
+	p.journal.records++
+	if p.journal.compactAfter > 0 && p.journal.records >= p.journal.compactAfter {
+		// the record is durable, a failed compaction is retried after the next record
+		if err = p.compact(); err != nil {
+			p.logger.Errorf("failed to compact the subscriber journal: %v", err)
+		}
+	}
+	return nil
+}
+
+// repairJournal cuts the partial record at the end of the journal. Callers must hold p.mu.
+func (p *API) repairJournal() error {
+	data, err := p.backend.Get(JournalKey)
+	if errors.Is(err, backend.ErrNotFound) {
+		p.journal.torn = false
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		err = p.backend.Put(JournalKey, data[:end])
+		p.recordWrite(err)
+		if err != nil {
+			return fmt.Errorf("failed to cut the torn record of the journal: %w", err)
+		}
+		p.logger.Warnf("cut a torn record of %d bytes at the end of the subscriber journal", len(data)-end)
+	}
+	p.journal.torn = false
+	return nil
+}
+
+// History returns the store mutations recorded since the last compaction, oldest first
+func (p *API) History() ([]JournalRecord, error) {
+	if p.journal == nil {
+		return nil, fmt.Errorf("journal is not enabled")
+	}
+	records, _, err := readJournal(p.backend)
+	return records, err
+}
+
+// Compact writes every client to its snapshot file and truncates the journal
+func (p *API) Compact() error {
+	if p.journal == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.compact()
+}
+
+func (p *API) compact() error {
+	return p.compactWith(nil, nil)
+}
+
+// compactWith writes the clients in memory to their snapshot files and truncates the journal as
+// compact does, with the clients in changed replacing and the clients in removed deleted from
+// the ones in memory. The store in memory is not updated. Callers must hold p.mu.
+func (p *API) compactWith(changed []subscriber.Subscriber, removed []uuid.UUID) error {
+	p.SubscriberStore.RLock()
+	clients := make(map[uuid.UUID]*subscriber.Subscriber, len(p.SubscriberStore.Store)+len(changed))
+	for clientID, s := range p.SubscriberStore.Store {
+		clients[clientID] = s
+	}
+	for i := range changed {
+		clients[changed[i].ClientID] = &changed[i]
+	}
+	for _, clientID := range removed {
+		delete(clients, clientID)
+	}
+	snapshots := make(map[string][]byte, len(clients))
+	for clientID, s := range clients {
+		persisted := *s
+		persisted.SetHealth(p.persistedHealth(s))
+		b, err := encodeSubscriber(&persisted)
+		if err != nil {
+			p.SubscriberStore.RUnlock()
+			return err
+		}
+		snapshots[fmt.Sprintf("%s.json", clientID)] = b
+	}
+	p.SubscriberStore.RUnlock()
+
+	for key, b := range snapshots {
+		err := p.backend.Put(key, b)
+		p.recordWrite(err)
+		if err != nil {
+			return err
+		}
+	}
+	keys, err := p.backend.List()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, ok := snapshots[key]; !ok && uuid.Validate(strings.Split(key, ".")[0]) == nil {
+			if err = p.deleteAllFromBackend(key); err != nil {
+				return err
+			}
+		}
+	}
+	// replaying the journal over the new snapshots is idempotent, so a crash before
+	// this point only costs a longer replay
+	err = p.backend.Put(JournalKey, []byte{})
+	p.recordWrite(err)
+	if err != nil {
+		return err
+	}
+	p.journal.records = 0
+	p.journal.torn = false
+	p.logger.Infof("compacted subscriber journal into %d client snapshots", len(snapshots))
+	return nil
+}
+
+// replayJournal applies the journal on top of the client snapshots loaded by ReloadStore
+func (p *API) replayJournal() error {
+	records, torn, err := readJournal(p.backend)
+	p.journal.torn = torn
+	for _, r := range records {
+		p.applyJournalRecord(r)
+		if r.Seq > p.journal.seq {
+			p.journal.seq = r.Seq
+		}
+	}
+	p.journal.records = len(records)
+	var corruption *backend.CorruptionError
+	if errors.As(err, &corruption) && !p.journal.readOnly {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if e := p.setAsideJournal(); e != nil {
+			return errors.Join(err, e)
+		}
+	}
+	return err
+}
+
+// setAsideJournal moves a journal that can't be replayed to CorruptJournalKey and compacts the
+// clients in memory, so that new records are never written behind a record that can't be read.
+// Callers must hold p.mu.
+func (p *API) setAsideJournal() error {
+	data, err := p.backend.Get(JournalKey)
+	if err != nil {
+		return err
+	}
+	err = p.backend.Put(CorruptJournalKey, data)
+	p.recordWrite(err)
+	if err != nil {
+		return fmt.Errorf("failed to set the corrupted journal aside: %w", err)
+	}
+	p.logger.Warnf("subscriber journal is corrupted, moved it to %s", CorruptJournalKey)
+	return p.compact()
+}
+
+func (p *API) applyJournalRecord(r JournalRecord) {
+	switch r.Op {
+	case JournalCreate, JournalEndpoint:
+		client, ok := p.SubscriberStore.Get(r.ClientID)
+		if !ok {
+			if r.Op == JournalEndpoint {
+				return
+			}
+			client = *subscriber.New(r.ClientID)
+			client.SetStatus(subscriber.Active)
+			client.SetNamespace(r.Namespace)
+		}
+		if r.EndPointURI != "" {
+			_ = client.SetEndPointURI(r.EndPointURI)
+		}
+		for _, sub := range r.Subscriptions {
+			client.SubStore.Set(sub.ID, sub)
+		}
+		if r.Op == JournalCreate && r.ExpiresAt != nil {
+			client.LeaseTTL, client.ExpiresAt = r.LeaseTTL, r.ExpiresAt
+		}
+		setRevision(&client, r.Revision)
+		p.SubscriberStore.Set(r.ClientID, client)
+	case JournalRenew:
+		if client, ok := p.SubscriberStore.Get(r.ClientID); ok {
+			client.LeaseTTL, client.ExpiresAt = r.LeaseTTL, r.ExpiresAt
+			setRevision(&client, r.Revision)
+			p.SubscriberStore.Set(r.ClientID, client)
+		}
+	case JournalDelete:
+		if client, ok := p.SubscriberStore.Get(r.ClientID); ok {
+			client.SubStore.Delete(r.SubscriptionID)
+			setRevision(&client, r.Revision)
+			p.SubscriberStore.Set(r.ClientID, client)
+		}
+	case JournalDeleteClient:
+		p.SubscriberStore.Delete(r.ClientID)
+	case JournalStatus:
+		// status changes are kept for history only, the health records restore the status
+	case JournalHealth:
+		if client, ok := p.SubscriberStore.Get(r.ClientID); ok && r.Health != nil && p.health.Persist {
+			client.SetHealth(*r.Health)
+			p.SubscriberStore.Set(r.ClientID, client)
+		}
+	}
+}
+
+// setRevision restores the revision recorded by the journal, records written before
+// revisions were introduced leave it unchanged
+func setRevision(client *subscriber.Subscriber, revision uint64) {
+	if revision != 0 {
+		client.SetRevision(revision)
+	}
+}
+
+// readJournal decodes the journal. Every record ends with a newline, a last record without
+// one was torn by an interrupted append: it is ignored and torn is set.
+func readJournal(b backend.Backend) (records []JournalRecord, torn bool, err error) {
+	data, err := b.Get(JournalKey)
+	if errors.Is(err, backend.ErrNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		torn = len(bytes.TrimSpace(data[end:])) > 0
+		data = data[:end]
+	}
+	for i, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var r JournalRecord
+		if err = json.Unmarshal(line, &r); err != nil {
+			return records, torn, &backend.CorruptionError{Key: JournalKey, Err: fmt.Errorf("record %d: %w", i+1, err)}
+		}
+		records = append(records, r)
+	}
+	return records, torn, nil
+}
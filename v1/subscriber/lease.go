@@ -0,0 +1,135 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/channel"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+// RenewSubscription extends the lease of a client by its ttl and returns the new expiry time
+func (p *API) RenewSubscription(clientID uuid.UUID) (time.Time, error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	client, ok := p.SubscriberStore.Get(clientID)
+	if !ok {
+		return time.Time{}, fmt.Errorf("subscriber data was not found for id %s", clientID)
+	}
+	if client.GetLeaseTTL() <= 0 {
+		return time.Time{}, fmt.Errorf("subscriber %s has no lease", clientID)
+	}
+	client.RenewLease(p.clock.Now())
+	client.SetRevision(client.GetRevision() + 1)
+	p.SubscriberStore.Set(clientID, client)
+	var err error
+	if p.journal != nil {
+		err = p.writeJournal(JournalRecord{Op: JournalRenew, ClientID: clientID, LeaseTTL: client.LeaseTTL, ExpiresAt: client.ExpiresAt,
+			Revision: client.GetRevision()})
+	} else {
+		err = p.writeToBackend(client, fmt.Sprintf("%s.json", clientID))
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return *client.ExpiresAt, nil
+}
+
+// ReapExpiredSubscriptions deletes every client whose lease expired from memory and disk and
+// returns their ids. A DELETE notification is sent for each of them when a notifier is set.
+func (p *API) ReapExpiredSubscriptions() []uuid.UUID {
+	now := p.clock.Now()
+	var expired []uuid.UUID
+	p.SubscriberStore.RLock()
+	for clientID, s := range p.SubscriberStore.Store {
+		if s.IsExpired(now) {
+			expired = append(expired, clientID)
+		}
+	}
+	p.SubscriberStore.RUnlock()
+
+	var reaped []uuid.UUID
+	for _, clientID := range expired {
+		client, ok := p.reapExpired(clientID, now)
+		if !ok {
+			continue
+		}
+		p.logger.Infof("subscriber %s lease expired at %s, subscriptions deleted", clientID, client.ExpiresAt)
+		reaped = append(reaped, clientID)
+		p.notifyExpired(clientID, client.GetEndPointURI())
+	}
+	return reaped
+}
+
+// reapExpired deletes the client if its lease is still expired, the check and the delete hold
+// p.updateMu so that a concurrent renewal keeps the client
+func (p *API) reapExpired(clientID uuid.UUID, now time.Time) (subscriber.Subscriber, bool) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	client, ok := p.SubscriberStore.Get(clientID)
+	if !ok || !client.IsExpired(now) {
+		return client, false
+	}
+	reason := fmt.Sprintf("lease expired at %s", client.ExpiresAt.UTC().Format(time.RFC3339))
+	if err := p.deleteClientLocked(clientID, client.GetRevision(), audit.ActionAutoDelete, reason, store.Call{Actor: audit.ActorSystem}); err != nil {
+		p.logger.Errorf("failed to delete expired subscriber %s: %v", clientID, err)
+		return client, false
+	}
+	return client, true
+}
+
+// StartLeaseReaper runs ReapExpiredSubscriptions now and then every period of the API clock until
+// stopCh is closed
+func (p *API) StartLeaseReaper(period time.Duration, stopCh <-chan struct{}) {
+	ticker := p.clock.NewTicker(period)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+			p.ReapExpiredSubscriptions()
+			select {
+			case <-ticker.C():
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// notifyExpired tells the notifier that an expired client was deleted
+func (p *API) notifyExpired(clientID uuid.UUID, endPointURI string) {
+	if p.expiryNotifier == nil {
+		return
+	}
+	select {
+	case p.expiryNotifier <- &channel.DataChan{
+		ClientID: clientID,
+		Address:  endPointURI,
+		Status:   channel.DELETE,
+		Type:     channel.SUBSCRIBER,
+	}:
+	default:
+		p.logger.Warnf("expiry notifier is full, dropping notification for subscriber %s", clientID)
+	}
+}
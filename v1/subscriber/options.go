@@ -0,0 +1,185 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redhat-cne/sdk-go/pkg/channel"
+	"github.com/redhat-cne/sdk-go/pkg/localmetrics"
+	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/encryption"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
+	log "github.com/sirupsen/logrus"
+)
+
+// Option configures an API created by NewAPI
+type Option func(*API)
+
+// WithStorePath persists subscriber clients as files in path.
+// It is ignored if WithBackend is also given.
+func WithStorePath(path string) Option {
+	return func(p *API) {
+		p.storeFilePath = path
+	}
+}
+
+// WithBackend persists subscriber clients to b
+func WithBackend(b backend.Backend) Option {
+	return func(p *API) {
+		p.backend = b
+	}
+}
+
+// WithLogger sets the logger used by the API, defaults to the logrus standard logger
+func WithLogger(l log.FieldLogger) Option {
+	return func(p *API) {
+		p.logger = l
+	}
+}
+
+// WithClock sets the clock used by the API, defaults to the real clock
+func WithClock(c clock.Clock) Option {
+	return func(p *API) {
+		p.clock = c
+	}
+}
+
+// WithMetricsRegistry registers the store metrics with reg, by default no metrics are collected
+func WithMetricsRegistry(reg prometheus.Registerer) Option {
+	return func(p *API) {
+		p.metrics = localmetrics.NewStoreMetrics(reg)
+	}
+}
+
+// WithJournal appends store mutations to a journal instead of rewriting client files on every
+// change. Client files are rewritten as snapshots once compactAfter records were written;
+// with compactAfter <= 0 compaction only happens on Compact and the full history is kept.
+// GetSubscriptionsFromFile returns the last snapshot while the journal is enabled.
+func WithJournal(compactAfter int) Option {
+	return func(p *API) {
+		p.journal = &journal{compactAfter: compactAfter}
+	}
+}
+
+// WithExpiryNotifier sends a DELETE notification of type SUBSCRIBER to ch for every client removed
+// because its lease expired. Notifications are dropped when ch is full.
+func WithExpiryNotifier(ch chan<- *channel.DataChan) Option {
+	return func(p *API) {
+		p.expiryNotifier = ch
+	}
+}
+
+// WithQuota limits the number of clients and subscriptions of namespace
+func WithQuota(namespace string, quota store.Quota) Option {
+	return func(p *API) {
+		p.quotas.Set(namespace, quota)
+	}
+}
+
+// WithDefaultQuota limits the number of clients and subscriptions of the namespaces without their own quota
+func WithDefaultQuota(quota store.Quota) Option {
+	return func(p *API) {
+		p.quotas.SetDefault(quota)
+	}
+}
+
+// WithAuditLog records every change of the clients and their subscriptions in l, including
+// the automatic deletions after delivery failures and expired leases
+func WithAuditLog(l *audit.Log) Option {
+	return func(p *API) {
+		p.audit = l
+	}
+}
+
+// WithAuditActor sets the actor recorded for the changes requested through the API,
+// defaults to audit.ActorAPI
+func WithAuditActor(actor string) Option {
+	return func(p *API) {
+		p.auditActor = actor
+	}
+}
+
+// CallOption configures a single call of the API
+type CallOption = store.CallOption
+
+// AsActor records actor as the author of the changes made by the call instead of the actor set
+// with WithAuditActor, a REST layer passes the identity of each request
+func AsActor(actor string) CallOption {
+	return store.AsActor(actor)
+}
+
+// call returns the options of a call with the defaults of the API
+func (p *API) call(opts []CallOption) store.Call {
+	return store.NewCall(p.auditActor, opts...)
+}
+
+// WithHealthPolicy sets whether the delivery health of the clients is persisted, by default every
+// client is active after a restart
+func WithHealthPolicy(policy HealthPolicy) Option {
+	return func(p *API) {
+		p.health = policy
+	}
+}
+
+// WithFailurePolicy decides when a client failed too many deliveries, defaults to
+// subscriber.ConsecutiveFailures(subscriber.SetConnectionToFailAfter)
+func WithFailurePolicy(policy subscriber.FailurePolicy) Option {
+	return func(p *API) {
+		p.failurePolicy = policy
+	}
+}
+
+// WithQuarantine quarantines a client exceeding the failure policy for d before deleting it. No
+// event is delivered during the quarantine and the client is marked for delete if the first
+// delivery after it fails. By default the client is marked for delete as soon as the policy is
+// exceeded.
+func WithQuarantine(d time.Duration) Option {
+	return func(p *API) {
+		p.quarantine = d
+	}
+}
+
+// WithBackoff delays the next delivery to a client after each failed delivery, starting at
+// initial and multiplying the delay by factor up to maxBackoff. Each delay is jittered by up to
+// jitter times its duration.
+func WithBackoff(initial, maxBackoff time.Duration, factor, jitter float64) Option {
+	return func(p *API) {
+		p.backoff = &backoffConfig{initial: initial, max: maxBackoff, factor: factor, jitter: jitter}
+	}
+}
+
+// WithEncryption encrypts everything the API persists with the keys of keys, values stored
+// in plaintext before are read and encrypted on their next write unless opts include
+// encryption.RequireEncryption
+func WithEncryption(keys encryption.KeyProvider, opts ...encryption.Option) Option {
+	return func(p *API) {
+		p.keys = keys
+		p.encryptionOpts = opts
+	}
+}
+
+// WithReloadNotifier sends to ch the changes applied each time the store changed by another
+// process is reloaded, see StartHotReload. Reports are dropped when ch is full.
+func WithReloadNotifier(ch chan<- archive.Report) Option {
+	return func(p *API) {
+		p.reloadNotifier = ch
+	}
+}
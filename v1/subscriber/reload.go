@@ -0,0 +1,152 @@
+// Copyright 2020 The Cloud Native Events Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriber
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	SubscriberStore "github.com/redhat-cne/sdk-go/pkg/store/subscriber"
+	"github.com/redhat-cne/sdk-go/pkg/subscriber"
+)
+
+// StartHotReload reconciles the store each time a client file or the journal is changed by
+// another process, for example an operator restoring a backup of the store directory, until
+// stopCh is closed. It fails with backend.ErrExternalWatchUnsupported if the backend can't
+// detect such changes.
+func (p *API) StartHotReload(stopCh <-chan struct{}) error {
+	return backend.OnExternalChange(p.backend, func(key string) bool {
+		return key == JournalKey || uuid.Validate(strings.Split(key, ".")[0]) == nil
+	}, func() {
+		if _, err := p.Reconcile(); err != nil {
+			p.logger.Errorf("failed to reload changed store files: %v", err)
+		}
+	}, stopCh)
+}
+
+// Reconcile re-reads the store and applies its differences with the clients in memory: clients
+// added or changed in the store are set and clients missing from it are deleted, watchers see the
+// usual events. Nothing is changed if the store can't be read completely. Unless the health policy
+// persists it, the delivery health of the clients kept in memory is preserved.
+func (p *API) Reconcile() (report archive.Report, err error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	shadow := &API{
+		SubscriberStore: &SubscriberStore.Store{
+			RWMutex: sync.RWMutex{},
+			Store:   map[uuid.UUID]*subscriber.Subscriber{},
+		},
+		storeFilePath: p.storeFilePath,
+		backend:       p.backend,
+		logger:        p.logger,
+		clock:         p.clock,
+		health:        p.health,
+	}
+	if p.journal != nil {
+		shadow.journal = &journal{readOnly: true}
+	}
+	if err = shadow.ReloadStore(); err != nil {
+		var corruption *backend.CorruptionError
+		if p.journal != nil && errors.As(err, &corruption) && corruption.Key == JournalKey {
+			// the clients in memory are kept, new records must not follow the unreadable one
+			p.mu.Lock()
+			if e := p.setAsideJournal(); e != nil {
+				err = errors.Join(err, e)
+			}
+			p.mu.Unlock()
+		}
+		return
+	}
+
+	current := map[uuid.UUID]subscriber.Subscriber{}
+	p.SubscriberStore.RLock()
+	for clientID, client := range p.SubscriberStore.Store {
+		current[clientID] = *client
+	}
+	p.SubscriberStore.RUnlock()
+	for clientID, client := range shadow.SubscriberStore.Store {
+		prev, ok := current[clientID]
+		delete(current, clientID)
+		if ok && !p.health.Persist {
+			client.SetHealth(prev.GetHealth())
+		}
+		switch {
+		case !ok:
+			report.Added++
+		case sameState(&prev, client):
+			report.Unchanged++
+			continue
+		default:
+			report.Updated++
+		}
+		p.SubscriberStore.Set(clientID, *client)
+	}
+	for clientID := range current {
+		p.SubscriberStore.Delete(clientID)
+		p.forgetClient(clientID)
+		report.Removed++
+	}
+	if p.journal != nil {
+		p.mu.Lock()
+		if shadow.journal.seq > p.journal.seq {
+			p.journal.seq = shadow.journal.seq
+		}
+		p.journal.records = shadow.journal.records
+		p.journal.torn = p.journal.torn || shadow.journal.torn
+		p.mu.Unlock()
+	}
+	if report.Added+report.Updated+report.Removed == 0 {
+		return
+	}
+	p.updateObjectCounts()
+	p.audit.Record(audit.Record{Action: audit.ActionReload, Kind: audit.KindStore, Actor: audit.ActorSystem,
+		Reason: fmt.Sprintf("external change: %d added, %d updated, %d removed", report.Added, report.Updated, report.Removed)})
+	p.notifyReload(report)
+	return
+}
+
+// sameState reports whether two copies of a client have the same subscriptions, lease and health
+func sameState(a, b *subscriber.Subscriber) bool {
+	ha, hb := a.GetHealth(), b.GetHealth()
+	return sameClient(a, b) && a.GetRevision() == b.GetRevision() && a.LeaseTTL == b.LeaseTTL && sameTime(a.ExpiresAt, b.ExpiresAt) &&
+		ha.Status == hb.Status && ha.Action == hb.Action && ha.Failures == hb.Failures && sameTime(ha.LastSuccess, hb.LastSuccess) &&
+		sameTime(ha.LastFailure, hb.LastFailure) && sameTime(ha.QuarantinedUntil, hb.QuarantinedUntil)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// notifyReload sends the report of an external change to the reload notifier
+func (p *API) notifyReload(report archive.Report) {
+	if p.reloadNotifier == nil {
+		return
+	}
+	select {
+	case p.reloadNotifier <- report:
+	default:
+		p.logger.Warnf("reload notifier is full, dropping report of an external change")
+	}
+}
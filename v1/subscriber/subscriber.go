@@ -1,20 +1,28 @@
 package subscriber
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/redhat-cne/sdk-go/pkg/channel"
+	"github.com/redhat-cne/sdk-go/pkg/localmetrics"
 	"github.com/redhat-cne/sdk-go/pkg/types"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
 
-	"github.com/pkg/errors"
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
 	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/encryption"
+	"github.com/redhat-cne/sdk-go/pkg/store/query"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
 
 	log "github.com/sirupsen/logrus"
 
@@ -26,12 +34,37 @@ import (
 type API struct {
 	SubscriberStore  *SubscriberStore.Store //  each client will have one store
 	storeFilePath    string                 // subscribers
+	backend          backend.Backend        // persistence for subscribers
+	keys             encryption.KeyProvider // encrypts the backend when set
+	encryptionOpts   []encryption.Option    // options of the encrypted backend
 	transportEnabled bool                   //  http  is enabled
+	logger           log.FieldLogger
+	clock            clock.Clock
+	metrics          *localmetrics.StoreMetrics
+	journal          *journal
+	expiryNotifier   chan<- *channel.DataChan
+	// mu serializes read-modify-write cycles on the backend
+	mu sync.Mutex
+	// updateMu makes revision checks and the following update of a client atomic
+	updateMu   sync.Mutex
+	quotas     store.Quotas
+	audit      *audit.Log
+	auditActor string
+	health     HealthPolicy
+	// healthWritten is the time the health of each client was last written
+	healthWritten map[uuid.UUID]time.Time
+	failurePolicy subscriber.FailurePolicy
+	quarantine    time.Duration
+	backoff       *backoffConfig
+	// deliveries and backoffs of the clients, guarded by updateMu
+	deliveries map[uuid.UUID][]subscriber.Delivery
+	backoffs   map[uuid.UUID]*clientBackoff
+	// reloadNotifier receives the changes applied by hot reload
+	reloadNotifier chan<- archive.Report
 }
 
 var instance *API
 var once sync.Once
-var mu sync.Mutex
 
 // NewSubscriber create new subscribers connections
 func NewSubscriber(clientID uuid.UUID) subscriber.Subscriber {
@@ -51,55 +84,130 @@ func New() subscriber.Subscriber {
 	return subscriber.Subscriber{}
 }
 
-// GetAPIInstance get event instance
+// NewAPI creates an independent subscriber API and loads its store.
+// Without WithBackend or WithStorePath the API keeps its state in memory only.
+func NewAPI(opts ...Option) *API {
+	p := &API{
+		transportEnabled: true,
+		SubscriberStore: &SubscriberStore.Store{
+			RWMutex: sync.RWMutex{},
+			Store:   map[uuid.UUID]*subscriber.Subscriber{},
+		},
+		logger:        log.StandardLogger(),
+		clock:         clock.RealClock{},
+		auditActor:    audit.ActorAPI,
+		failurePolicy: subscriber.ConsecutiveFailures(subscriber.SetConnectionToFailAfter),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.backend == nil {
+		if p.storeFilePath != "" {
+			p.backend = backend.NewFileBackend(p.storeFilePath, backend.WithBackupCheck(p.checkBackup))
+		} else {
+			p.backend = backend.NewMemoryBackend()
+		}
+	}
+	if p.keys != nil {
+		p.backend = encryption.NewBackend(p.backend, p.keys, append([]encryption.Option{encryption.WithLogger(p.logger)}, p.encryptionOpts...)...)
+	}
+	if err := p.ReloadStore(); err != nil {
+		p.logger.Errorf("error reloading store %s: %v", p.storeFilePath, err)
+	}
+	return p
+}
+
+// checkBackup accepts the content of a store file as backup if it decrypts and holds valid JSON
+func (p *API) checkBackup(key string, data []byte) (err error) {
+	if e, ok := p.backend.(*encryption.Backend); ok {
+		if data, err = e.Decrypt(key, data); err != nil {
+			return err
+		}
+	}
+	return backend.ValidJSON(key, data)
+}
+
+// GetAPIInstance get event instance.
+// The first call creates a shared instance, later calls reload and return it regardless of
+// storeFilePath; use NewAPI for independent instances.
 func GetAPIInstance(storeFilePath string) *API {
+	created := false
+	once.Do(func() {
+		instance = NewAPI(WithStorePath(storeFilePath))
+		created = true
+	})
+	if !created {
+		if err := instance.ReloadStore(); err != nil {
+			instance.logger.Errorf("error reloading store %s: %v", storeFilePath, err)
+		}
+	}
+	return instance
+}
+
+// GetAPIInstanceWithBackend get event instance persisting to the given backend
+func GetAPIInstanceWithBackend(b backend.Backend) *API {
+	created := false
 	once.Do(func() {
-		instance = &API{
-			transportEnabled: true,
-			SubscriberStore: &SubscriberStore.Store{
-				RWMutex: sync.RWMutex{},
-				Store:   map[uuid.UUID]*subscriber.Subscriber{},
-			},
-			storeFilePath: storeFilePath,
-		}
-		hasDir(storeFilePath)
+		instance = NewAPI(WithBackend(b))
+		created = true
 	})
-	instance.ReloadStore()
+	if !created {
+		if err := instance.ReloadStore(); err != nil {
+			instance.logger.Errorf("error reloading store: %v", err)
+		}
+	}
 	return instance
 }
 
-// ReloadStore reload store if there is any change or refresh is required
-func (p *API) ReloadStore() {
+// ReloadStore reload store if there is any change or refresh is required.
+// A corrupted client file is replaced by its last good copy when one exists; corruption is
+// reported as a *backend.CorruptionError in the returned error. Client files written in an
+// older layout are upgraded to the current schema version. With the journal enabled the
+// recorded mutations are replayed on top of the client files.
+func (p *API) ReloadStore() error {
 	// load for file
-	log.Infof("reloading subscribers from the store %s", p.storeFilePath)
-	if files, err := loadFileNamesFromDir(p.storeFilePath); err == nil {
-		for _, f := range files {
-			// valid subscription filename is <uuid>.json
-			if uuid.Validate(strings.Split(f, ".")[0]) != nil {
-				continue
-			} else if b, err1 := loadFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, f)); err1 == nil {
-				if len(b) > 0 {
-					var sub subscriber.Subscriber
-					var err2 error
-					if err2 = json.Unmarshal(b, &sub); err2 == nil {
-						if sub.ClientID != uuid.Nil {
-							p.SubscriberStore.Set(sub.ClientID, sub)
-						} else {
-							log.Errorf("subscriber data from file %s is not valid", f)
-						}
-					} else {
-						log.Errorf("error parsing subscriber %s\n %s", string(b), err2.Error())
-					}
-				}
-			} else {
-				log.Errorf("error loading file %s\n %s", fmt.Sprintf("%s/%s", p.storeFilePath, f), err1.Error())
-			}
+	p.logger.Infof("reloading subscribers from the store %s", p.storeFilePath)
+	files, err := p.backend.List()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, f := range files {
+		// valid subscription filename is <uuid>.json
+		if uuid.Validate(strings.Split(f, ".")[0]) != nil {
+			continue
+		}
+		sub, err1 := p.loadSubscriber(f)
+		if err1 != nil {
+			errs = append(errs, err1)
+		}
+		if sub != nil {
+			p.restoreHealth(sub)
+			p.SubscriberStore.Set(sub.ClientID, *sub)
+		}
+	}
+	if p.journal != nil {
+		if err = p.replayJournal(); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	log.Infof("%d registered clients reloaded", len(p.SubscriberStore.Store))
+	p.updateObjectCounts()
+	p.logger.Infof("%d registered clients reloaded", len(p.SubscriberStore.Store))
 	for k, v := range p.SubscriberStore.Store {
-		log.Infof("registered clients %s : %s", k, v.String())
+		p.logger.Infof("registered clients %s : %s", k, v.String())
+	}
+	err = errors.Join(errs...)
+	reason := fmt.Sprintf("loaded %d clients", len(p.SubscriberStore.Store))
+	if err != nil {
+		reason = fmt.Sprintf("%s: %v", reason, err)
 	}
+	p.audit.Record(audit.Record{Action: audit.ActionReload, Kind: audit.KindStore, Actor: audit.ActorSystem, Reason: reason})
+	return err
+}
+
+// Watch streams client changes, including those made by ReloadStore, until ctx is cancelled
+func (p *API) Watch(ctx context.Context) <-chan SubscriberStore.Event {
+	return p.SubscriberStore.Watch(ctx)
 }
 
 // HasTransportEnabled ...
@@ -125,15 +233,8 @@ func (p *API) ClientCount() int {
 // GetSubFromSubscriptionsStore get data from publisher store
 func (p *API) GetSubFromSubscriptionsStore(clientID uuid.UUID, address string) (pubsub.PubSub, error) {
 	if subscriber, ok := p.HasClient(clientID); ok {
-		for _, sub := range subscriber.SubStore.Store {
-			if sub.GetResource() == address {
-				return pubsub.PubSub{
-					ID:          sub.ID,
-					EndPointURI: sub.EndPointURI,
-					URILocation: sub.URILocation,
-					Resource:    sub.Resource,
-				}, nil
-			}
+		if sub, found := subscriber.SubStore.GetByResource(address); found {
+			return sub, nil
 		}
 	}
 
@@ -156,18 +257,52 @@ func (p *API) HasClient(clientID uuid.UUID) (*subscriber.Subscriber, bool) {
 	return nil, false
 }
 
-// CreateSubscription create a subscriptionOne and store it in a file and cache
-func (p *API) CreateSubscription(clientID uuid.UUID, sub subscriber.Subscriber) (subscriptionClient *subscriber.Subscriber, err error) {
+// CreateSubscription create a subscriptionOne and store it in a file and cache.
+// A lease set on sub with SetLeaseTTL replaces the lease of the client, creating a
+// subscription for a client with a lease renews it.
+func (p *API) CreateSubscription(clientID uuid.UUID, sub subscriber.Subscriber, opts ...CallOption) (subscriptionClient *subscriber.Subscriber, err error) {
+	return p.createSubscription(clientID, sub, store.AnyRevision, p.call(opts))
+}
+
+// CreateSubscriptionIfMatch adds the subscriptions of sub to the client if the revision of the
+// client is ifMatch. A revision mismatch returns a *store.ConflictError.
+func (p *API) CreateSubscriptionIfMatch(clientID uuid.UUID, sub subscriber.Subscriber, ifMatch uint64, opts ...CallOption) (*subscriber.Subscriber, error) {
+	return p.createSubscription(clientID, sub, ifMatch, p.call(opts))
+}
+
+func (p *API) createSubscription(clientID uuid.UUID, sub subscriber.Subscriber, ifMatch uint64, c store.Call) (subscriptionClient *subscriber.Subscriber, err error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
 	var ok bool
+	var prevEndPointURI string
 	if subscriptionClient, ok = p.HasClient(clientID); !ok {
 		subscriptionClient = subscriber.New(clientID)
+	} else {
+		prevEndPointURI = subscriptionClient.GetEndPointURI()
+	}
+	if err = store.CheckRevision(clientID.String(), ifMatch, subscriptionClient.GetRevision()); err != nil {
+		return nil, err
+	}
+	namespace := sub.GetNamespace()
+	if ok && subscriptionClient.GetNamespace() != namespace {
+		return nil, fmt.Errorf("client %s belongs to namespace %s", clientID, subscriptionClient.GetNamespace())
 	}
+	subscriptionClient.SetNamespace(namespace)
+	prevHealth := subscriptionClient.GetHealth()
+	subscriptionClient.SetRevision(subscriptionClient.GetRevision() + 1)
 	subscriptionClient.ResetFailCount()
 	_ = subscriptionClient.SetEndPointURI(sub.GetEndPointURI())
 	subscriptionClient.SetStatus(subscriber.Active)
+	subscriptionClient.QuarantinedUntil = nil
 	subscriptionClient.Action = channel.NEW
+	if sub.GetLeaseTTL() > 0 {
+		subscriptionClient.SetLeaseTTL(sub.GetLeaseTTL())
+	}
+	subscriptionClient.RenewLease(p.clock.Now())
 	pubStore := subscriptionClient.GetSubStore()
 	var hasResource bool
+	var added []pubsub.PubSub
+	var keys []string
 	for key, value := range sub.SubStore.Store {
 		hasResource = false
 		for _, s := range pubStore.Store {
@@ -176,23 +311,62 @@ func (p *API) CreateSubscription(clientID uuid.UUID, sub subscriber.Subscriber)
 				continue
 			}
 		}
+		for _, s := range added {
+			if s.Resource == value.Resource {
+				hasResource = true
+			}
+		}
 		if !hasResource {
 			if key == "" {
 				key = uuid.New().String()
 			}
-			subscriptionClient.SubStore.Set(key, *value)
+			newSub := *value
+			if newSub.GetCreatedAt().IsZero() {
+				newSub.SetCreatedAt(p.clock.Now())
+			}
+			newSub.SetRevision(1)
+			newSub.SetNamespace(namespace)
+			keys = append(keys, key)
+			added = append(added, newSub)
+		}
+	}
+	quota := p.quotas.Get(namespace)
+	clients, subscriptions := p.SubscriberStore.CountNamespace(namespace)
+	if !ok {
+		if err = store.CheckQuota(namespace, store.QuotaClients, quota.MaxClients, clients, 1); err != nil {
+			return nil, err
 		}
 	}
+	if err = store.CheckQuota(namespace, store.QuotaSubscriptions, quota.MaxSubscriptions, subscriptions, len(added)); err != nil {
+		return nil, err
+	}
+	for i := range added {
+		subscriptionClient.SubStore.Set(keys[i], added[i])
+		added[i].SetID(keys[i])
+	}
 	p.SubscriberStore.Set(clientID, *subscriptionClient)
 	// persist the subscriptionOne -
-	err = writeToFile(*subscriptionClient, fmt.Sprintf("%s/%s", p.storeFilePath, fmt.Sprintf("%s.json", clientID)))
+	if p.journal != nil {
+		err = p.journalCreate(ok, prevEndPointURI, subscriptionClient, added)
+		if err == nil && (prevHealth.Failures != 0 || prevHealth.Status != subscriber.Active) {
+			// the client is active again
+			err = p.writeHealth(subscriptionClient, true)
+		}
+	} else {
+		err = p.writeToBackend(*subscriptionClient, fmt.Sprintf("%s.json", clientID))
+	}
 	if err != nil {
-		log.Errorf("error writing to a store %v\n", err)
+		p.logger.Errorf("error writing to a store %v\n", err)
 		return nil, err
 	}
-	log.Infof("subscription persisted into a file %s", fmt.Sprintf("%s/%s  - content %s", p.storeFilePath, fmt.Sprintf("%s.json", clientID), subscriptionClient.String()))
-	// store the publisher
-
+	p.logger.Infof("subscription persisted into a file %s", fmt.Sprintf("%s/%s  - content %s", p.storeFilePath, fmt.Sprintf("%s.json", clientID), subscriptionClient.String()))
+	p.updateObjectCounts()
+	if !ok {
+		p.auditClient(c.Actor, audit.ActionCreate, subscriptionClient, "")
+	}
+	for _, s := range added {
+		p.auditSubscription(c.Actor, audit.ActionCreate, clientID, s, "")
+	}
 	return subscriptionClient, nil
 }
 
@@ -206,7 +380,7 @@ func (p *API) GetSubscriptionClient(clientID uuid.UUID) (subscriber.Subscriber,
 
 // GetSubscriptionsFromFile  get subscriptions data from the file store
 func (p *API) GetSubscriptionsFromFile(clientID uuid.UUID) ([]byte, error) {
-	b, err := loadFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, fmt.Sprintf("%s.json", clientID.String())))
+	b, err := loadFromBackend(p.backend, fmt.Sprintf("%s.json", clientID.String()))
 	return b, err
 }
 
@@ -231,6 +405,26 @@ func (p *API) GetSubscriptions() ([]byte, error) {
 	return json.MarshalIndent(&allSubs, "", " ")
 }
 
+// QuerySubscriptions returns a page of copies of the subscriptions selected by req
+func (p *API) QuerySubscriptions(req query.Request) (query.Page, error) {
+	var items []query.Item
+	p.SubscriberStore.RLock()
+	for clientID, client := range p.SubscriberStore.Store {
+		if client.SubStore == nil || (req.ClientID != uuid.Nil && req.ClientID != clientID) {
+			continue
+		}
+		status := client.GetStatus()
+		client.SubStore.RLock()
+		for _, sub := range client.SubStore.Store {
+			s := status
+			items = append(items, query.Item{ClientID: clientID, Status: &s, Subscription: sub.Copy()})
+		}
+		client.SubStore.RUnlock()
+	}
+	p.SubscriberStore.RUnlock()
+	return query.Run(items, req)
+}
+
 // GetSubscription get sub info from clientID and subID
 func (p *API) GetSubscription(clientID uuid.UUID, subID string) (pubsub.PubSub, error) {
 	if subs, ok := p.SubscriberStore.Get(clientID); ok {
@@ -241,17 +435,11 @@ func (p *API) GetSubscription(clientID uuid.UUID, subID string) (pubsub.PubSub,
 
 // GetSubscriberURLByResourceAndClientID  get  subscription information by client id/resource
 func (p *API) GetSubscriberURLByResourceAndClientID(clientID uuid.UUID, resource string) (url *string) {
-	p.SubscriberStore.RLock()
-	defer p.SubscriberStore.RUnlock()
-	for _, subs := range p.SubscriberStore.Store {
+	for _, subs := range p.SubscriberStore.ByResource(resource) {
 		if subs.ClientID == clientID {
-			for _, sub := range subs.SubStore.Store {
-				if sub.GetResource() == resource {
-					return func(s string) *string {
-						return &s
-					}(subs.GetEndPointURI())
-				}
-			}
+			return func(s string) *string {
+				return &s
+			}(subs.GetEndPointURI())
 		}
 	}
 	return nil
@@ -259,28 +447,16 @@ func (p *API) GetSubscriberURLByResourceAndClientID(clientID uuid.UUID, resource
 
 // GetSubscriberURLByResource  get  subscriptionOne information
 func (p *API) GetSubscriberURLByResource(resource string) (urls []string) {
-	p.SubscriberStore.RLock()
-	defer p.SubscriberStore.RUnlock()
-	for _, subs := range p.SubscriberStore.Store {
-		for _, sub := range subs.SubStore.Store {
-			if sub.GetResource() == resource {
-				urls = append(urls, subs.GetEndPointURI())
-			}
-		}
+	for _, subs := range p.SubscriberStore.ByResource(resource) {
+		urls = append(urls, subs.GetEndPointURI())
 	}
 	return urls
 }
 
 // GetClientIDBySubID ...
 func (p *API) GetClientIDBySubID(subID string) (clientIDs []uuid.UUID) {
-	p.SubscriberStore.RLock()
-	defer p.SubscriberStore.RUnlock()
-	for _, subs := range p.SubscriberStore.Store {
-		for _, sub := range subs.SubStore.Store {
-			if sub.GetID() == subID {
-				clientIDs = append(clientIDs, subs.ClientID)
-			}
-		}
+	for _, subs := range p.SubscriberStore.BySubID(subID) {
+		clientIDs = append(clientIDs, subs.ClientID)
 	}
 	return clientIDs
 }
@@ -288,38 +464,108 @@ func (p *API) GetClientIDBySubID(subID string) (clientIDs []uuid.UUID) {
 // GetClientIDAddressByResource get subscriptionOne information
 func (p *API) GetClientIDAddressByResource(resource string) map[uuid.UUID]*types.URI {
 	clients := map[uuid.UUID]*types.URI{}
-	p.SubscriberStore.RLock()
-	defer p.SubscriberStore.RUnlock()
-	for _, subs := range p.SubscriberStore.Store {
-		for _, sub := range subs.SubStore.Store {
-			if sub.GetResource() == resource {
-				clients[subs.ClientID] = subs.EndPointURI
-			}
-		}
+	for _, subs := range p.SubscriberStore.ByResource(resource) {
+		clients[subs.ClientID] = subs.EndPointURI
 	}
 	return clients
 }
 
 // DeleteSubscription delete a subscriptionOne by id
-func (p *API) DeleteSubscription(clientID uuid.UUID, subscriptionID string) error {
-	if subStore, ok := p.SubscriberStore.Get(clientID); ok { // client found
-		if sub, ok2 := subStore.SubStore.Store[subscriptionID]; ok2 {
-			err := deleteFromFile(*sub, fmt.Sprintf("%s/%s", p.storeFilePath, fmt.Sprintf("%s.json", clientID)))
-			subStore.SubStore.Delete(subscriptionID)
+func (p *API) DeleteSubscription(clientID uuid.UUID, subscriptionID string, opts ...CallOption) error {
+	return p.deleteSubscription(clientID, subscriptionID, store.AnyRevision, p.call(opts))
+}
+
+// DeleteSubscriptionIfMatch deletes a subscription if its revision is ifMatch.
+// A revision mismatch returns a *store.ConflictError.
+func (p *API) DeleteSubscriptionIfMatch(clientID uuid.UUID, subscriptionID string, ifMatch uint64, opts ...CallOption) error {
+	return p.deleteSubscription(clientID, subscriptionID, ifMatch, p.call(opts))
+}
+
+func (p *API) deleteSubscription(clientID uuid.UUID, subscriptionID string, ifMatch uint64, c store.Call) error {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	subStore, ok := p.SubscriberStore.Get(clientID)
+	var sub *pubsub.PubSub
+	if ok {
+		sub = subStore.SubStore.Store[subscriptionID]
+	}
+	var current uint64
+	if sub != nil {
+		current = sub.GetRevision()
+	}
+	if err := store.CheckRevision(subscriptionID, ifMatch, current); err != nil {
+		return err
+	}
+	if sub == nil {
+		return nil
+	}
+	subStore.SetRevision(subStore.GetRevision() + 1)
+	deleted := *sub
+	if p.journal != nil {
+		// the store is updated first so that a compaction triggered by the record sees the change
+		subStore.SubStore.Delete(subscriptionID)
+		p.SubscriberStore.Set(clientID, subStore)
+		if err := p.writeJournal(JournalRecord{Op: JournalDelete, ClientID: clientID, SubscriptionID: subscriptionID, Revision: subStore.GetRevision()}); err != nil {
+			subStore.SubStore.Set(subscriptionID, deleted)
+			subStore.SetRevision(subStore.GetRevision() - 1)
 			p.SubscriberStore.Set(clientID, subStore)
 			return err
 		}
+	} else {
+		if err := p.deleteFromBackend(deleted, fmt.Sprintf("%s.json", clientID), subStore.GetRevision()); err != nil {
+			return err
+		}
+		subStore.SubStore.Delete(subscriptionID)
+		p.SubscriberStore.Set(clientID, subStore)
 	}
+	p.updateObjectCounts()
+	p.auditSubscription(c.Actor, audit.ActionDelete, clientID, deleted, "")
 	return nil
 }
 
+// UpdateSubscriptionIfMatch replaces the subscription of the client with the id of sub if its
+// revision is ifMatch, store.AnyRevision skips the check. A revision mismatch returns a
+// *store.ConflictError.
+func (p *API) UpdateSubscriptionIfMatch(clientID uuid.UUID, sub pubsub.PubSub, ifMatch uint64, opts ...CallOption) (pubsub.PubSub, error) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	client, ok := p.SubscriberStore.Get(clientID)
+	var current pubsub.PubSub
+	if ok {
+		current = client.SubStore.Get(sub.ID)
+	}
+	if err := store.CheckRevision(sub.ID, ifMatch, current.GetRevision()); err != nil {
+		return pubsub.PubSub{}, err
+	}
+	if current.GetID() == "" {
+		return pubsub.PubSub{}, fmt.Errorf("subscription %s was not found for client %s", sub.ID, clientID)
+	}
+	sub.SetCreatedAt(current.GetCreatedAt())
+	sub.SetNamespace(client.GetNamespace())
+	sub.SetRevision(current.GetRevision() + 1)
+	client.SubStore.Set(sub.ID, sub)
+	client.SetRevision(client.GetRevision() + 1)
+	p.SubscriberStore.Set(clientID, client)
+	var err error
+	if p.journal != nil {
+		err = p.writeJournal(JournalRecord{Op: JournalCreate, ClientID: clientID, Subscriptions: []pubsub.PubSub{sub}, Revision: client.GetRevision()})
+	} else {
+		err = p.writeToBackend(client, fmt.Sprintf("%s.json", clientID))
+	}
+	if err != nil {
+		return pubsub.PubSub{}, err
+	}
+	p.auditSubscription(p.call(opts).Actor, audit.ActionUpdate, clientID, sub, "")
+	return sub, nil
+}
+
 // DeleteAllSubscriptionsForClient delete all subscriptions for the client
-func (p *API) DeleteAllSubscriptionsForClient(clientID uuid.UUID) (int, error) {
+func (p *API) DeleteAllSubscriptionsForClient(clientID uuid.UUID, opts ...CallOption) (int, error) {
 	var err error
 	var numSubDeleted, numSubToDelete int
 	if sub, ok := p.SubscriberStore.Get(clientID); ok {
 		numSubToDelete = len(sub.SubStore.Store)
-		if err = p.DeleteClient(clientID); err != nil {
+		if err = p.DeleteClient(clientID, opts...); err != nil {
 			return 0, err
 		}
 		numSubDeleted += numSubToDelete
@@ -327,13 +573,79 @@ func (p *API) DeleteAllSubscriptionsForClient(clientID uuid.UUID) (int, error) {
 	return numSubDeleted, nil
 }
 
-// DeleteAllSubscriptions delete all subscriptions in store
-func (p *API) DeleteAllSubscriptions() (int, error) {
+// DeleteAllSubscriptions delete all subscriptions in store. The client records are removed in a
+// single transaction when the backend implements backend.Batcher.
+func (p *API) DeleteAllSubscriptions(opts ...CallOption) (int, error) {
+	c := p.call(opts)
+	if p.journal != nil {
+		return p.deleteAllClients(c)
+	}
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	var clientIDs []uuid.UUID
+	var numSubToDelete int
+	p.SubscriberStore.RLock()
+	for clientID, subs := range p.SubscriberStore.Store {
+		clientIDs = append(clientIDs, clientID)
+		numSubToDelete += len(subs.SubStore.Store)
+	}
+	p.SubscriberStore.RUnlock()
+
+	p.mu.Lock()
+	err := backend.Batch(p.backend, func(tx backend.Tx) error {
+		for _, clientID := range clientIDs {
+			if err := tx.Delete(fmt.Sprintf("%s.json", clientID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	p.recordWrite(err)
+	p.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	for _, clientID := range clientIDs {
+		if client, ok := p.SubscriberStore.Get(clientID); ok {
+			p.auditClient(c.Actor, audit.ActionDelete, &client, "all subscriptions deleted")
+		}
+		p.forgetClient(clientID)
+		p.SubscriberStore.Delete(clientID)
+	}
+	p.updateObjectCounts()
+	return numSubToDelete, nil
+}
+
+// DeleteAllSubscriptionsInNamespace deletes the clients of namespace and their subscriptions
+func (p *API) DeleteAllSubscriptionsInNamespace(namespace string, opts ...CallOption) (int, error) {
+	var numSubDeleted int
+	for _, clientID := range p.SubscriberStore.InNamespace(namespace) {
+		n, err := p.DeleteAllSubscriptionsForClient(clientID, opts...)
+		if err != nil {
+			return numSubDeleted, err
+		}
+		numSubDeleted += n
+	}
+	return numSubDeleted, nil
+}
+
+// GetClientIDsInNamespace returns the ids of the clients of namespace
+func (p *API) GetClientIDsInNamespace(namespace string) []uuid.UUID {
+	return p.SubscriberStore.InNamespace(namespace)
+}
+
+// SetQuota sets the quota of namespace, existing clients and subscriptions above the quota are kept
+func (p *API) SetQuota(namespace string, quota store.Quota) {
+	p.quotas.Set(namespace, quota)
+}
+
+// deleteAllClients deletes the clients one by one, each deletion is recorded in the journal
+func (p *API) deleteAllClients(c store.Call) (int, error) {
 	var err error
 	var numSubDeleted, numSubToDelete int
 	for clientID, subs := range p.SubscriberStore.Store {
 		numSubToDelete = len(subs.SubStore.Store)
-		if err = p.DeleteClient(clientID); err != nil {
+		if err = p.deleteClient(clientID, store.AnyRevision, audit.ActionDelete, "", c); err != nil {
 			return numSubDeleted, err
 		}
 		numSubDeleted += numSubToDelete
@@ -342,54 +654,123 @@ func (p *API) DeleteAllSubscriptions() (int, error) {
 }
 
 // DeleteClient  delete all subscriptionOne information
-func (p *API) DeleteClient(clientID uuid.UUID) error {
-	if _, ok := p.SubscriberStore.Get(clientID); ok { // client found
-		log.Infof("delete from file %s",
-			fmt.Sprintf("%s/%s", p.storeFilePath, fmt.Sprintf("%s.json", clientID)))
-		if err := deleteAllFromFile(fmt.Sprintf("%s/%s", p.storeFilePath, fmt.Sprintf("%s.json", clientID))); err != nil {
-			return err
+func (p *API) DeleteClient(clientID uuid.UUID, opts ...CallOption) error {
+	return p.deleteClient(clientID, store.AnyRevision, audit.ActionDelete, "", p.call(opts))
+}
+
+// DeleteClientIfMatch deletes a client and its subscriptions if the revision of the client is
+// ifMatch. A revision mismatch returns a *store.ConflictError.
+func (p *API) DeleteClientIfMatch(clientID uuid.UUID, ifMatch uint64, opts ...CallOption) error {
+	return p.deleteClient(clientID, ifMatch, audit.ActionDelete, "", p.call(opts))
+}
+
+// deleteClient deletes a client and records the deletion with action and reason in the audit log.
+// The deletion of a client marked for delete after repeated delivery failures is recorded
+// as an automatic deletion.
+func (p *API) deleteClient(clientID uuid.UUID, ifMatch uint64, action audit.Action, reason string, c store.Call) error {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	return p.deleteClientLocked(clientID, ifMatch, action, reason, c)
+}
+
+// deleteClientLocked is deleteClient for callers holding p.updateMu
+func (p *API) deleteClientLocked(clientID uuid.UUID, ifMatch uint64, action audit.Action, reason string, c store.Call) error {
+	client, ok := p.SubscriberStore.Get(clientID)
+	if err := store.CheckRevision(clientID.String(), ifMatch, client.GetRevision()); err != nil {
+		return err
+	}
+	if ok { // client found
+		if client.Action == channel.DELETE && action == audit.ActionDelete {
+			action, reason = audit.ActionAutoDelete, fmt.Sprintf("delivery failed %d times", client.FailedCount())
 		}
-		p.SubscriberStore.Delete(clientID)
+		if p.journal != nil {
+			// the store is updated first so that a compaction triggered by the record sees the
+			// change, the client file is removed on the next compaction
+			p.SubscriberStore.Delete(clientID)
+			if err := p.writeJournal(JournalRecord{Op: JournalDeleteClient, ClientID: clientID}); err != nil {
+				p.SubscriberStore.Set(clientID, client)
+				return err
+			}
+		} else {
+			p.logger.Infof("delete from file %s",
+				fmt.Sprintf("%s/%s", p.storeFilePath, fmt.Sprintf("%s.json", clientID)))
+			if err := p.deleteAllFromBackend(fmt.Sprintf("%s.json", clientID)); err != nil {
+				return err
+			}
+			p.SubscriberStore.Delete(clientID)
+		}
+		p.forgetClient(clientID)
+		p.updateObjectCounts()
+		p.auditClient(c.Actor, action, &client, reason)
 	} else {
-		log.Infof("subscription for client id %s not found", clientID)
+		p.logger.Infof("subscription for client id %s not found", clientID)
 	}
 	return nil
 }
 
 // UpdateStatus .. update status
 func (p *API) UpdateStatus(clientID uuid.UUID, status subscriber.Status) error {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
 	if subStore, ok := p.SubscriberStore.Get(clientID); ok {
+		changed := subStore.GetStatus() != status
 		subStore.SetStatus(status)
 		p.SubscriberStore.Set(clientID, subStore)
+		if changed && p.health.Persist {
+			return p.writeHealth(&subStore, true)
+		}
 		// do not write to file , if restarts it will consider all client are active
+		// the journal keeps status changes for history only
+		if changed && p.journal != nil {
+			return p.writeJournal(JournalRecord{Op: JournalStatus, ClientID: clientID, Status: &status})
+		}
 	} else {
 		return errors.New("failed to update subscriber status")
 	}
 	return nil
 }
 
-// IncFailCountToFail .. update fail count
+// IncFailCountToFail records a failed delivery to the client and applies the failure policy.
+// It returns true once the client is marked for delete.
 func (p *API) IncFailCountToFail(clientID uuid.UUID) bool {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
 	if subStore, ok := p.SubscriberStore.Get(clientID); ok {
-		subStore.IncFailCount()
+		now, status := p.clock.Now(), subStore.GetStatus()
+		subStore.RecordFailure(now)
+		action, reason := p.onFailure(&subStore, now)
 		p.SubscriberStore.Set(clientID, subStore)
-		if subStore.Action == channel.DELETE {
-			return true
+		if err := p.writeHealth(&subStore, subStore.GetStatus() != status); err != nil {
+			p.logger.Errorf("failed to persist the health of subscriber %s: %v", clientID, err)
+		}
+		if action != "" {
+			p.auditClient(audit.ActorSystem, action, &subStore, reason)
 		}
+		return subStore.Action == channel.DELETE
 	}
 	return false
 }
 
-// ResetFailCount ..reset fail count
+// ResetFailCount records a successful delivery to the client, ending its backoff and quarantine
 func (p *API) ResetFailCount(clientID uuid.UUID) {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
 	if subStore, ok := p.SubscriberStore.Get(clientID); ok {
-		subStore.ResetFailCount()
+		failed := subStore.FailedCount() != 0 || subStore.GetStatus() == subscriber.Quarantined
+		p.onSuccess(&subStore, p.clock.Now())
 		p.SubscriberStore.Set(clientID, subStore)
+		if err := p.writeHealth(&subStore, failed); err != nil {
+			p.logger.Errorf("failed to persist the health of subscriber %s: %v", clientID, err)
+		}
 	}
 }
 
-// FailCountThreshold .. get threshold
+// FailCountThreshold returns the number of consecutive failures after which a client fails,
+// subscriber.SetConnectionToFailAfter if the failure policy is not subscriber.ConsecutiveFailures
 func (p *API) FailCountThreshold() int {
+	if n, ok := p.failurePolicy.(subscriber.ConsecutiveFailures); ok {
+		return int(n)
+	}
 	return subscriber.SetConnectionToFailAfter
 }
 
@@ -410,90 +791,144 @@ func (p *API) SubscriberMarkedForDelete(clientID uuid.UUID) bool {
 	return false
 }
 
-// deleteAllFromFile deletes  publisher and subscriptionOne information from the file system
-func deleteAllFromFile(filePath string) error {
-	return os.Remove(filePath)
+// QueryAudit returns the audit records selected by q, none if no audit log is set
+func (p *API) QueryAudit(q audit.Query) ([]audit.Record, error) {
+	return p.audit.Query(q)
 }
 
-// DeleteFromFile is used to delete subscriptionOne from the file system
-func deleteFromFile(sub pubsub.PubSub, filePath string) error {
-	var persistedSubClient subscriber.Subscriber
-	//open file
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		return err
+// auditClient records a change of client by actor, or by the system if the action is
+// performed by the system
+func (p *API) auditClient(actor string, action audit.Action, client *subscriber.Subscriber, reason string) {
+	p.audit.Record(audit.Record{Action: action, Kind: audit.KindClient, ID: client.ClientID.String(), ClientID: client.ClientID.String(),
+		Namespace: client.GetNamespace(), Actor: actorFor(actor, action), Reason: reason})
+}
+
+// auditSubscription records a change of the subscription s of the client by actor
+func (p *API) auditSubscription(actor string, action audit.Action, clientID uuid.UUID, s pubsub.PubSub, reason string) {
+	p.audit.Record(audit.Record{Action: action, Kind: audit.KindSubscription, ID: s.ID, ClientID: clientID.String(),
+		Namespace: s.GetNamespace(), Resource: s.GetResource(), Actor: actorFor(actor, action), Reason: reason})
+}
+
+func actorFor(actor string, action audit.Action) string {
+	switch action {
+	case audit.ActionFail, audit.ActionQuarantine, audit.ActionAutoDelete, audit.ActionReload:
+		return audit.ActorSystem
 	}
-	defer file.Close()
-	//read file and unmarshall json file to slice of users
-	b, err := io.ReadAll(file)
+	return actor
+}
+
+// deleteAllFromBackend deletes  publisher and subscriptionOne information from the backend
+func (p *API) deleteAllFromBackend(key string) error {
+	err := p.backend.Delete(key)
+	p.recordWrite(err)
+	return err
+}
+
+// deleteFromBackend is used to delete subscriptionOne from the backend
+func (p *API) deleteFromBackend(sub pubsub.PubSub, key string, revision uint64) error {
+	var persistedSubClient subscriber.Subscriber
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := loadFromBackend(p.backend, key)
 	if err != nil {
 		return err
 	}
 
-	if len(b) > 0 {
-		err = json.Unmarshal(b, &persistedSubClient)
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &persistedSubClient)
 		if err != nil {
 			return err
 		}
 	}
-	delete(persistedSubClient.SubStore.Store, sub.ID)
+	if persistedSubClient.SubStore != nil {
+		delete(persistedSubClient.SubStore.Store, sub.ID)
+	}
+	persistedSubClient.SetRevision(revision)
 
-	newBytes, err := json.MarshalIndent(&persistedSubClient, "", " ")
-	if err != nil {
-		log.Errorf("error deleting sub %v", err)
-		return err
+	if err = p.putSubscriber(key, persistedSubClient); err != nil {
+		p.logger.Errorf("error deleting sub %v", err)
 	}
-	return os.WriteFile(filePath, newBytes, 0666)
+	return err
 }
 
-// loadFromFile is used to read subscriptionOne/publisher from the file system
-func loadFromFile(filePath string) (b []byte, err error) {
-	//open file
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		return nil, err
+// loadSubscriber reads a client file, falling back to the backup copy if the key is corrupted.
+// A client file written with an older schema version is rewritten in the current one.
+func (p *API) loadSubscriber(key string) (sub *subscriber.Subscriber, err error) {
+	version := schema.CurrentVersion
+	err = backend.LoadWithFallback(p.backend, key, func(data []byte) error {
+		payload, v, e := schema.Unwrap(schema.KindSubscriber, data)
+		if e != nil {
+			return e
+		}
+		var decoded subscriber.Subscriber
+		if e = json.Unmarshal(payload, &decoded); e != nil {
+			return e
+		}
+		if decoded.ClientID == uuid.Nil {
+			return fmt.Errorf("subscriber data is missing the client id")
+		}
+		sub, version = &decoded, v
+		return nil
+	})
+	if err != nil || sub == nil || version >= schema.CurrentVersion {
+		return
 	}
-	defer file.Close()
-	//read file and unmarshall json file to slice of users
-	b, err = io.ReadAll(file)
-	if err != nil {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e := p.putSubscriber(key, *sub); e != nil {
+		p.logger.Errorf("failed to upgrade %s from schema version %d: %v", key, version, e)
+		return
+	}
+	p.logger.Infof("upgraded %s from schema version %d to %d", key, version, schema.CurrentVersion)
+	return
+}
+
+// loadFromBackend is used to read subscriptionOne/publisher from the backend, a missing key reads as empty.
+// The returned client is upgraded to the current schema version and unwrapped from its envelope.
+func loadFromBackend(b backend.Backend, key string) ([]byte, error) {
+	data, err := b.Get(key)
+	if errors.Is(err, backend.ErrNotFound) {
+		return []byte{}, nil
+	} else if err != nil {
 		return nil, err
 	}
-	return b, nil
+	payload, _, err := schema.Unwrap(schema.KindSubscriber, data)
+	return payload, err
 }
 
-func loadFileNamesFromDir(filePath string) (subFiles []string, err error) {
-	files, err := os.ReadDir(filePath)
+// encodeSubscriber serializes a client file in the current schema version
+func encodeSubscriber(s *subscriber.Subscriber) ([]byte, error) {
+	payload, err := json.Marshal(s)
 	if err != nil {
-		return subFiles, err
-	}
-	for _, file := range files {
-		if !file.IsDir() {
-			subFiles = append(subFiles, file.Name())
-		}
+		return nil, err
 	}
-	return
+	return schema.Wrap(schema.KindSubscriber, payload)
 }
 
-// writeToFile writes subscriptionOne data to a file
-func writeToFile(subscriberClient subscriber.Subscriber, filePath string) error {
-	//open file
-	mu.Lock()
-	defer mu.Unlock()
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0644)
+// putSubscriber writes a client file in the current schema version
+func (p *API) putSubscriber(key string, s subscriber.Subscriber) error {
+	newBytes, err := encodeSubscriber(&s)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	//read file and unmarshall json file to slice of users
-	b, err := io.ReadAll(file)
+	p.logger.Infof("persisting following contents %s to %s\n", string(newBytes), key)
+	err = p.backend.Put(key, newBytes)
+	p.recordWrite(err)
+	return err
+}
+
+// writeToBackend writes subscriptionOne data to the backend
+func (p *API) writeToBackend(subscriberClient subscriber.Subscriber, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := loadFromBackend(p.backend, key)
 	if err != nil {
 		return err
 	}
 
 	var persistedSubClient subscriber.Subscriber
-	if len(b) > 0 {
-		err = json.Unmarshal(b, &persistedSubClient)
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &persistedSubClient)
 		if err != nil {
 			return err
 		}
@@ -501,21 +936,40 @@ func writeToFile(subscriberClient subscriber.Subscriber, filePath string) error
 		persistedSubClient = *subscriber.New(subscriberClient.ClientID)
 	} // no  file found
 	_ = persistedSubClient.SetEndPointURI(subscriberClient.GetEndPointURI())
-	persistedSubClient.SetStatus(subscriber.Active)
+	persistedSubClient.SetHealth(p.persistedHealth(&subscriberClient))
+	persistedSubClient.LeaseTTL = subscriberClient.LeaseTTL
+	persistedSubClient.ExpiresAt = subscriberClient.ExpiresAt
+	persistedSubClient.SetRevision(subscriberClient.GetRevision())
+	persistedSubClient.SetNamespace(subscriberClient.GetNamespace())
 	for subID, sub := range subscriberClient.SubStore.Store {
 		persistedSubClient.SubStore.Store[subID] = sub
 	}
 
-	newBytes, err := json.MarshalIndent(&persistedSubClient, "", " ")
+	return p.putSubscriber(key, persistedSubClient)
+}
+
+// recordWrite counts a write to the backend
+func (p *API) recordWrite(err error) {
 	if err != nil {
-		return err
+		p.metrics.IncWriteCount("client", localmetrics.FAILED)
+		return
 	}
-	log.Infof("persisting following contents %s to a file %s\n", string(newBytes), filePath)
-	return os.WriteFile(filePath, newBytes, 0666)
+	p.metrics.IncWriteCount("client", localmetrics.SUCCESS)
 }
 
-func hasDir(path string) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		_ = os.Mkdir(path, 0700)
+// updateObjectCounts publishes the number of clients and subscriptions held in memory
+func (p *API) updateObjectCounts() {
+	if p.metrics == nil {
+		return
+	}
+	p.SubscriberStore.RLock()
+	defer p.SubscriberStore.RUnlock()
+	subs := 0
+	for _, s := range p.SubscriberStore.Store {
+		if s.SubStore != nil {
+			subs += len(s.SubStore.Store)
+		}
 	}
+	p.metrics.SetObjectCount("client", len(p.SubscriberStore.Store))
+	p.metrics.SetObjectCount("subscription", subs)
 }
@@ -1,19 +1,32 @@
 package subscriber_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/redhat-cne/sdk-go/pkg/channel"
 	"github.com/redhat-cne/sdk-go/pkg/pubsub"
 	"github.com/redhat-cne/sdk-go/pkg/store"
+	"github.com/redhat-cne/sdk-go/pkg/store/archive"
+	"github.com/redhat-cne/sdk-go/pkg/store/audit"
+	"github.com/redhat-cne/sdk-go/pkg/store/backend"
+	"github.com/redhat-cne/sdk-go/pkg/store/encryption"
+	"github.com/redhat-cne/sdk-go/pkg/store/query"
+	"github.com/redhat-cne/sdk-go/pkg/store/schema"
 	"github.com/redhat-cne/sdk-go/pkg/subscriber"
 	"github.com/redhat-cne/sdk-go/pkg/types"
+	"github.com/redhat-cne/sdk-go/pkg/util/clock"
 	api "github.com/redhat-cne/sdk-go/v1/subscriber"
 	"github.com/stretchr/testify/assert"
 )
@@ -66,6 +79,22 @@ func TestAPI_GetAPIInstance(t *testing.T) {
 	assert.Equal(t, &globalInstance, &localInstance)
 }
 
+func TestAPI_NewAPI(t *testing.T) {
+	one := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()))
+	two := api.NewAPI(api.WithStorePath(t.TempDir()))
+	assert.NotSame(t, one, two)
+
+	_, e := one.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, one.ClientCount())
+	assert.Equal(t, 0, two.ClientCount())
+
+	_, e = two.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	assert.Len(t, one.GetSubscriptionsFromClientID(clientID), 1)
+	assert.Len(t, two.GetSubscriptionsFromClientID(clientID), 2)
+}
+
 func TestAPI_CreateSubscription(t *testing.T) {
 	defer clean()
 	s, e := globalInstance.CreateSubscription(clientID, subscriberWithOneEventCheck)
@@ -88,7 +117,7 @@ func TestAPI_CreateSubscription(t *testing.T) {
 	assert.Equal(t, *s, subscriptionClient)
 	assert.NotNil(t, subscriptionClient.SubStore)
 	assert.Equal(t, len(subscriptionClient.SubStore.Store), len(s.SubStore.Store))
-	assert.Equal(t, subscriptionOne, subscriptionClient.SubStore.Store[subscriptionOne.ID])
+	assert.Equal(t, subscriptionOne, withoutStoreMetadata(subscriptionClient.SubStore.Store[subscriptionOne.ID]))
 }
 
 func TestAPI_CreateTwoSubscription(t *testing.T) {
@@ -119,7 +148,7 @@ func TestAPI_CreateTwoSubscription(t *testing.T) {
 	assert.NotNil(t, subscriptionClient.SubStore)
 	assert.Equal(t, len(s.SubStore.Store), len(subscriptionClient.SubStore.Store))
 	//assert.NotEmpty(t, subscriber[0].SubStore.)
-	assert.Equal(t, subscriptionOne, subscriptionClient.SubStore.Store[subscriptionOne.ID])
+	assert.Equal(t, subscriptionOne, withoutStoreMetadata(subscriptionClient.SubStore.Store[subscriptionOne.ID]))
 }
 
 func TestAPI_DeleteAllSubscriptions(t *testing.T) {
@@ -184,7 +213,7 @@ func Test_Concurrency(t *testing.T) {
 	assert.NotNil(t, subscriptionClient.SubStore)
 	assert.Equal(t, len(s.SubStore.Store), len(subscriptionClient.SubStore.Store))
 	//assert.NotEmpty(t, subscriber[0].SubStore.)
-	assert.Equal(t, subscriptionOne, subscriptionClient.SubStore.Store[subscriptionOne.ID])
+	assert.Equal(t, subscriptionOne, withoutStoreMetadata(subscriptionClient.SubStore.Store[subscriptionOne.ID]))
 
 	var wg sync.WaitGroup
 	for i := 0; i <= 10; i++ {
@@ -214,10 +243,1022 @@ func Test_Concurrency(t *testing.T) {
 	wg.Wait()
 }
 
+func TestAPI_ReloadStoreRecoversCorruptedFile(t *testing.T) {
+	defer clean()
+	_, e := globalInstance.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	_, e = globalInstance.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	// a torn write leaves a truncated file behind
+	assert.Nil(t, os.WriteFile(fmt.Sprintf("%s/%s.json", storePath, clientID), []byte(`{"clientID":`), 0600))
+	e = globalInstance.ReloadStore()
+	var corrupted *backend.CorruptionError
+	assert.True(t, errors.As(e, &corrupted))
+	assert.Equal(t, fmt.Sprintf("%s.json", clientID), corrupted.Key)
+	assert.True(t, corrupted.Recovered)
+	b, e := globalInstance.GetSubscriptionsFromFile(clientID)
+	assert.Nil(t, e)
+	var subscriptionClient subscriber.Subscriber
+	assert.Nil(t, json.Unmarshal(b, &subscriptionClient))
+	assert.Equal(t, clientID, subscriptionClient.ClientID)
+	assert.Len(t, subscriptionClient.SubStore.Store, 1)
+}
+
+func TestAPI_ReloadStoreUpgradesLegacyFile(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	legacy := fmt.Sprintf(`{"clientID":"%s","endPointURI":"http://localhost:8080/health",`+
+		`"subStore":{"Store":{"%s":{"id":"%s","endpointUri":"http://localhost:9090/ack/event","resource":"test/test/1"}}},"status":1}`,
+		clientID, subscriptionOneID, subscriptionOneID)
+	key := fmt.Sprintf("%s.json", clientID)
+	assert.Nil(t, b.Put(key, []byte(legacy)))
+	p := api.NewAPI(api.WithBackend(b))
+	sub, e := p.GetSubscription(clientID, subscriptionOneID)
+	assert.Nil(t, e)
+	assert.Equal(t, "test/test/1", sub.Resource)
+
+	data, e := b.Get(key)
+	assert.Nil(t, e)
+	var env schema.Envelope
+	assert.Nil(t, json.Unmarshal(data, &env))
+	assert.Equal(t, schema.CurrentVersion, env.Version)
+	assert.Equal(t, schema.KindSubscriber, env.Kind)
+}
+
+func TestAPI_GetClientIDAddressByResourceWildcard(t *testing.T) {
+	p := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()))
+	exact := subscriber.New(clientID)
+	assert.Nil(t, exact.SetEndPointURI("http://localhost:8080/exact"))
+	exact.AddSubscription(pubsub.PubSub{ID: subscriptionOneID, Resource: "/cluster/node/worker-1/sync/sync-status/sync-state"})
+	_, e := p.CreateSubscription(clientID, *exact)
+	assert.Nil(t, e)
+	otherID := uuid.New()
+	wildcard := subscriber.New(otherID)
+	assert.Nil(t, wildcard.SetEndPointURI("http://localhost:8080/wildcard"))
+	wildcard.AddSubscription(pubsub.PubSub{ID: subscriptionTwoID, Resource: "/cluster/node/*/sync/**"})
+	_, e = p.CreateSubscription(otherID, *wildcard)
+	assert.Nil(t, e)
+
+	clients := p.GetClientIDAddressByResource("/cluster/node/worker-1/sync/sync-status/sync-state")
+	assert.Len(t, clients, 2)
+	assert.Equal(t, "http://localhost:8080/wildcard", clients[otherID].String())
+	assert.ElementsMatch(t, []string{"http://localhost:8080/wildcard"}, p.GetSubscriberURLByResource("/cluster/node/worker-2/sync/ptp-status/lock-state"))
+	sub, ok := p.HasSubscription(otherID, "/cluster/node/worker-2/sync/ptp-status/lock-state")
+	assert.True(t, ok)
+	assert.Equal(t, subscriptionTwoID, sub.ID)
+
+	assert.Nil(t, p.DeleteClient(otherID))
+	assert.Empty(t, p.GetSubscriberURLByResource("/cluster/node/worker-2/sync/ptp-status/lock-state"))
+}
+
+func TestAPI_QuerySubscriptions(t *testing.T) {
+	p := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()))
+	_, e := p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	otherID := uuid.New()
+	_, e = p.CreateSubscription(otherID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	assert.Nil(t, p.UpdateStatus(otherID, subscriber.InActive))
+
+	page, e := p.QuerySubscriptions(query.Request{Filter: query.Filter{ClientID: clientID}})
+	assert.Nil(t, e)
+	assert.Len(t, page.Items, 2)
+	inactive := subscriber.InActive
+	page, e = p.QuerySubscriptions(query.Request{Filter: query.Filter{Status: &inactive, EndpointHost: "localhost"}})
+	assert.Nil(t, e)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, otherID, page.Items[0].ClientID)
+	page, e = p.QuerySubscriptions(query.Request{Filter: query.Filter{ResourcePrefix: "test/test/"}, Limit: 2})
+	assert.Nil(t, e)
+	assert.Len(t, page.Items, 2)
+	assert.NotEmpty(t, page.NextCursor)
+}
+
+func TestAPI_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := backend.NewMemoryBackend()
+	p := api.NewAPI(api.WithBackend(b))
+	events := p.Watch(ctx)
+	_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	ev := <-events
+	assert.Equal(t, store.Added, ev.Type)
+	assert.Equal(t, clientID, ev.ClientID)
+	assert.Len(t, ev.Subscriber.SubStore.Store, 1)
+
+	// changes picked up by ReloadStore are reported as well
+	other := api.NewAPI(api.WithBackend(b))
+	_, e = other.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	assert.Nil(t, p.ReloadStore())
+	ev = <-events
+	assert.Equal(t, store.Updated, ev.Type)
+	assert.Len(t, ev.Subscriber.SubStore.Store, 2)
+
+	assert.Nil(t, p.DeleteClient(clientID))
+	ev = <-events
+	assert.Equal(t, store.Deleted, ev.Type)
+	assert.Equal(t, clientID, ev.ClientID)
+}
+
+func TestAPI_LeaseExpiry(t *testing.T) {
+	dir := t.TempDir()
+	fakeClock := clock.NewFakeClock(time.Now())
+	notifier := make(chan *channel.DataChan, 1)
+	p := api.NewAPI(api.WithStorePath(dir), api.WithClock(fakeClock), api.WithExpiryNotifier(notifier))
+	_, e := p.RenewSubscription(clientID)
+	assert.NotNil(t, e)
+
+	leased := subscriber.New(clientID)
+	assert.Nil(t, leased.SetEndPointURI("http://localhost:8080/health"))
+	leased.AddSubscription(*subscriptionOne)
+	leased.SetLeaseTTL(time.Minute)
+	_, e = p.CreateSubscription(clientID, *leased)
+	assert.Nil(t, e)
+
+	fakeClock.Step(50 * time.Second)
+	expiresAt, e := p.RenewSubscription(clientID)
+	assert.Nil(t, e)
+	assert.Equal(t, fakeClock.Now().Add(time.Minute), expiresAt)
+	fakeClock.Step(50 * time.Second)
+	assert.Empty(t, p.ReapExpiredSubscriptions())
+
+	// the lease survives a restart
+	reloaded := api.NewAPI(api.WithStorePath(dir), api.WithClock(fakeClock))
+	c, e := reloaded.GetSubscriptionClient(clientID)
+	assert.Nil(t, e)
+	assert.Equal(t, time.Minute, c.GetLeaseTTL())
+
+	fakeClock.Step(10 * time.Second)
+	assert.Equal(t, []uuid.UUID{clientID}, p.ReapExpiredSubscriptions())
+	assert.Equal(t, 0, p.ClientCount())
+	_, e = os.Stat(fmt.Sprintf("%s/%s.json", dir, clientID))
+	assert.True(t, os.IsNotExist(e))
+	n := <-notifier
+	assert.Equal(t, clientID, n.ClientID)
+	assert.Equal(t, channel.DELETE, n.Status)
+	assert.Equal(t, channel.SUBSCRIBER, n.Type)
+}
+
+func TestAPI_LeaseReaper(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	notifier := make(chan *channel.DataChan, 1)
+	p := api.NewAPI(api.WithBackend(backend.NewMemoryBackend()), api.WithClock(fakeClock), api.WithExpiryNotifier(notifier))
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	p.StartLeaseReaper(time.Hour, stopCh)
+
+	// the reaper runs each time the API clock reaches the next period
+	for i := 0; i < 2; i++ {
+		leased := subscriber.New(uuid.New())
+		assert.Nil(t, leased.SetEndPointURI("http://localhost:8080/health"))
+		leased.AddSubscription(*subscriptionOne)
+		leased.SetLeaseTTL(time.Minute)
+		_, e := p.CreateSubscription(leased.ClientID, *leased)
+		assert.Nil(t, e)
+		fakeClock.Step(time.Hour)
+		select {
+		case n := <-notifier:
+			assert.Equal(t, leased.ClientID, n.ClientID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("expired subscriber was not reaped")
+		}
+	}
+	assert.Equal(t, 0, p.ClientCount())
+}
+
+func TestAPI_JournalReplay(t *testing.T) {
+	dir := t.TempDir()
+	one := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	_, e := one.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	assert.Nil(t, one.DeleteSubscription(clientID, subscriptionTwoID))
+	assert.Nil(t, one.UpdateStatus(clientID, subscriber.InActive))
+	// client files are not written until the journal is compacted
+	_, e = os.Stat(fmt.Sprintf("%s/%s.json", dir, clientID))
+	assert.True(t, os.IsNotExist(e))
+
+	history, e := one.History()
+	assert.Nil(t, e)
+	assert.Len(t, history, 3)
+	assert.Equal(t, api.JournalCreate, history[0].Op)
+	assert.Equal(t, api.JournalDelete, history[1].Op)
+	assert.Equal(t, api.JournalStatus, history[2].Op)
+
+	two := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	assert.Equal(t, 1, two.ClientCount())
+	subs := two.GetSubscriptionsFromClientID(clientID)
+	assert.Len(t, subs, 1)
+	assert.Contains(t, subs, subscriptionOneID)
+}
+
+func TestAPI_JournalCompaction(t *testing.T) {
+	dir := t.TempDir()
+	one := api.NewAPI(api.WithStorePath(dir), api.WithJournal(2))
+	_, e := one.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	_, e = one.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	history, e := one.History()
+	assert.Nil(t, e)
+	assert.Empty(t, history)
+	b, e := one.GetSubscriptionsFromFile(clientID)
+	assert.Nil(t, e)
+	var subscriptionClient subscriber.Subscriber
+	assert.Nil(t, json.Unmarshal(b, &subscriptionClient))
+	assert.Len(t, subscriptionClient.SubStore.Store, 2)
+
+	_, e = one.DeleteAllSubscriptionsForClient(clientID)
+	assert.Nil(t, e)
+	assert.Nil(t, one.Compact())
+	_, e = os.Stat(fmt.Sprintf("%s/%s.json", dir, clientID))
+	assert.True(t, os.IsNotExist(e))
+	two := api.NewAPI(api.WithStorePath(dir), api.WithJournal(2))
+	assert.Equal(t, 0, two.ClientCount())
+}
+
+func TestAPI_JournalIgnoresTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	one := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	_, e := one.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	f, e := os.OpenFile(fmt.Sprintf("%s/%s", dir, api.JournalKey), os.O_APPEND|os.O_WRONLY, 0)
+	assert.Nil(t, e)
+	_, e = f.WriteString(`{"seq":2,"op":"del`)
+	assert.Nil(t, e)
+	assert.Nil(t, f.Close())
+
+	two := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	assert.Nil(t, two.ReloadStore())
+	assert.Len(t, two.GetSubscriptionsFromClientID(clientID), 1)
+
+	// the torn record is cut before the next append instead of corrupting the journal
+	assert.Nil(t, two.UpdateStatus(clientID, subscriber.InActive))
+	three := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	assert.Nil(t, three.ReloadStore())
+	assert.Len(t, three.GetSubscriptionsFromClientID(clientID), 1)
+	history, e := three.History()
+	assert.Nil(t, e)
+	assert.Len(t, history, 2)
+	assert.Equal(t, uint64(2), history[1].Seq)
+	assert.Equal(t, api.JournalStatus, history[1].Op)
+}
+
+func TestAPI_JournalSetsCorruptedJournalAside(t *testing.T) {
+	dir := t.TempDir()
+	one := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	_, e := one.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	assert.Nil(t, one.DeleteSubscription(clientID, subscriptionTwoID))
+	assert.Nil(t, one.UpdateStatus(clientID, subscriber.InActive))
+	path := filepath.Join(dir, api.JournalKey)
+	data, e := os.ReadFile(path)
+	assert.Nil(t, e)
+	lines := bytes.SplitAfter(data, []byte{'\n'})
+	lines[1] = []byte("not a record\n")
+	assert.Nil(t, os.WriteFile(path, bytes.Join(lines, nil), 0600))
+
+	// the records before the corrupted one are kept and new records are replayed on restart
+	two := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	assert.Len(t, two.GetSubscriptionsFromClientID(clientID), 2)
+	assert.Nil(t, two.DeleteSubscription(clientID, subscriptionOneID))
+	three := api.NewAPI(api.WithStorePath(dir), api.WithJournal(0))
+	assert.Nil(t, three.ReloadStore())
+	subs := three.GetSubscriptionsFromClientID(clientID)
+	assert.Len(t, subs, 1)
+	assert.Contains(t, subs, subscriptionTwoID)
+	aside, e := os.ReadFile(filepath.Join(dir, api.CorruptJournalKey))
+	assert.Nil(t, e)
+	assert.Equal(t, bytes.Join(lines, nil), aside)
+}
+
+func TestAPI_ConditionalUpdates(t *testing.T) {
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := api.NewAPI(append([]api.Option{api.WithStorePath(dir)}, opts...)...)
+			client, e := p.CreateSubscriptionIfMatch(clientID, subscriberWithManyEventCheck, store.AnyRevision)
+			assert.Nil(t, e)
+			assert.Equal(t, uint64(1), client.GetRevision())
+			_, e = p.CreateSubscriptionIfMatch(clientID, subscriberWithOneEventCheck, 2)
+			assert.True(t, errors.Is(e, store.ErrConflict))
+
+			sub, e := p.GetSubscription(clientID, subscriptionOneID)
+			assert.Nil(t, e)
+			assert.Equal(t, uint64(1), sub.GetRevision())
+			sub.Resource = "/test/updated"
+			updated, e := p.UpdateSubscriptionIfMatch(clientID, sub, 1)
+			assert.Nil(t, e)
+			assert.Equal(t, uint64(2), updated.GetRevision())
+			_, e = p.UpdateSubscriptionIfMatch(clientID, sub, 1)
+			var conflict *store.ConflictError
+			assert.True(t, errors.As(e, &conflict))
+			assert.Equal(t, uint64(2), conflict.Current)
+
+			assert.True(t, errors.Is(p.DeleteSubscriptionIfMatch(clientID, subscriptionTwoID, 2), store.ErrConflict))
+			assert.Nil(t, p.DeleteSubscriptionIfMatch(clientID, subscriptionTwoID, 1))
+
+			// revisions survive a reload
+			reloaded := api.NewAPI(append([]api.Option{api.WithStorePath(dir)}, opts...)...)
+			c, e := reloaded.GetSubscriptionClient(clientID)
+			assert.Nil(t, e)
+			assert.Equal(t, uint64(3), c.GetRevision())
+			sub, e = reloaded.GetSubscription(clientID, subscriptionOneID)
+			assert.Nil(t, e)
+			assert.Equal(t, uint64(2), sub.GetRevision())
+			assert.Equal(t, "/test/updated", sub.Resource)
+
+			assert.True(t, errors.Is(reloaded.DeleteClientIfMatch(clientID, 2), store.ErrConflict))
+			assert.Nil(t, reloaded.DeleteClientIfMatch(clientID, 3))
+			assert.Equal(t, 0, reloaded.ClientCount())
+		})
+	}
+}
+
+func TestAPI_ExportImport(t *testing.T) {
+	source := api.NewAPI()
+	_, e := source.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	var b bytes.Buffer
+	assert.Nil(t, source.Export(&b))
+	data := b.Bytes()
+
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := api.NewAPI(append([]api.Option{api.WithStorePath(dir)}, opts...)...)
+			otherClientID := uuid.New()
+			_, e := target.CreateSubscription(otherClientID, subscriberWithOneEventCheck)
+			assert.Nil(t, e)
+
+			report, e := target.Import(bytes.NewReader(data), archive.Merge)
+			assert.Nil(t, e)
+			assert.Equal(t, archive.Report{Added: 1}, report)
+			report, e = target.Import(bytes.NewReader(data), archive.Merge)
+			assert.Nil(t, e)
+			assert.Equal(t, archive.Report{Unchanged: 1}, report)
+
+			// a client with another endpoint is not merged
+			moved := api.NewSubscriber(clientID)
+			_ = moved.SetEndPointURI("http://localhost:9090/event")
+			var conflicting bytes.Buffer
+			assert.Nil(t, archive.Encode(&conflicting, &archive.Archive{Clients: []subscriber.Subscriber{moved}}))
+			report, e = target.Import(bytes.NewReader(conflicting.Bytes()), archive.Merge)
+			assert.Nil(t, e)
+			assert.Len(t, report.Conflicts, 1)
+			assert.Equal(t, archive.SectionClients, report.Conflicts[0].Section)
+
+			report, e = target.Import(bytes.NewReader(data), archive.Replace)
+			assert.Nil(t, e)
+			assert.Equal(t, archive.Report{Unchanged: 1, Removed: 1}, report)
+
+			reloaded := api.NewAPI(append([]api.Option{api.WithStorePath(dir)}, opts...)...)
+			assert.Equal(t, 1, reloaded.ClientCount())
+			assert.Len(t, reloaded.GetSubscriptionsFromClientID(clientID), 2)
+			_, e = reloaded.GetSubscriptionClient(otherClientID)
+			assert.NotNil(t, e)
+		})
+	}
+}
+
+func TestAPI_ImportFailed(t *testing.T) {
+	source := api.NewAPI()
+	_, e := source.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	var data bytes.Buffer
+	assert.Nil(t, source.Export(&data))
+
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &failingBackend{MemoryBackend: backend.NewMemoryBackend()}
+			log := audit.New(backend.NewMemoryBackend())
+			target := api.NewAPI(append([]api.Option{api.WithBackend(b), api.WithAuditLog(log)}, opts...)...)
+			otherClientID := uuid.New()
+			_, e := target.CreateSubscription(otherClientID, subscriberWithOneEventCheck)
+			assert.Nil(t, e)
+			before, e := target.QueryAudit(audit.Query{})
+			assert.Nil(t, e)
+
+			// nothing is applied or recorded when the import can't be persisted
+			b.fail = true
+			_, e = target.Import(bytes.NewReader(data.Bytes()), archive.Replace)
+			assert.NotNil(t, e)
+			assert.Equal(t, 1, target.ClientCount())
+			_, e = target.GetSubscriptionClient(otherClientID)
+			assert.Nil(t, e)
+			records, e := target.QueryAudit(audit.Query{})
+			assert.Nil(t, e)
+			assert.Equal(t, before, records)
+
+			b.fail = false
+			_, e = target.Import(bytes.NewReader(data.Bytes()), archive.Replace, api.AsActor("alice"))
+			assert.Nil(t, e)
+			records, e = target.QueryAudit(audit.Query{Kind: audit.KindClient, Since: before[len(before)-1].Time})
+			assert.Nil(t, e)
+			for _, r := range records[len(records)-2:] {
+				assert.Equal(t, "alice", r.Actor)
+			}
+			reloaded := api.NewAPI(append([]api.Option{api.WithBackend(b)}, opts...)...)
+			assert.Equal(t, 1, reloaded.ClientCount())
+			assert.Len(t, reloaded.GetSubscriptionsFromClientID(clientID), 2)
+		})
+	}
+}
+
+func TestAPI_ImportQuota(t *testing.T) {
+	tenantClient := func(id uuid.UUID, resources ...string) subscriber.Subscriber {
+		c := api.NewSubscriber(id)
+		_ = c.SetEndPointURI(subscriptionOne.EndPointURI.String())
+		c.SetNamespace("tenant")
+		c.SubStore.Store = map[string]*pubsub.PubSub{}
+		for _, r := range resources {
+			subID := uuid.New().String()
+			c.SubStore.Store[subID] = &pubsub.PubSub{ID: subID, EndPointURI: subscriptionOne.EndPointURI, Resource: r}
+		}
+		return c
+	}
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := append([]api.Option{api.WithStorePath(dir), api.WithQuota("tenant", store.Quota{MaxClients: 2, MaxSubscriptions: 2})}, opts...)
+			p := api.NewAPI(opts...)
+			stored := tenantClient(uuid.New(), "/tenant/1")
+			_, e := p.CreateSubscription(stored.ClientID, stored)
+			assert.Nil(t, e)
+
+			// the client with two subscriptions exceeds the subscription quota and is rejected
+			tooMany, fits := tenantClient(uuid.New(), "/tenant/2", "/tenant/3"), tenantClient(uuid.New(), "/tenant/4")
+			report, e := p.ImportFrom(&archive.Archive{Clients: []subscriber.Subscriber{tooMany, fits}}, archive.Merge)
+			assert.Nil(t, e)
+			assert.Equal(t, 1, report.Added)
+			if assert.Len(t, report.Conflicts, 1) {
+				assert.Equal(t, tooMany.ClientID.String(), report.Conflicts[0].ID)
+			}
+
+			// a merged subscription and a new client are rejected once the namespace is full
+			merged := tenantClient(stored.ClientID, "/tenant/5")
+			report, e = p.ImportFrom(&archive.Archive{Clients: []subscriber.Subscriber{merged, tenantClient(uuid.New())}}, archive.Merge)
+			assert.Nil(t, e)
+			assert.Len(t, report.Conflicts, 2)
+			assert.Equal(t, 0, report.Added+report.Updated)
+
+			reloaded := api.NewAPI(opts...)
+			assert.ElementsMatch(t, []uuid.UUID{stored.ClientID, fits.ClientID}, reloaded.GetClientIDsInNamespace("tenant"))
+			assert.Len(t, reloaded.GetSubscriptionsFromClientID(stored.ClientID), 1)
+		})
+	}
+}
+
+func TestAPI_BoltBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscribers.db")
+	b, e := backend.NewBoltBackend(path)
+	assert.Nil(t, e)
+	p := api.NewAPI(api.WithBackend(b))
+	for i := 0; i < 10; i++ {
+		_, e = p.CreateSubscription(uuid.New(), subscriberWithOneEventCheck)
+		assert.Nil(t, e)
+	}
+	assert.Nil(t, b.Close())
+
+	b, e = backend.NewBoltBackend(path)
+	assert.Nil(t, e)
+	defer b.Close()
+	reloaded := api.NewAPI(api.WithBackend(b))
+	assert.Equal(t, 10, reloaded.ClientCount())
+	n, e := reloaded.DeleteAllSubscriptions()
+	assert.Nil(t, e)
+	assert.Equal(t, 10, n)
+	assert.Equal(t, 0, reloaded.ClientCount())
+	keys, e := b.List()
+	assert.Nil(t, e)
+	assert.Empty(t, keys)
+}
+
+func TestAPI_AuditLog(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	b := backend.NewMemoryBackend()
+	log := audit.New(b, audit.WithClock(fakeClock))
+	p := api.NewAPI(api.WithBackend(b), api.WithClock(fakeClock), api.WithAuditLog(log))
+	_, e := p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	assert.Nil(t, p.DeleteSubscription(clientID, subscriptionTwoID))
+	for i := 0; i < p.FailCountThreshold(); i++ {
+		p.IncFailCountToFail(clientID)
+	}
+	assert.True(t, p.IncFailCountToFail(clientID))
+	assert.Nil(t, p.DeleteClient(clientID))
+
+	records, e := p.QueryAudit(audit.Query{ClientID: clientID.String()})
+	assert.Nil(t, e)
+	if assert.Len(t, records, 6) {
+		assert.Equal(t, audit.KindClient, records[0].Kind)
+		assert.Equal(t, audit.ActionCreate, records[1].Action)
+		assert.Equal(t, audit.ActionCreate, records[2].Action)
+		assert.Equal(t, audit.Record{Time: fakeClock.Now().UTC(), Action: audit.ActionDelete, Kind: audit.KindSubscription, ID: subscriptionTwoID,
+			ClientID: clientID.String(), Namespace: pubsub.DefaultNamespace, Resource: subscriptionTwo.Resource, Actor: audit.ActorAPI}, records[3])
+		assert.Equal(t, audit.ActionFail, records[4].Action)
+		assert.Equal(t, audit.Record{Time: fakeClock.Now().UTC(), Action: audit.ActionAutoDelete, Kind: audit.KindClient, ID: clientID.String(),
+			ClientID: clientID.String(), Namespace: pubsub.DefaultNamespace, Actor: audit.ActorSystem,
+			Reason: fmt.Sprintf("delivery failed %d times", p.FailCountThreshold()+1)}, records[5])
+	}
+
+	// expired leases
+	leased := subscriber.New(clientID)
+	assert.Nil(t, leased.SetEndPointURI("http://localhost:8080/health"))
+	leased.AddSubscription(*subscriptionOne)
+	leased.SetLeaseTTL(time.Minute)
+	_, e = p.CreateSubscription(clientID, *leased)
+	assert.Nil(t, e)
+	fakeClock.Step(2 * time.Minute)
+	assert.Equal(t, []uuid.UUID{clientID}, p.ReapExpiredSubscriptions())
+	records, e = p.QueryAudit(audit.Query{Actions: []audit.Action{audit.ActionAutoDelete}, Limit: 1})
+	assert.Nil(t, e)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, audit.ActorSystem, records[0].Actor)
+		assert.Contains(t, records[0].Reason, "lease expired at ")
+	}
+}
+
+// failingBackend fails every write while fail is set
+type failingBackend struct {
+	*backend.MemoryBackend
+	fail bool
+}
+
+func (b *failingBackend) Put(key string, value []byte) error {
+	if b.fail {
+		return errors.New("disk full")
+	}
+	return b.MemoryBackend.Put(key, value)
+}
+
+func (b *failingBackend) Append(key string, record []byte) error {
+	if b.fail {
+		return errors.New("disk full")
+	}
+	return backend.Append(b.MemoryBackend, key, record)
+}
+
+func (b *failingBackend) Delete(key string) error {
+	if b.fail {
+		return errors.New("disk full")
+	}
+	return b.MemoryBackend.Delete(key)
+}
+
+func TestAPI_AuditFailedDelete(t *testing.T) {
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &failingBackend{MemoryBackend: backend.NewMemoryBackend()}
+			log := audit.New(backend.NewMemoryBackend())
+			p := api.NewAPI(append([]api.Option{api.WithBackend(b), api.WithAuditLog(log)}, opts...)...)
+			_, e := p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+			assert.Nil(t, e)
+			created, e := p.QueryAudit(audit.Query{})
+			assert.Nil(t, e)
+
+			// nothing is deleted or recorded when the store can't be written
+			b.fail = true
+			assert.NotNil(t, p.DeleteSubscription(clientID, subscriptionTwoID))
+			assert.NotNil(t, p.DeleteClient(clientID))
+			client, ok := p.SubscriberStore.Get(clientID)
+			assert.True(t, ok)
+			assert.Len(t, client.SubStore.Store, 2)
+			records, e := p.QueryAudit(audit.Query{})
+			assert.Nil(t, e)
+			assert.Equal(t, created, records)
+
+			b.fail = false
+			assert.Nil(t, p.DeleteSubscription(clientID, subscriptionTwoID))
+			assert.Nil(t, p.DeleteClient(clientID))
+			records, e = p.QueryAudit(audit.Query{Actions: []audit.Action{audit.ActionDelete}})
+			assert.Nil(t, e)
+			assert.Len(t, records, 2)
+			assert.Empty(t, api.NewAPI(append([]api.Option{api.WithBackend(b)}, opts...)...).SubscriberStore.Store)
+		})
+	}
+}
+
+func TestAPI_AuditActorPerCall(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	log := audit.New(b)
+	p := api.NewAPI(api.WithBackend(b), api.WithAuditLog(log), api.WithAuditActor("operator"))
+	_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck, api.AsActor("alice"))
+	assert.Nil(t, e)
+	assert.Nil(t, p.DeleteClient(clientID, api.AsActor("bob")))
+	_, e = p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+
+	records, e := p.QueryAudit(audit.Query{ClientID: clientID.String(), Kind: audit.KindClient})
+	assert.Nil(t, e)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "alice", records[0].Actor)
+		assert.Equal(t, audit.ActionDelete, records[1].Action)
+		assert.Equal(t, "bob", records[1].Actor)
+		assert.Equal(t, "operator", records[2].Actor)
+	}
+}
+
+func TestAPI_HealthPolicy(t *testing.T) {
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fakeClock := clock.NewFakeClock(time.Now())
+			withoutPolicy := append([]api.Option{api.WithStorePath(t.TempDir()), api.WithClock(fakeClock)}, opts...)
+			opts := append([]api.Option{api.WithHealthPolicy(api.HealthPolicy{Persist: true})}, withoutPolicy...)
+			p := api.NewAPI(opts...)
+			_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+			assert.Nil(t, e)
+			p.ResetFailCount(clientID)
+			fakeClock.Step(time.Second)
+			for i := 0; i < 3; i++ {
+				p.IncFailCountToFail(clientID)
+			}
+
+			h, e := api.NewAPI(opts...).GetHealth(clientID)
+			assert.Nil(t, e)
+			assert.Equal(t, 3, h.Failures)
+			assert.Equal(t, subscriber.Active, h.Status)
+			assert.True(t, h.LastFailure.Equal(fakeClock.Now()))
+			assert.True(t, h.LastSuccess.Equal(fakeClock.Now().Add(-time.Second)))
+
+			for i := 3; i < p.FailCountThreshold(); i++ {
+				p.IncFailCountToFail(clientID)
+			}
+			reloaded := api.NewAPI(opts...)
+			assert.True(t, reloaded.SubscriberMarkedForDelete(clientID))
+			h, _ = reloaded.GetHealth(clientID)
+			assert.Equal(t, subscriber.InActive, h.Status)
+
+			// a new subscription makes the client active again
+			_, e = p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+			assert.Nil(t, e)
+			reloaded = api.NewAPI(opts...)
+			assert.False(t, reloaded.SubscriberMarkedForDelete(clientID))
+			assert.Equal(t, 0, reloaded.GetFailCount(clientID))
+
+			// without the policy every client is active after a restart
+			for i := 0; i < p.FailCountThreshold(); i++ {
+				p.IncFailCountToFail(clientID)
+			}
+			reloaded = api.NewAPI(withoutPolicy...)
+			assert.False(t, reloaded.SubscriberMarkedForDelete(clientID))
+			h, _ = reloaded.GetHealth(clientID)
+			assert.Equal(t, subscriber.Health{Status: subscriber.Active}, h)
+		})
+	}
+}
+
+func TestAPI_HealthPolicyWriteInterval(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	b := backend.NewMemoryBackend()
+	opts := []api.Option{api.WithBackend(b), api.WithClock(fakeClock), api.WithHealthPolicy(api.HealthPolicy{Persist: true, MinWriteInterval: time.Minute})}
+	p := api.NewAPI(opts...)
+	_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	p.IncFailCountToFail(clientID)
+	p.IncFailCountToFail(clientID)
+	assert.Equal(t, 1, api.NewAPI(opts...).GetFailCount(clientID))
+	fakeClock.Step(time.Minute)
+	p.IncFailCountToFail(clientID)
+	assert.Equal(t, 3, api.NewAPI(opts...).GetFailCount(clientID))
+}
+
+func TestAPI_FailurePolicy(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	for name, tc := range map[string]struct {
+		policy   subscriber.FailurePolicy
+		deliver  func(p *api.API)
+		exceeded bool
+	}{
+		"consecutive failures": {
+			policy: subscriber.ConsecutiveFailures(3),
+			deliver: func(p *api.API) {
+				p.IncFailCountToFail(clientID)
+				p.IncFailCountToFail(clientID)
+			},
+			exceeded: true,
+		},
+		"consecutive failures reset by a success": {
+			policy: subscriber.ConsecutiveFailures(3),
+			deliver: func(p *api.API) {
+				p.IncFailCountToFail(clientID)
+				p.ResetFailCount(clientID)
+				p.IncFailCountToFail(clientID)
+			},
+		},
+		"failure rate": {
+			policy: subscriber.FailureRate{Window: time.Minute, MinDeliveries: 4, MaxRate: 0.5},
+			deliver: func(p *api.API) {
+				p.IncFailCountToFail(clientID)
+				p.ResetFailCount(clientID)
+				p.IncFailCountToFail(clientID)
+			},
+			exceeded: true,
+		},
+		"failure rate outside the window": {
+			policy: subscriber.FailureRate{Window: time.Minute, MinDeliveries: 4, MaxRate: 0.5},
+			deliver: func(p *api.API) {
+				p.IncFailCountToFail(clientID)
+				p.ResetFailCount(clientID)
+				p.IncFailCountToFail(clientID)
+				fakeClock.Step(2 * time.Minute)
+				p.ResetFailCount(clientID)
+			},
+		},
+		"no success within": {
+			policy: subscriber.AnyOf(subscriber.ConsecutiveFailures(10), subscriber.NoSuccessWithin(time.Minute)),
+			deliver: func(p *api.API) {
+				p.ResetFailCount(clientID)
+				fakeClock.Step(time.Minute)
+			},
+			exceeded: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := api.NewAPI(api.WithClock(fakeClock), api.WithFailurePolicy(tc.policy))
+			_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+			assert.Nil(t, e)
+			tc.deliver(p)
+			assert.Equal(t, tc.exceeded, p.IncFailCountToFail(clientID))
+			assert.Equal(t, tc.exceeded, p.SubscriberMarkedForDelete(clientID))
+		})
+	}
+}
+
+func TestAPI_Quarantine(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	b := backend.NewMemoryBackend()
+	log := audit.New(b, audit.WithClock(fakeClock))
+	opts := []api.Option{api.WithBackend(b), api.WithClock(fakeClock), api.WithAuditLog(log), api.WithFailurePolicy(subscriber.ConsecutiveFailures(2)),
+		api.WithQuarantine(time.Minute), api.WithHealthPolicy(api.HealthPolicy{Persist: true})}
+	p := api.NewAPI(opts...)
+	_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	assert.False(t, p.IncFailCountToFail(clientID))
+	assert.False(t, p.IncFailCountToFail(clientID))
+	assert.False(t, p.DeliveryAllowed(clientID))
+	h, _ := p.GetHealth(clientID)
+	assert.Equal(t, subscriber.Quarantined, h.Status)
+
+	// the quarantine survives a restart
+	reloaded := api.NewAPI(opts...)
+	assert.False(t, reloaded.DeliveryAllowed(clientID))
+
+	// a successful delivery after the quarantine reactivates the client
+	fakeClock.Step(time.Minute)
+	assert.True(t, p.DeliveryAllowed(clientID))
+	p.ResetFailCount(clientID)
+	h, _ = p.GetHealth(clientID)
+	assert.Equal(t, subscriber.Health{Status: subscriber.Active, LastSuccess: h.LastSuccess, LastFailure: h.LastFailure}, h)
+
+	// a failed one deletes it
+	p.IncFailCountToFail(clientID)
+	p.IncFailCountToFail(clientID)
+	fakeClock.Step(time.Minute)
+	assert.True(t, p.IncFailCountToFail(clientID))
+	assert.False(t, p.DeliveryAllowed(clientID))
+	records, e := p.QueryAudit(audit.Query{Actions: []audit.Action{audit.ActionQuarantine, audit.ActionFail}})
+	assert.Nil(t, e)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, audit.ActionQuarantine, records[0].Action)
+		assert.Equal(t, audit.ActionQuarantine, records[1].Action)
+		assert.Equal(t, audit.ActionFail, records[2].Action)
+	}
+}
+
+func TestAPI_Backoff(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	p := api.NewAPI(api.WithClock(fakeClock), api.WithBackoff(time.Second, 4*time.Second, 2, 0))
+	_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	assert.True(t, p.DeliveryAllowed(clientID))
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		p.IncFailCountToFail(clientID)
+		assert.False(t, p.DeliveryAllowed(clientID))
+		fakeClock.Step(backoff - time.Millisecond)
+		assert.False(t, p.DeliveryAllowed(clientID))
+		fakeClock.Step(time.Millisecond)
+		assert.True(t, p.DeliveryAllowed(clientID))
+	}
+
+	// a successful delivery resets the backoff
+	p.ResetFailCount(clientID)
+	p.IncFailCountToFail(clientID)
+	fakeClock.Step(time.Second)
+	assert.True(t, p.DeliveryAllowed(clientID))
+}
+
+func TestAPI_Encryption(t *testing.T) {
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			keys, e := encryption.NewFileKeyProvider(filepath.Join(t.TempDir(), "keys.json"))
+			assert.Nil(t, e)
+			opts := append([]api.Option{api.WithStorePath(dir), api.WithEncryption(keys)}, opts...)
+			p := api.NewAPI(opts...)
+			_, e = p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+			assert.Nil(t, e)
+			assert.Nil(t, p.DeleteSubscription(clientID, subscriptionTwoID))
+
+			files, e := os.ReadDir(dir)
+			assert.Nil(t, e)
+			assert.NotEmpty(t, files)
+			for _, f := range files {
+				raw, e := os.ReadFile(filepath.Join(dir, f.Name()))
+				assert.Nil(t, e)
+				assert.NotContains(t, string(raw), "localhost", f.Name())
+			}
+
+			reloaded := api.NewAPI(opts...)
+			c, e := reloaded.GetSubscriptionClient(clientID)
+			assert.Nil(t, e)
+			assert.Len(t, c.SubStore.Store, 1)
+			assert.Equal(t, subscriptionOne.Resource, c.Get(subscriptionOneID).Resource)
+		})
+	}
+}
+
+func TestAPI_EncryptedBackupCheck(t *testing.T) {
+	dir := t.TempDir()
+	keys, e := encryption.NewFileKeyProvider(filepath.Join(t.TempDir(), "keys.json"))
+	assert.Nil(t, e)
+	p := api.NewAPI(api.WithStorePath(dir), api.WithEncryption(keys))
+	_, e = p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+	assert.Nil(t, e)
+	assert.Nil(t, p.DeleteSubscription(clientID, subscriptionTwoID))
+
+	// a torn client file does not replace the backup holding both subscriptions
+	path := filepath.Join(dir, clientID.String()+".json")
+	raw, e := os.ReadFile(path)
+	assert.Nil(t, e)
+	assert.Nil(t, os.WriteFile(path, raw[:len(raw)/2], backend.DefaultFileMode))
+	assert.Nil(t, p.DeleteSubscription(clientID, subscriptionOneID))
+	backup, e := encryption.NewBackend(backend.NewFileBackend(dir), keys).GetBackup(clientID.String() + ".json")
+	assert.Nil(t, e)
+	assert.Contains(t, string(backup), subscriptionTwoID)
+}
+
+func TestAPI_Namespaces(t *testing.T) {
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := append([]api.Option{api.WithStorePath(dir), api.WithQuota("tenant", store.Quota{MaxClients: 1, MaxSubscriptions: 2})}, opts...)
+			p := api.NewAPI(opts...)
+			_, e := p.CreateSubscription(clientID, subscriberWithManyEventCheck)
+			assert.Nil(t, e)
+
+			tenantClientID := uuid.New()
+			tenantClient := api.NewSubscriber(tenantClientID)
+			_ = tenantClient.SetEndPointURI(subscriptionOne.EndPointURI.String())
+			tenantClient.SetNamespace("tenant")
+			tenantClient.SubStore.Store = map[string]*pubsub.PubSub{
+				subscriptionOneID: {ID: subscriptionOneID, EndPointURI: subscriptionOne.EndPointURI, Resource: "/tenant/1"},
+			}
+			c, e := p.CreateSubscription(tenantClientID, tenantClient)
+			assert.Nil(t, e)
+			sub := c.Get(subscriptionOneID)
+			assert.Equal(t, "tenant", sub.GetNamespace())
+
+			// quotas of the tenant namespace
+			other := tenantClient
+			other.ClientID = uuid.New()
+			_, e = p.CreateSubscription(other.ClientID, other)
+			assert.True(t, errors.Is(e, store.ErrQuotaExceeded))
+			tenantClient.SubStore.Store = map[string]*pubsub.PubSub{
+				"a": {EndPointURI: subscriptionOne.EndPointURI, Resource: "/tenant/2"},
+				"b": {EndPointURI: subscriptionOne.EndPointURI, Resource: "/tenant/3"},
+			}
+			_, e = p.CreateSubscription(tenantClientID, tenantClient)
+			assert.True(t, errors.Is(e, store.ErrQuotaExceeded))
+			assert.Len(t, p.GetSubscriptionsFromClientID(tenantClientID), 1)
+
+			// a client can't change its namespace
+			_, e = p.CreateSubscription(clientID, tenantClient)
+			assert.NotNil(t, e)
+
+			reloaded := api.NewAPI(opts...)
+			assert.Equal(t, []uuid.UUID{tenantClientID}, reloaded.GetClientIDsInNamespace("tenant"))
+			assert.Equal(t, []uuid.UUID{clientID}, reloaded.GetClientIDsInNamespace(pubsub.DefaultNamespace))
+			n, e := reloaded.DeleteAllSubscriptionsInNamespace("tenant")
+			assert.Nil(t, e)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, 1, reloaded.ClientCount())
+		})
+	}
+}
+
+// withoutStoreMetadata clears the creation time and revision set by the store to compare with the fixtures
+func withoutStoreMetadata(sub *pubsub.PubSub) *pubsub.PubSub {
+	if sub == nil {
+		return nil
+	}
+	c := *sub
+	c.CreatedAt = time.Time{}
+	c.Revision = 0
+	return &c
+}
+
 func clean() {
 	globalInstance.DeleteAllSubscriptionsForClient(clientID) //nolint
 }
 
 func TestTeardown(*testing.T) {
 	_ = os.Remove(fmt.Sprintf("%s/%s.json", storePath, clientID))
+	_ = os.Remove(fmt.Sprintf("%s/%s.json.bak", storePath, clientID))
+}
+
+func TestAPI_Reconcile(t *testing.T) {
+	for name, opts := range map[string][]api.Option{
+		"file":    nil,
+		"journal": {api.WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := append([]api.Option{api.WithBackend(backend.NewMemoryBackend())}, opts...)
+			p := api.NewAPI(opts...)
+			_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+			assert.Nil(t, e)
+			p.IncFailCountToFail(clientID)
+			report, e := p.Reconcile()
+			assert.Nil(t, e)
+			assert.Equal(t, archive.Report{Unchanged: 1}, report)
+
+			// another process changes the store
+			other := api.NewAPI(opts...)
+			otherID := uuid.New()
+			client := subscriber.New(otherID)
+			assert.Nil(t, client.SetEndPointURI("http://localhost:8080/health"))
+			client.AddSubscription(*subscriptionTwo)
+			_, e = other.CreateSubscription(otherID, *client)
+			assert.Nil(t, e)
+			assert.Nil(t, other.DeleteSubscription(clientID, subscriptionOneID))
+			report, e = p.Reconcile()
+			assert.Nil(t, e)
+			assert.Equal(t, archive.Report{Added: 1, Updated: 1}, report)
+			assert.Equal(t, 2, p.ClientCount())
+			_, ok := p.HasSubscription(clientID, subscriptionOne.Resource)
+			assert.False(t, ok)
+			// the health is not persisted, the one in memory is kept
+			h, e := p.GetHealth(clientID)
+			assert.Nil(t, e)
+			assert.Equal(t, 1, h.Failures)
+
+			assert.Nil(t, other.DeleteClient(clientID))
+			report, e = p.Reconcile()
+			assert.Nil(t, e)
+			assert.Equal(t, archive.Report{Removed: 1, Unchanged: 1}, report)
+			assert.Equal(t, 1, p.ClientCount())
+		})
+	}
+	assert.ErrorIs(t, api.NewAPI(api.WithBackend(backend.NewMemoryBackend())).StartHotReload(make(chan struct{})),
+		backend.ErrExternalWatchUnsupported)
+}
+
+func TestAPI_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	reports := make(chan archive.Report, 10)
+	p := api.NewAPI(api.WithStorePath(dir), api.WithReloadNotifier(reports))
+	_, e := p.CreateSubscription(clientID, subscriberWithOneEventCheck)
+	assert.Nil(t, e)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := p.Watch(ctx)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	assert.Nil(t, p.StartHotReload(stopCh))
+
+	assert.Nil(t, api.NewAPI(api.WithStorePath(dir)).DeleteClient(clientID))
+	select {
+	case report := <-reports:
+		assert.Equal(t, archive.Report{Removed: 1}, report)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the store to be reloaded")
+	}
+	assert.Equal(t, 0, p.ClientCount())
+	event := <-events
+	assert.Equal(t, store.Deleted, event.Type)
+	assert.Equal(t, clientID, event.ClientID)
 }